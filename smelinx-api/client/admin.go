@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+/* -------------------- manifest sync -------------------- */
+
+func syncQuery(prune bool) url.Values {
+	if !prune {
+		return nil
+	}
+	return url.Values{"prune": {"true"}}
+}
+
+// PlanSync shows the changes applying a smelinx.yaml manifest would make.
+// With prune, APIs and versions missing from the manifest are deleted.
+func (c *Client) PlanSync(ctx context.Context, manifest []byte, prune bool) (*SyncResult, error) {
+	var out SyncResult
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/sync/plan", query: syncQuery(prune),
+		raw: manifest, contentType: "application/yaml"}, &out)
+}
+
+// ApplySync brings the org in line with a manifest.
+func (c *Client) ApplySync(ctx context.Context, manifest []byte, prune bool) (*SyncResult, error) {
+	var out SyncResult
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/sync/apply", query: syncQuery(prune),
+		raw: manifest, contentType: "application/yaml"}, &out)
+}
+
+/* -------------------- export / import -------------------- */
+
+// ExportOrg downloads the org as a portable bundle, as JSON or (yaml) YAML.
+func (c *Client) ExportOrg(ctx context.Context, orgID string, yaml bool) ([]byte, error) {
+	req := request{method: http.MethodGet, path: p("orgs", orgID, "export")}
+	if yaml {
+		req.query = url.Values{"format": {"yaml"}}
+		req.accept = "application/yaml"
+	}
+	_, body, err := c.send(ctx, req)
+	return body, err
+}
+
+// ImportOptions control ImportOrg.
+type ImportOptions struct {
+	DryRun        bool // report what would happen without writing
+	SkipConflicts bool // keep existing records instead of refusing the import
+	YAML          bool // the bundle is YAML
+}
+
+// ImportOrg loads a bundle from ExportOrg into the org under new IDs. On
+// conflicts it returns ErrConflict; the report is in the error's Body.
+func (c *Client) ImportOrg(ctx context.Context, orgID string, bundle []byte, opts ImportOptions) (*ImportReport, error) {
+	q := url.Values{}
+	if opts.DryRun {
+		q.Set("dry_run", "true")
+	}
+	if opts.SkipConflicts {
+		q.Set("on_conflict", "skip")
+	}
+	contentType := "application/json"
+	if opts.YAML {
+		contentType = "application/yaml"
+	}
+	var out ImportReport
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("orgs", orgID, "import"), query: q,
+		raw: bundle, contentType: contentType}, &out)
+}
+
+/* -------------------- instance admin -------------------- */
+
+// ListBackups lists SQLite backups (instance admins only).
+func (c *Client) ListBackups(ctx context.Context) ([]Backup, error) {
+	var out []Backup
+	return out, c.do(ctx, request{method: http.MethodGet, path: "/admin/backups"}, &out)
+}
+
+// CreateBackup takes a backup now (instance admins only).
+func (c *Client) CreateBackup(ctx context.Context) (*Backup, error) {
+	var out Backup
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/admin/backups"}, &out)
+}
@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+/* -------------------- APIs -------------------- */
+
+// ListAPIs lists the org's APIs, newest first unless opts.Sort says otherwise.
+func (c *Client) ListAPIs(ctx context.Context, opts *APIListOptions) (*Page[API], error) {
+	return getPage[API](ctx, c, "/apis", opts.values())
+}
+
+func (c *Client) CreateAPI(ctx context.Context, req CreateAPIRequest) (*API, error) {
+	var out API
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/apis", in: req}, &out)
+}
+
+func (c *Client) GetAPI(ctx context.Context, apiID string) (*API, error) {
+	var out API
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("apis", apiID)}, &out)
+}
+
+// UpdateAPI changes an API still at revision (see the package doc).
+func (c *Client) UpdateAPI(ctx context.Context, apiID string, revision int64, req UpdateAPIRequest) (*API, error) {
+	var out API
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("apis", apiID), in: req, header: ifMatch(revision)}, &out)
+}
+
+// DeleteAPI soft-deletes an API still at revision.
+func (c *Client) DeleteAPI(ctx context.Context, apiID string, revision int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("apis", apiID), header: ifMatch(revision)}, nil)
+}
+
+// AutoNotify reports whether version changes of the API enqueue notices.
+func (c *Client) AutoNotify(ctx context.Context, apiID string) (bool, error) {
+	var out struct{ Enabled bool }
+	err := c.do(ctx, request{method: http.MethodGet, path: p("apis", apiID, "auto-notify")}, &out)
+	return out.Enabled, err
+}
+
+func (c *Client) SetAutoNotify(ctx context.Context, apiID string, enabled bool) error {
+	return c.do(ctx, request{method: http.MethodPut, path: p("apis", apiID, "auto-notify"), in: map[string]bool{"enabled": enabled}}, nil)
+}
+
+// APIFeed returns the API's public changelog feed settings.
+func (c *Client) APIFeed(ctx context.Context, apiID string) (*FeedSettings, error) {
+	var out FeedSettings
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("apis", apiID, "feed")}, &out)
+}
+
+func (c *Client) SetAPIFeed(ctx context.Context, apiID string, enabled bool) (*FeedSettings, error) {
+	var out FeedSettings
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("apis", apiID, "feed"), in: map[string]bool{"enabled": enabled}}, &out)
+}
+
+/* -------------------- versions -------------------- */
+
+func (c *Client) ListVersions(ctx context.Context, apiID string, opts *VersionListOptions) (*Page[Version], error) {
+	return getPage[Version](ctx, c, p("apis", apiID, "versions"), opts.values())
+}
+
+func (c *Client) CreateVersion(ctx context.Context, apiID string, req CreateVersionRequest) (*Version, error) {
+	var out Version
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("apis", apiID, "versions"), in: req}, &out)
+}
+
+func (c *Client) GetVersion(ctx context.Context, versionID string) (*Version, error) {
+	var out Version
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("versions", versionID)}, &out)
+}
+
+// UpdateVersion changes a version still at revision.
+func (c *Client) UpdateVersion(ctx context.Context, versionID string, revision int64, req UpdateVersionRequest) (*Version, error) {
+	var out Version
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("versions", versionID), in: req, header: ifMatch(revision)}, &out)
+}
+
+// DeprecateVersion marks a version deprecated with a sunset date (YYYY-MM-DD).
+func (c *Client) DeprecateVersion(ctx context.Context, versionID string, revision int64, sunsetDate string) (*Version, error) {
+	return c.UpdateVersion(ctx, versionID, revision, UpdateVersionRequest{Status: "deprecated", SunsetDate: &sunsetDate})
+}
+
+func (c *Client) DeleteVersion(ctx context.Context, versionID string, revision int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("versions", versionID), header: ifMatch(revision)}, nil)
+}
+
+/* -------------------- notifications -------------------- */
+
+func (c *Client) ListNotifications(ctx context.Context, apiID string, opts *NotificationListOptions) (*Page[Notification], error) {
+	return getPage[Notification](ctx, c, p("apis", apiID, "notifications"), opts.values())
+}
+
+func (c *Client) CreateNotification(ctx context.Context, apiID string, req CreateNotificationRequest) (*Notification, error) {
+	var out Notification
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("apis", apiID, "notifications"), in: req}, &out)
+}
+
+// PreviewNotification renders the notice the dispatcher would send.
+func (c *Client) PreviewNotification(ctx context.Context, apiID string, req PreviewNotificationRequest) (*RenderedMessage, error) {
+	var out RenderedMessage
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("apis", apiID, "notifications", "preview"), in: req}, &out)
+}
+
+func (c *Client) GetNotification(ctx context.Context, notificationID string) (*Notification, error) {
+	var out Notification
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("notifications", notificationID)}, &out)
+}
+
+// SetNotificationStatus sets a notice still at revision to pending, sent
+// or canceled.
+func (c *Client) SetNotificationStatus(ctx context.Context, notificationID string, revision int64, status string) (*Notification, error) {
+	var out Notification
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("notifications", notificationID), in: map[string]string{"status": status}, header: ifMatch(revision)}, &out)
+}
+
+func (c *Client) CancelNotification(ctx context.Context, notificationID string, revision int64) (*Notification, error) {
+	return c.SetNotificationStatus(ctx, notificationID, revision, "canceled")
+}
+
+// TestSendNotification emails the notice to the caller.
+func (c *Client) TestSendNotification(ctx context.Context, notificationID string) (*SentTo, error) {
+	var out SentTo
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("notifications", notificationID, "test-send")}, &out)
+}
+
+func (c *Client) ListNotificationDeliveries(ctx context.Context, notificationID string) ([]Delivery, error) {
+	var out []Delivery
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("notifications", notificationID, "deliveries")}, &out)
+}
+
+/* -------------------- acknowledgements -------------------- */
+
+func (c *Client) VersionAcknowledgements(ctx context.Context, versionID string) (*AckReport, error) {
+	var out AckReport
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("versions", versionID, "acknowledgements")}, &out)
+}
+
+// SetAcknowledgement records a consumer as acknowledged or migrated.
+func (c *Client) SetAcknowledgement(ctx context.Context, versionID, consumerID, status string) (*Acknowledgement, error) {
+	var out Acknowledgement
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("versions", versionID, "acknowledgements", consumerID), in: map[string]string{"status": status}}, &out)
+}
+
+func (c *Client) DeleteAcknowledgement(ctx context.Context, versionID, consumerID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("versions", versionID, "acknowledgements", consumerID)}, nil)
+}
+
+/* -------------------- channels -------------------- */
+
+func (c *Client) ListAPIChannels(ctx context.Context, apiID string) ([]Channel, error) {
+	var out []Channel
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("apis", apiID, "channels")}, &out)
+}
+
+func (c *Client) CreateAPIChannel(ctx context.Context, apiID string, req CreateChannelRequest) (*Channel, error) {
+	var out Channel
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("apis", apiID, "channels"), in: req}, &out)
+}
+
+func (c *Client) ListConsumerChannels(ctx context.Context, consumerID string) ([]Channel, error) {
+	var out []Channel
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("consumers", consumerID, "channels")}, &out)
+}
+
+func (c *Client) CreateConsumerChannel(ctx context.Context, consumerID string, req CreateChannelRequest) (*Channel, error) {
+	var out Channel
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("consumers", consumerID, "channels"), in: req}, &out)
+}
+
+func (c *Client) DeleteChannel(ctx context.Context, channelID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("channels", channelID)}, nil)
+}
+
+/* -------------------- consumers -------------------- */
+
+func (c *Client) ListConsumers(ctx context.Context) ([]Consumer, error) {
+	var out []Consumer
+	return out, c.do(ctx, request{method: http.MethodGet, path: "/consumers"}, &out)
+}
+
+func (c *Client) CreateConsumer(ctx context.Context, req ConsumerRequest) (*Consumer, error) {
+	var out Consumer
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/consumers", in: req}, &out)
+}
+
+func (c *Client) GetConsumer(ctx context.Context, consumerID string) (*Consumer, error) {
+	var out Consumer
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("consumers", consumerID)}, &out)
+}
+
+func (c *Client) UpdateConsumer(ctx context.Context, consumerID string, req ConsumerRequest) (*Consumer, error) {
+	var out Consumer
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("consumers", consumerID), in: req}, &out)
+}
+
+func (c *Client) DeleteConsumer(ctx context.Context, consumerID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("consumers", consumerID)}, nil)
+}
+
+// ListAPIConsumers lists the consumers subscribed to an API.
+func (c *Client) ListAPIConsumers(ctx context.Context, apiID string) ([]Consumer, error) {
+	var out []Consumer
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("apis", apiID, "consumers")}, &out)
+}
+
+// SubscribeConsumer subscribes a consumer to an API's notices.
+func (c *Client) SubscribeConsumer(ctx context.Context, apiID, consumerID string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: p("apis", apiID, "consumers", consumerID)}, nil)
+}
+
+func (c *Client) UnsubscribeConsumer(ctx context.Context, apiID, consumerID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("apis", apiID, "consumers", consumerID)}, nil)
+}
+
+// ConsumerCalendarURL is the consumer's calendar subscription URL.
+func (c *Client) ConsumerCalendarURL(ctx context.Context, consumerID string) (string, error) {
+	var out struct{ URL string }
+	err := c.do(ctx, request{method: http.MethodGet, path: p("consumers", consumerID, "calendar")}, &out)
+	return out.URL, err
+}
+
+func (c *Client) ConsumerPreferences(ctx context.Context, consumerID string) (*ConsumerPreferences, error) {
+	var out ConsumerPreferences
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("consumers", consumerID, "preferences")}, &out)
+}
+
+func (c *Client) UpdateConsumerPreferences(ctx context.Context, consumerID string, req PreferencesRequest) (*ConsumerPreferences, error) {
+	var out ConsumerPreferences
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("consumers", consumerID, "preferences"), in: req}, &out)
+}
+
+/* -------------------- suppressions -------------------- */
+
+func (c *Client) ListSuppressions(ctx context.Context) ([]Suppression, error) {
+	var out []Suppression
+	return out, c.do(ctx, request{method: http.MethodGet, path: "/suppressions"}, &out)
+}
+
+// DeleteSuppression lets notices reach the address again.
+func (c *Client) DeleteSuppression(ctx context.Context, email string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("suppressions", email)}, nil)
+}
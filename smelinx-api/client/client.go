@@ -0,0 +1,222 @@
+// Package client is a typed Go client for the Smelinx API.
+//
+//	c := client.New("https://api.smelinx.com", client.WithToken(os.Getenv("SMELINX_TOKEN")))
+//	api, err := c.CreateAPI(ctx, client.CreateAPIRequest{Name: "Payments"})
+//	v, err := c.CreateVersion(ctx, api.ID, client.CreateVersionRequest{Version: "v2"})
+//
+// Authenticate with a personal access token (WithToken), or call Login to
+// use a session cookie. Failed requests return *Error; test for a kind with
+// errors.Is(err, client.ErrNotFound) and the like. Requests rejected by the
+// rate limiter (429) are retried after X-RateLimit-Reset.
+//
+// Updates and deletes of APIs, versions and notifications take the
+// revision the caller last read and fail with ErrPreconditionFailed when
+// someone else changed the object since; revision 0 overwrites regardless.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls one Smelinx server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	userAgent  string
+	http       *http.Client
+	maxRetries int
+	maxWait    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken authenticates every request with a personal access token.
+func WithToken(token string) Option { return func(c *Client) { c.token = token } }
+
+// WithHTTPClient replaces the default HTTP client (30s timeout, with a
+// cookie jar for Login). Give it a Jar to use Login.
+func WithHTTPClient(h *http.Client) Option { return func(c *Client) { c.http = h } }
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(ua string) Option { return func(c *Client) { c.userAgent = ua } }
+
+// WithRetries sets how often a rate-limited request is retried (default 3)
+// and the longest single wait (default 1m); 0 retries disables retrying.
+func WithRetries(n int, maxWait time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.maxWait = n, maxWait }
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "https://api.smelinx.com".
+func New(baseURL string, opts ...Option) *Client {
+	jar, _ := cookiejar.New(nil)
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		userAgent:  "smelinx-go",
+		http:       &http.Client{Timeout: 30 * time.Second, Jar: jar},
+		maxRetries: 3,
+		maxWait:    time.Minute,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// BaseURL is the server the client talks to.
+func (c *Client) BaseURL() string { return c.baseURL }
+
+/* -------------------- requests -------------------- */
+
+// request is one call: a JSON body (in) or a raw one (raw + contentType).
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	in          any
+	raw         []byte
+	contentType string
+	accept      string
+	header      http.Header
+}
+
+// do sends req and decodes a JSON response into out (if not nil).
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	_, body, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("smelinx: decode %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send performs req, retrying while the server answers 429, and returns
+// the response with its body read. Non-2xx responses are *Error.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, []byte, error) {
+	payload := req.raw
+	contentType := req.contentType
+	if req.in != nil {
+		var err error
+		if payload, err = json.Marshal(req.in); err != nil {
+			return nil, nil, err
+		}
+		contentType = "application/json"
+	}
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		hr, err := http.NewRequestWithContext(ctx, req.method, u, body)
+		if err != nil {
+			return nil, nil, err
+		}
+		hr.Header.Set("Accept", firstNonEmpty(req.accept, "application/json"))
+		hr.Header.Set("User-Agent", c.userAgent)
+		if contentType != "" {
+			hr.Header.Set("Content-Type", contentType)
+		}
+		if c.token != "" {
+			hr.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		for k, v := range req.header {
+			hr.Header[k] = v
+		}
+
+		resp, err := c.http.Do(hr)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.maxRetries {
+			if err := sleep(ctx, c.retryDelay(resp, attempt)); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return resp, raw, newError(req.method, req.path, resp, raw)
+		}
+		return resp, raw, nil
+	}
+}
+
+// retryDelay is how long to wait before retrying a 429: until the window
+// in X-RateLimit-Reset ends, else Retry-After, else a backoff. Both are
+// whole seconds rounded down, so a second is added.
+func (c *Client) retryDelay(resp *http.Response, attempt int) time.Duration {
+	wait := time.Duration(1<<attempt) * time.Second
+	for _, h := range []string{"X-RateLimit-Reset", "Retry-After"} {
+		if n, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get(h))); err == nil && n >= 0 {
+			wait = time.Duration(n+1) * time.Second
+			break
+		}
+	}
+	if c.maxWait > 0 && wait > c.maxWait {
+		wait = c.maxWait
+	}
+	return wait
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// p builds a path from segments, escaping each one.
+func p(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// ifMatch is the If-Match header for a revision; 0 matches any.
+func ifMatch(revision int64) http.Header {
+	if revision == 0 {
+		return http.Header{"If-Match": {"*"}}
+	}
+	return http.Header{"If-Match": {`"` + strconv.FormatInt(revision, 10) + `"`}}
+}
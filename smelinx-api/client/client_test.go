@@ -0,0 +1,267 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// server starts an httptest server with h and a client for it.
+func server(t *testing.T, h http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return New(srv.URL, append([]Option{WithToken("smx_pat_test")}, opts...)...)
+}
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string, extra map[string]any) {
+	p := map[string]any{"type": "about:blank", "title": http.StatusText(status), "status": status, "code": code, "detail": detail}
+	for k, v := range extra {
+		p[k] = v
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+/* -------------------- retries -------------------- */
+
+func TestRetryDelay(t *testing.T) {
+	c := New("http://x", WithRetries(3, 30*time.Second))
+	cases := []struct {
+		name    string
+		headers map[string]string
+		attempt int
+		want    time.Duration
+	}{
+		{"reset window", map[string]string{"X-RateLimit-Reset": "4"}, 0, 5 * time.Second},
+		{"reset wins over Retry-After", map[string]string{"X-RateLimit-Reset": "2", "Retry-After": "20"}, 0, 3 * time.Second},
+		{"Retry-After", map[string]string{"Retry-After": "7"}, 0, 8 * time.Second},
+		{"backoff", nil, 2, 4 * time.Second},
+		{"garbage header backs off", map[string]string{"X-RateLimit-Reset": "soon"}, 1, 2 * time.Second},
+		{"capped", map[string]string{"X-RateLimit-Reset": "3600"}, 0, 30 * time.Second},
+	}
+	for _, tc := range cases {
+		resp := &http.Response{Header: http.Header{}}
+		for k, v := range tc.headers {
+			resp.Header.Set(k, v)
+		}
+		if got := c.retryDelay(resp, tc.attempt); got != tc.want {
+			t.Errorf("%s: retryDelay = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRetriesRateLimited(t *testing.T) {
+	var calls atomic.Int32
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("X-RateLimit-Reset", "0")
+			writeProblem(w, http.StatusTooManyRequests, "rate_limited", "slow down", nil)
+			return
+		}
+		json.NewEncoder(w).Encode(API{ID: "a1", Name: "Payments"})
+	}, WithRetries(3, 10*time.Millisecond))
+
+	api, err := c.GetAPI(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("GetAPI: %v", err)
+	}
+	if api.Name != "Payments" || calls.Load() != 3 {
+		t.Errorf("got %q after %d calls, want Payments after 3", api.Name, calls.Load())
+	}
+}
+
+func TestRateLimitedGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-RateLimit-Reset", "0")
+		writeProblem(w, http.StatusTooManyRequests, "rate_limited", "slow down", nil)
+	}, WithRetries(2, 10*time.Millisecond))
+
+	_, err := c.GetAPI(context.Background(), "a1")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("%d calls, want the request and 2 retries", calls.Load())
+	}
+}
+
+/* -------------------- errors -------------------- */
+
+func TestProblemErrors(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-1")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/apis/missing"):
+			writeProblem(w, http.StatusNotFound, "not_found", "no such API", map[string]any{"request_id": "req-2"})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/apis/"):
+			writeProblem(w, http.StatusPreconditionFailed, "precondition_failed", "modified since you read it", map[string]any{"revision": 3})
+		case r.URL.Path == "/apis":
+			writeProblem(w, http.StatusBadRequest, "validation_failed", "", map[string]any{
+				"errors": []FieldError{{Field: "name", Code: "required", Message: "is required"}},
+			})
+		default:
+			http.Error(w, "boom", http.StatusBadGateway)
+		}
+	})
+	ctx := context.Background()
+
+	_, err := c.GetAPI(ctx, "missing")
+	var e *Error
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &e) {
+		t.Fatalf("missing API: err = %v, want ErrNotFound", err)
+	}
+	if e.Code != "not_found" || e.Message != "no such API" || e.RequestID != "req-2" || e.Method != http.MethodGet {
+		t.Errorf("not found error = %+v", e)
+	}
+	if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrServer) {
+		t.Error("404 matches another sentinel")
+	}
+
+	_, err = c.UpdateAPI(ctx, "a1", 2, UpdateAPIRequest{})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("stale update: err = %v, want ErrPreconditionFailed", err)
+	}
+
+	_, err = c.CreateAPI(ctx, CreateAPIRequest{})
+	if !errors.Is(err, ErrBadRequest) || !errors.As(err, &e) {
+		t.Fatalf("invalid create: err = %v, want ErrBadRequest", err)
+	}
+	if len(e.Errors) != 1 || e.Errors[0].Field != "name" || e.Message != "Bad Request" || e.RequestID != "req-1" {
+		t.Errorf("validation error = %+v", e)
+	}
+	if !strings.Contains(err.Error(), "name: is required") {
+		t.Errorf("message %q does not name the field", err)
+	}
+
+	_, err = c.GetVersion(ctx, "v1")
+	if !errors.Is(err, ErrServer) || !errors.As(err, &e) || e.Message != "boom" || e.Code != "" {
+		t.Errorf("plain-text 502: err = %v, want ErrServer with the body as message", err)
+	}
+}
+
+/* -------------------- If-Match -------------------- */
+
+func TestIfMatch(t *testing.T) {
+	got := map[string]string{}
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		got[r.Method+" "+r.URL.Path] = r.Header.Get("If-Match")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	ctx := context.Background()
+
+	calls := []error{
+		func() error { _, err := c.UpdateAPI(ctx, "a1", 7, UpdateAPIRequest{}); return err }(),
+		c.DeleteAPI(ctx, "a2", 0),
+		func() error { _, err := c.DeprecateVersion(ctx, "v1", 3, "2030-06-30"); return err }(),
+		c.DeleteVersion(ctx, "v2", 12),
+		func() error { _, err := c.CancelNotification(ctx, "n1", 2); return err }(),
+		func() error { _, err := c.GetAPI(ctx, "a1"); return err }(),
+	}
+	for i, err := range calls {
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	want := map[string]string{
+		"PUT /apis/a1":          `"7"`,
+		"DELETE /apis/a2":       `*`,
+		"PUT /versions/v1":      `"3"`,
+		"DELETE /versions/v2":   `"12"`,
+		"PUT /notifications/n1": `"2"`,
+		"GET /apis/a1":          "",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: If-Match = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+/* -------------------- pagination -------------------- */
+
+func TestAllFollowsCursors(t *testing.T) {
+	var queries []string
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, r.URL.RawQuery)
+		page, _ := map[string]int{"": 0, "c1": 1, "c2": 2}[q.Get("cursor")]
+		items := []API{{ID: fmt.Sprintf("a%d", 2*page)}, {ID: fmt.Sprintf("a%d", 2*page+1)}}
+		switch page {
+		case 0: // cursor in the body
+			json.NewEncoder(w).Encode(Page[API]{Items: items, NextCursor: "c1"})
+		case 1: // cursor only in the Link header
+			w.Header().Set("Link", `</apis?cursor=c2&limit=2>; rel="next"`)
+			json.NewEncoder(w).Encode(map[string]any{"items": items})
+		default:
+			json.NewEncoder(w).Encode(Page[API]{Items: items[:1]})
+		}
+	})
+
+	opts := &APIListOptions{ListOptions: ListOptions{Limit: 2, Sort: "name"}, OwnerTeam: "core"}
+	apis, err := Collect(All(context.Background(), c.ListAPIs, opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, a := range apis {
+		ids = append(ids, a.ID)
+	}
+	if got := strings.Join(ids, ","); got != "a0,a1,a2,a3,a4" {
+		t.Errorf("items = %s, want a0..a4", got)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("%d requests, want 3", len(queries))
+	}
+	for i, q := range queries {
+		for _, p := range []string{"limit=2", "sort=name", "owner_team=core"} {
+			if !strings.Contains(q, p) {
+				t.Errorf("request %d (%s) lost %s", i, q, p)
+			}
+		}
+	}
+	if opts.Cursor != "" {
+		t.Error("All modified the caller's options")
+	}
+}
+
+func TestAllStopsOnError(t *testing.T) {
+	var calls int
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("cursor") == "" {
+			json.NewEncoder(w).Encode(Page[Version]{Items: []Version{{ID: "v1"}}, NextCursor: "c1"})
+			return
+		}
+		writeProblem(w, http.StatusNotFound, "not_found", "gone", nil)
+	})
+
+	var got []string
+	var gotErr error
+	for v, err := range All(context.Background(), func(ctx context.Context, o *VersionListOptions) (*Page[Version], error) {
+		return c.ListVersions(ctx, "a1", o)
+	}, nil) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, v.ID)
+	}
+	if len(got) != 1 || !errors.Is(gotErr, ErrNotFound) || calls != 2 {
+		t.Errorf("items %v, err %v after %d calls; want v1 then ErrNotFound after 2", got, gotErr, calls)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for errors.Is; every *Error matches the one for its
+// status code.
+var (
+	ErrBadRequest    = &Error{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized  = &Error{StatusCode: http.StatusUnauthorized}
+	ErrForbidden     = &Error{StatusCode: http.StatusForbidden}
+	ErrNotFound      = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict      = &Error{StatusCode: http.StatusConflict}
+	ErrUnprocessable = &Error{StatusCode: http.StatusUnprocessableEntity}
+	ErrRateLimited   = &Error{StatusCode: http.StatusTooManyRequests}
+	ErrServer        = &Error{StatusCode: http.StatusInternalServerError}
+
+	// ErrPreconditionFailed: the object changed since the revision passed
+	// to an update or delete; fetch it again and retry.
+	ErrPreconditionFailed   = &Error{StatusCode: http.StatusPreconditionFailed}
+	ErrPreconditionRequired = &Error{StatusCode: http.StatusPreconditionRequired}
+)
+
+// Error is a non-2xx response from the server, decoded from its
+// application/problem+json body.
+type Error struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Code is the stable problem code, e.g. "validation_failed" or
+	// "not_found"; branch on it rather than on Message.
+	Code string
+	// Message is the problem's detail (or title), or the plain-text body.
+	Message string
+	// Errors lists the invalid fields of a validation failure.
+	Errors []FieldError
+	// RequestID identifies the request in the server log.
+	RequestID string
+	// Body is the raw response body.
+	Body []byte
+}
+
+// FieldError is one invalid field of a request. Field is a JSON path such
+// as "sunset_date" or "apis[0].versions[1].status".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Errors) > 0 {
+		fields := make([]string, len(e.Errors))
+		for i, f := range e.Errors {
+			fields[i] = f.Field + ": " + f.Message
+			if f.Field == "" {
+				fields[i] = f.Message
+			}
+		}
+		msg += ": " + strings.Join(fields, "; ")
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	if e.Method == "" {
+		return fmt.Sprintf("smelinx: %d %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("smelinx: %s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Is matches the sentinel for the same status code; all 5xx match ErrServer.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Method != "" || t.Message != "" {
+		return false
+	}
+	if t.StatusCode == http.StatusInternalServerError {
+		return e.StatusCode >= 500
+	}
+	return e.StatusCode == t.StatusCode
+}
+
+func newError(method, path string, resp *http.Response, body []byte) *Error {
+	e := &Error{StatusCode: resp.StatusCode, Method: method, Path: path, Body: body}
+	e.RequestID = resp.Header.Get("X-Request-ID")
+	var p struct {
+		Title     string       `json:"title"`
+		Code      string       `json:"code"`
+		Detail    string       `json:"detail"`
+		RequestID string       `json:"request_id"`
+		Errors    []FieldError `json:"errors"`
+	}
+	if json.Unmarshal(body, &p) == nil && p.Code != "" {
+		e.Code, e.Errors = p.Code, p.Errors
+		e.Message = p.Detail
+		if e.Message == "" {
+			e.Message = p.Title
+		}
+		if p.RequestID != "" {
+			e.RequestID = p.RequestID
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
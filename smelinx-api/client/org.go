@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+/* -------------------- account and org -------------------- */
+
+// Me is who the client is authenticated as.
+func (c *Client) Me(ctx context.Context) (*Me, error) {
+	var out Me
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/me"}, &out)
+}
+
+func (c *Client) GetOrg(ctx context.Context) (*Org, error) {
+	var out Org
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/org"}, &out)
+}
+
+func (c *Client) UpdateOrg(ctx context.Context, req UpdateOrgRequest) (*Org, error) {
+	var out Org
+	return &out, c.do(ctx, request{method: http.MethodPut, path: "/org", in: req}, &out)
+}
+
+// OrgCalendarURL is the org's calendar subscription URL.
+func (c *Client) OrgCalendarURL(ctx context.Context) (string, error) {
+	var out struct{ URL string }
+	err := c.do(ctx, request{method: http.MethodGet, path: "/calendar"}, &out)
+	return out.URL, err
+}
+
+// OrgFeed returns the org-wide changelog feed settings.
+func (c *Client) OrgFeed(ctx context.Context) (*FeedSettings, error) {
+	var out FeedSettings
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/feed"}, &out)
+}
+
+func (c *Client) SetOrgFeed(ctx context.Context, enabled bool) (*FeedSettings, error) {
+	var out FeedSettings
+	return &out, c.do(ctx, request{method: http.MethodPut, path: "/feed", in: map[string]bool{"enabled": enabled}}, &out)
+}
+
+/* -------------------- tokens -------------------- */
+
+func (c *Client) ListTokens(ctx context.Context) ([]Token, error) {
+	var out []Token
+	return out, c.do(ctx, request{method: http.MethodGet, path: "/me/tokens"}, &out)
+}
+
+// CreateToken issues a personal access token; its secret is in Token.Token
+// and is not returned again. The server only accepts this from a Login
+// session, not from a client using a token.
+func (c *Client) CreateToken(ctx context.Context, req CreateTokenRequest) (*Token, error) {
+	var out Token
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/me/tokens", in: req}, &out)
+}
+
+func (c *Client) RevokeToken(ctx context.Context, tokenID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("me", "tokens", tokenID)}, nil)
+}
+
+/* -------------------- member digest -------------------- */
+
+func (c *Client) DigestSettings(ctx context.Context) (*DigestSettings, error) {
+	var out DigestSettings
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/me/digest"}, &out)
+}
+
+func (c *Client) UpdateDigestSettings(ctx context.Context, req DigestSettingsRequest) (*DigestSettings, error) {
+	var out DigestSettings
+	return &out, c.do(ctx, request{method: http.MethodPut, path: "/me/digest", in: req}, &out)
+}
+
+func (c *Client) PreviewDigest(ctx context.Context) (*RenderedMessage, error) {
+	var out RenderedMessage
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/me/digest/preview"}, &out)
+}
+
+// SendDigest emails the current digest to the caller now.
+func (c *Client) SendDigest(ctx context.Context) (*SentTo, error) {
+	var out SentTo
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/me/digest/send"}, &out)
+}
+
+/* -------------------- templates -------------------- */
+
+func localeQuery(locale string) url.Values {
+	if locale == "" {
+		return nil
+	}
+	return url.Values{"locale": {locale}}
+}
+
+// ListTemplates returns the template in effect for each notice type in
+// locale ("" = the locale-neutral ones).
+func (c *Client) ListTemplates(ctx context.Context, locale string) (*Templates, error) {
+	var out Templates
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/templates", query: localeQuery(locale)}, &out)
+}
+
+func (c *Client) GetTemplate(ctx context.Context, typ, locale string) (*TemplateView, error) {
+	var out TemplateView
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("templates", typ), query: localeQuery(locale)}, &out)
+}
+
+// SaveTemplate stores a new version of the template and makes it active.
+func (c *Client) SaveTemplate(ctx context.Context, typ, locale string, req SaveTemplateRequest) (*Template, error) {
+	var out Template
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("templates", typ), query: localeQuery(locale), in: req}, &out)
+}
+
+// DeleteTemplate drops every version for the locale, reverting to the default.
+func (c *Client) DeleteTemplate(ctx context.Context, typ, locale string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("templates", typ), query: localeQuery(locale)}, nil)
+}
+
+func (c *Client) ListTemplateVersions(ctx context.Context, typ, locale string) ([]Template, error) {
+	var out []Template
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("templates", typ, "versions"), query: localeQuery(locale)}, &out)
+}
+
+// RestoreTemplateVersion copies an old version into a new active one.
+func (c *Client) RestoreTemplateVersion(ctx context.Context, typ, locale string, version int) (*Template, error) {
+	var out Template
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("templates", typ, "versions", strconv.Itoa(version), "restore"), query: localeQuery(locale)}, &out)
+}
+
+func (c *Client) PreviewTemplate(ctx context.Context, req PreviewTemplateRequest) (*RenderedMessage, error) {
+	var out RenderedMessage
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/templates/preview", in: req}, &out)
+}
+
+/* -------------------- webhooks -------------------- */
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var out []Webhook
+	return out, c.do(ctx, request{method: http.MethodGet, path: "/webhooks"}, &out)
+}
+
+// CreateWebhook registers an endpoint; the signing secret is in Secret.
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/webhooks", in: req}, &out)
+}
+
+func (c *Client) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	var out Webhook
+	return &out, c.do(ctx, request{method: http.MethodGet, path: p("webhooks", webhookID)}, &out)
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, req UpdateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	return &out, c.do(ctx, request{method: http.MethodPut, path: p("webhooks", webhookID), in: req}, &out)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: p("webhooks", webhookID)}, nil)
+}
+
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID string) ([]WebhookDelivery, error) {
+	var out []WebhookDelivery
+	return out, c.do(ctx, request{method: http.MethodGet, path: p("webhooks", webhookID, "deliveries")}, &out)
+}
+
+// TestWebhook sends a signed webhook.test event right away.
+func (c *Client) TestWebhook(ctx context.Context, webhookID string) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	return &out, c.do(ctx, request{method: http.MethodPost, path: p("webhooks", webhookID, "test")}, &out)
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListOptions pages through a list endpoint. The zero value asks for the
+// server's default page size and order from the start.
+type ListOptions struct {
+	Limit  int
+	Cursor string
+	// Sort is a field of the list, "-" prefixed for descending, e.g.
+	// "name" or "-created_at". A cursor only continues the sort it came from.
+	Sort string
+}
+
+func (o *ListOptions) values() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	return q
+}
+
+// APIListOptions filters ListAPIs.
+type APIListOptions struct {
+	ListOptions
+	Search    string // name contains, case-insensitive
+	OwnerTeam string
+}
+
+func (o *APIListOptions) values() url.Values {
+	if o == nil {
+		return url.Values{}
+	}
+	q := o.ListOptions.values()
+	setIf(q, "q", o.Search)
+	setIf(q, "owner_team", o.OwnerTeam)
+	return q
+}
+
+// VersionListOptions filters ListVersions.
+type VersionListOptions struct {
+	ListOptions
+	Status []string // active, deprecated, sunset
+}
+
+func (o *VersionListOptions) values() url.Values {
+	if o == nil {
+		return url.Values{}
+	}
+	q := o.ListOptions.values()
+	setIf(q, "status", strings.Join(o.Status, ","))
+	return q
+}
+
+// NotificationListOptions filters ListNotifications. ScheduledAfter
+// (inclusive) and ScheduledBefore (exclusive) are RFC 3339 times, or
+// YYYY-MM-DD for midnight in the org's time zone.
+type NotificationListOptions struct {
+	ListOptions
+	Status          []string // pending, sent, canceled
+	Type            []string // deprecate, sunset
+	ScheduledAfter  string
+	ScheduledBefore string
+}
+
+func (o *NotificationListOptions) values() url.Values {
+	if o == nil {
+		return url.Values{}
+	}
+	q := o.ListOptions.values()
+	setIf(q, "status", strings.Join(o.Status, ","))
+	setIf(q, "type", strings.Join(o.Type, ","))
+	setIf(q, "scheduled_after", o.ScheduledAfter)
+	setIf(q, "scheduled_before", o.ScheduledBefore)
+	return q
+}
+
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+// Page is one page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// getPage fetches one page. It reads both a {"items": [...]} envelope and a
+// bare array, and takes the next cursor from the body or the Link header.
+func getPage[T any](ctx context.Context, c *Client, path string, q url.Values) (*Page[T], error) {
+	resp, body, err := c.send(ctx, request{method: http.MethodGet, path: path, query: q})
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[T]{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &page.Items)
+	} else {
+		err = json.Unmarshal(body, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if page.NextCursor == "" {
+		page.NextCursor = nextCursor(resp.Header.Values("Link"))
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page, nil
+}
+
+// nextCursor reads the cursor of the rel="next" link (RFC 8288).
+func nextCursor(links []string) string {
+	for _, header := range links {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			if len(parts) < 2 {
+				continue
+			}
+			isNext := false
+			for _, param := range parts[1:] {
+				if strings.ReplaceAll(strings.TrimSpace(param), `"`, "") == "rel=next" {
+					isNext = true
+				}
+			}
+			if !isNext {
+				continue
+			}
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			if u, err := url.Parse(target); err == nil {
+				return u.Query().Get("cursor")
+			}
+		}
+	}
+	return ""
+}
+
+// pageable is an options struct that embeds ListOptions.
+type pageable interface{ listOptions() *ListOptions }
+
+func (o *ListOptions) listOptions() *ListOptions { return o }
+
+// All iterates over every item of a list, fetching pages as needed:
+//
+//	for api, err := range client.All(ctx, c.ListAPIs, nil) { ... }
+//
+// For lists under a parent, wrap the call:
+//
+//	client.All(ctx, func(ctx context.Context, o *client.VersionListOptions) (*client.Page[client.Version], error) {
+//		return c.ListVersions(ctx, apiID, o)
+//	}, &client.VersionListOptions{Status: []string{"deprecated"}})
+//
+// Iteration stops after the first error, which is yielded with a zero item.
+func All[T any, O any, PO interface {
+	*O
+	pageable
+}](ctx context.Context, list func(context.Context, PO) (*Page[T], error), opts PO) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var o O
+		if opts != nil {
+			o = *opts
+		}
+		po := PO(&o)
+		for {
+			page, err := list(ctx, po)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			po.listOptions().Cursor = page.NextCursor
+		}
+	}
+}
+
+// Collect gathers every item of a list into a slice.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	out := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+/* -------------------- session auth -------------------- */
+
+// Health reports whether the server is up.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodGet, path: "/health"}, nil)
+}
+
+// Info is the server's greeting at "/".
+func (c *Client) Info(ctx context.Context) (string, error) {
+	_, body, err := c.send(ctx, request{method: http.MethodGet, path: "/", accept: "*/*"})
+	return string(body), err
+}
+
+// Signup creates an account and its org; call Login next.
+func (c *Client) Signup(ctx context.Context, req SignupRequest) (*SignupResult, error) {
+	var out SignupResult
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/auth/signup", in: req}, &out)
+}
+
+// Login starts a cookie session; later requests use it when no token is set.
+func (c *Client) Login(ctx context.Context, email, password string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/login",
+		in: map[string]string{"email": email, "password": password}}, nil)
+}
+
+// Refresh renews the session cookies.
+func (c *Client) Refresh(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/refresh"}, nil)
+}
+
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/logout"}, nil)
+}
+
+/* -------------------- signed links -------------------- */
+
+// These routes authenticate with the signed token from a link the server
+// generated (calendar, preferences, acknowledgement) rather than a session.
+
+func tokenQuery(token string) url.Values { return url.Values{"token": {token}} }
+
+// CalendarFeed downloads the iCalendar feed behind a calendar URL's token.
+func (c *Client) CalendarFeed(ctx context.Context, token string) ([]byte, error) {
+	_, body, err := c.send(ctx, request{method: http.MethodGet, path: "/calendar.ics", query: tokenQuery(token), accept: "text/calendar"})
+	return body, err
+}
+
+// LinkPreferences reads the preferences behind a consumer's link.
+func (c *Client) LinkPreferences(ctx context.Context, token string) (*Preferences, error) {
+	var out Preferences
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/preferences", query: tokenQuery(token)}, &out)
+}
+
+func (c *Client) SaveLinkPreferences(ctx context.Context, token string, req PreferencesRequest) (*Preferences, error) {
+	var out Preferences
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/preferences", query: tokenQuery(token), in: req}, &out)
+}
+
+// UnsubscribePage fetches the page a browser sees when following the
+// List-Unsubscribe link.
+func (c *Client) UnsubscribePage(ctx context.Context, token string) ([]byte, error) {
+	_, body, err := c.send(ctx, request{method: http.MethodGet, path: "/preferences/unsubscribe", query: tokenQuery(token), accept: "text/html"})
+	return body, err
+}
+
+// OneClickUnsubscribe is the RFC 8058 one-click unsubscribe.
+func (c *Client) OneClickUnsubscribe(ctx context.Context, token string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/preferences/unsubscribe", query: tokenQuery(token),
+		raw: []byte("List-Unsubscribe=One-Click"), contentType: "application/x-www-form-urlencoded"}, nil)
+}
+
+// AckPage reads what an acknowledgement link refers to.
+func (c *Client) AckPage(ctx context.Context, token string) (*AckPage, error) {
+	var out AckPage
+	return &out, c.do(ctx, request{method: http.MethodGet, path: "/ack", query: tokenQuery(token)}, &out)
+}
+
+// Acknowledge records an acknowledgement link as acknowledged or migrated.
+func (c *Client) Acknowledge(ctx context.Context, token, status string) (*Acknowledgement, error) {
+	var out Acknowledgement
+	return &out, c.do(ctx, request{method: http.MethodPost, path: "/ack", query: tokenQuery(token),
+		in: map[string]string{"status": status}}, &out)
+}
+
+/* -------------------- public feeds -------------------- */
+
+// APIChangelog downloads an API's public changelog (format "atom" or "rss").
+func (c *Client) APIChangelog(ctx context.Context, apiID, format string) ([]byte, error) {
+	_, body, err := c.send(ctx, request{method: http.MethodGet, path: p("feeds", "apis", apiID+"."+format), accept: "*/*"})
+	return body, err
+}
+
+// OrgChangelog downloads an org's public changelog (format "atom" or "rss").
+func (c *Client) OrgChangelog(ctx context.Context, orgID, format string) ([]byte, error) {
+	_, body, err := c.send(ctx, request{method: http.MethodGet, path: p("feeds", "orgs", orgID+"."+format), accept: "*/*"})
+	return body, err
+}
+
+// ForwardSendGridEvents relays a SendGrid Event Webhook delivery (body and
+// its signature headers, unchanged) to the server, e.g. from a proxy.
+func (c *Client) ForwardSendGridEvents(ctx context.Context, body []byte, signature, timestamp string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/inbound/sendgrid", raw: body, contentType: "application/json",
+		header: http.Header{
+			"X-Twilio-Email-Event-Webhook-Signature": {signature},
+			"X-Twilio-Email-Event-Webhook-Timestamp": {timestamp},
+		}}, nil)
+}
@@ -0,0 +1,443 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// API is a registered API.
+type API struct {
+	ID           string    `json:"id"`
+	OrgID        string    `json:"org_id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	BaseURL      *string   `json:"base_url,omitempty"`
+	DocsURL      *string   `json:"docs_url,omitempty"`
+	ContactEmail *string   `json:"contact_email,omitempty"`
+	OwnerTeam    *string   `json:"owner_team,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Revision     int64     `json:"revision"`
+}
+
+type CreateAPIRequest struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	BaseURL      *string `json:"base_url,omitempty"`
+	DocsURL      *string `json:"docs_url,omitempty"`
+	ContactEmail *string `json:"contact_email,omitempty"`
+	OwnerTeam    *string `json:"owner_team,omitempty"`
+}
+
+// UpdateAPIRequest changes the fields that are not nil.
+type UpdateAPIRequest struct {
+	Name         *string `json:"name,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	BaseURL      *string `json:"base_url,omitempty"`
+	DocsURL      *string `json:"docs_url,omitempty"`
+	ContactEmail *string `json:"contact_email,omitempty"`
+	OwnerTeam    *string `json:"owner_team,omitempty"`
+}
+
+// Version is a version of an API.
+type Version struct {
+	ID         string     `json:"id"`
+	APIID      string     `json:"api_id"`
+	Version    string     `json:"version"`
+	Status     string     `json:"status"` // active | deprecated | sunset
+	SunsetDate *time.Time `json:"sunset_date,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Revision   int64      `json:"revision"`
+}
+
+type CreateVersionRequest struct {
+	Version    string  `json:"version"`
+	Status     string  `json:"status,omitempty"`      // default active
+	SunsetDate *string `json:"sunset_date,omitempty"` // YYYY-MM-DD
+}
+
+// UpdateVersionRequest replaces status and sunset date; a nil SunsetDate
+// clears it.
+type UpdateVersionRequest struct {
+	Status     string  `json:"status"`
+	SunsetDate *string `json:"sunset_date,omitempty"` // YYYY-MM-DD
+}
+
+// Notification is a scheduled deprecation or sunset notice.
+type Notification struct {
+	ID          string    `json:"id"`
+	APIID       string    `json:"api_id"`
+	VersionID   string    `json:"version_id"`
+	Type        string    `json:"type"` // deprecate | sunset
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Status      string    `json:"status"` // pending | sent | canceled
+	CreatedAt   time.Time `json:"created_at"`
+	Revision    int64     `json:"revision"`
+}
+
+type CreateNotificationRequest struct {
+	VersionID string `json:"version_id"`
+	Type      string `json:"type"`
+	// ScheduledAt is RFC 3339, or "2006-01-02T15:04" / "2006-01-02" in Timezone.
+	ScheduledAt string `json:"scheduled_at"`
+	Timezone    string `json:"timezone,omitempty"`
+	SendTime    string `json:"send_time,omitempty"` // HH:MM for a date-only ScheduledAt
+}
+
+type PreviewNotificationRequest struct {
+	VersionID   string `json:"version_id"`
+	Type        string `json:"type"`
+	ConsumerID  string `json:"consumer_id,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	ScheduledAt string `json:"scheduled_at,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	SendTime    string `json:"send_time,omitempty"`
+}
+
+// RenderedMessage is a rendered email (notice, digest or template preview).
+type RenderedMessage struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// Delivery is the outcome of a notice for one destination.
+type Delivery struct {
+	ID                string     `json:"id"`
+	Channel           string     `json:"channel"`
+	Target            string     `json:"target"`
+	ConsumerID        *string    `json:"consumer_id,omitempty"`
+	Status            string     `json:"status"` // sent | failed
+	Error             *string    `json:"error,omitempty"`
+	ProviderStatus    *string    `json:"provider_status,omitempty"`
+	ProviderReason    *string    `json:"provider_reason,omitempty"`
+	ProviderUpdatedAt *time.Time `json:"provider_updated_at,omitempty"`
+	OpenedAt          *time.Time `json:"opened_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Consumer is a client of the org's APIs that receives notices.
+type Consumer struct {
+	ID              string    `json:"id"`
+	OrgID           string    `json:"org_id"`
+	Name            string    `json:"name"`
+	Email           *string   `json:"email,omitempty"`
+	Timezone        *string   `json:"timezone,omitempty"`
+	QuietHoursStart *string   `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string   `json:"quiet_hours_end,omitempty"`
+	Locale          *string   `json:"locale,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ConsumerRequest creates or replaces a consumer.
+type ConsumerRequest struct {
+	Name            string  `json:"name"`
+	Email           *string `json:"email,omitempty"`
+	Timezone        *string `json:"timezone,omitempty"`
+	QuietHoursStart *string `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string `json:"quiet_hours_end,omitempty"`
+	Locale          *string `json:"locale,omitempty"`
+}
+
+// Preferences are a consumer's notice preferences.
+type Preferences struct {
+	ConsumerID   string        `json:"consumer_id"`
+	Unsubscribed bool          `json:"unsubscribed"`
+	NoticeTypes  []string      `json:"notice_types"`
+	Digest       string        `json:"digest"` // immediate | weekly
+	MutedAPIIDs  []string      `json:"muted_api_ids"`
+	ConsumerName string        `json:"consumer_name"`
+	APIs         []ConsumerAPI `json:"apis"`
+}
+
+type ConsumerAPI struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Muted bool   `json:"muted"`
+}
+
+type PreferencesRequest struct {
+	Unsubscribed *bool    `json:"unsubscribed,omitempty"`
+	NoticeTypes  []string `json:"notice_types,omitempty"`
+	Digest       string   `json:"digest,omitempty"`
+	MutedAPIIDs  []string `json:"muted_api_ids,omitempty"`
+}
+
+// ConsumerPreferences is a consumer's preferences with their signed link.
+type ConsumerPreferences struct {
+	Preferences    Preferences `json:"preferences"`
+	PreferencesURL string      `json:"preferences_url"`
+}
+
+// Channel is a Slack or Teams destination for an API or a consumer.
+type Channel struct {
+	ID         string    `json:"id"`
+	OrgID      string    `json:"org_id"`
+	APIID      *string   `json:"api_id,omitempty"`
+	ConsumerID *string   `json:"consumer_id,omitempty"`
+	Kind       string    `json:"kind"` // slack | teams
+	Name       string    `json:"name"`
+	WebhookURL string    `json:"webhook_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateChannelRequest struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name,omitempty"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+// Webhook is an endpoint receiving signed event payloads.
+type Webhook struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"` // empty = every event
+}
+
+// UpdateWebhookRequest changes the fields that are not nil.
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID           string     `json:"id"`
+	EndpointID   string     `json:"endpoint_id"`
+	EventID      string     `json:"event_id"`
+	EventType    string     `json:"event_type"`
+	Status       string     `json:"status"` // pending | delivered | failed
+	Attempts     int        `json:"attempts"`
+	ResponseCode *int       `json:"response_code,omitempty"`
+	LastError    *string    `json:"last_error,omitempty"`
+	RetryAfter   *time.Time `json:"retry_after,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Template is a stored version of a custom notice template.
+type Template struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	Type      string    `json:"type"`
+	Locale    string    `json:"locale,omitempty"`
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"`
+	HTML      string    `json:"html"`
+	Text      string    `json:"text,omitempty"`
+	CreatedBy *string   `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateView is the template in effect for a type and locale.
+type TemplateView struct {
+	Type     string       `json:"type"`
+	Locale   string       `json:"locale,omitempty"`
+	Source   string       `json:"source"` // custom | default
+	Version  int          `json:"version,omitempty"`
+	Template TemplateBody `json:"template"`
+	Stored   *Template    `json:"stored,omitempty"`
+}
+
+type TemplateBody struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text,omitempty"`
+}
+
+// Templates is the active template of every type plus what they may use.
+type Templates struct {
+	Templates []TemplateView    `json:"templates"`
+	Variables map[string]string `json:"variables"`
+	Locales   []string          `json:"locales"`
+}
+
+type SaveTemplateRequest struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text,omitempty"`
+}
+
+// PreviewTemplateRequest renders a draft; nil parts use the active template.
+type PreviewTemplateRequest struct {
+	Type    string  `json:"type"`
+	Locale  string  `json:"locale,omitempty"`
+	Subject *string `json:"subject,omitempty"`
+	HTML    *string `json:"html,omitempty"`
+	Text    *string `json:"text,omitempty"`
+}
+
+// Org is the caller's organisation.
+type Org struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Timezone         string   `json:"timezone"`
+	Locale           string   `json:"locale"`
+	SupportedLocales []string `json:"supported_locales"`
+}
+
+type UpdateOrgRequest struct {
+	Timezone *string `json:"timezone,omitempty"`
+	Locale   *string `json:"locale,omitempty"`
+}
+
+// Me is who the client is authenticated as.
+type Me struct {
+	UserID string `json:"user_id"`
+	OrgID  string `json:"org_id"`
+	Role   string `json:"role"`
+}
+
+type SignupRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	OrgName  string `json:"org_name"`
+}
+
+type SignupResult struct {
+	Message string `json:"message"`
+	UserID  string `json:"user_id"`
+	OrgID   string `json:"org_id"`
+	Role    string `json:"role"`
+}
+
+// Token is a personal access token; Token is only set when it is created.
+type Token struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Token      string     `json:"token,omitempty"`
+}
+
+type CreateTokenRequest struct {
+	Name          string `json:"name"`
+	ExpiresInDays int    `json:"expires_in_days,omitempty"` // 0 = never
+}
+
+type DigestSettings struct {
+	OrgID       string     `json:"org_id"`
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email"`
+	Frequency   string     `json:"frequency"` // off | daily | weekly
+	HorizonDays int        `json:"horizon_days"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+type DigestSettingsRequest struct {
+	Frequency   *string `json:"frequency,omitempty"`
+	HorizonDays *int    `json:"horizon_days,omitempty"`
+}
+
+// FeedSettings says whether a changelog feed is public and where.
+type FeedSettings struct {
+	Enabled bool   `json:"enabled"`
+	AtomURL string `json:"atom_url"`
+	RSSURL  string `json:"rss_url"`
+}
+
+type Acknowledgement struct {
+	ConsumerID string    `json:"consumer_id"`
+	VersionID  string    `json:"version_id"`
+	Status     string    `json:"status"` // acknowledged | migrated
+	Source     string    `json:"source"` // link | manual
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AckReport is who has acknowledged or migrated off a version.
+type AckReport struct {
+	API       struct{ ID, Name string } `json:"api"`
+	Version   Version                   `json:"version"`
+	Total     int                       `json:"total"`
+	Counts    map[string]int            `json:"counts"`
+	Consumers []AckReportRow            `json:"consumers"`
+}
+
+type AckReportRow struct {
+	ConsumerID     string     `json:"consumer_id"`
+	Name           string     `json:"name"`
+	Email          *string    `json:"email,omitempty"`
+	Subscribed     bool       `json:"subscribed"`
+	Status         string     `json:"status"` // acknowledged | migrated | outstanding
+	Source         *string    `json:"source,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// AckPage is what a consumer's acknowledgement link shows.
+type AckPage struct {
+	ConsumerName    string           `json:"consumer_name"`
+	APIName         string           `json:"api_name"`
+	Version         Version          `json:"version"`
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+}
+
+type Suppression struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"` // bounce | spamreport
+	Detail    *string   `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SentTo reports a test send or digest email.
+type SentTo struct {
+	Status string `json:"status"`
+	To     string `json:"to"`
+}
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImportReport is the outcome (or, for a dry run, the plan) of an import.
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   map[string]int    `json:"created"`
+	Skipped   map[string]int    `json:"skipped"`
+	Conflicts []ImportConflict  `json:"conflicts"`
+	IDs       map[string]string `json:"ids"`
+}
+
+type ImportConflict struct {
+	Kind       string `json:"kind"`
+	BundleID   string `json:"bundle_id,omitempty"`
+	Name       string `json:"name"`
+	ExistingID string `json:"existing_id,omitempty"`
+}
+
+// SyncResult is a manifest sync plan, applied or not.
+type SyncResult struct {
+	Changes []SyncChange `json:"changes"`
+	Diff    string       `json:"diff"`
+	Applied bool         `json:"applied"`
+}
+
+type SyncChange struct {
+	Action string      `json:"action"` // create | update | delete | cancel
+	Kind   string      `json:"kind"`   // api | version | notification
+	Target string      `json:"target"`
+	Diff   []FieldDiff `json:"diff,omitempty"`
+}
+
+type FieldDiff struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
@@ -17,14 +17,20 @@ import (
 
 /* ---------------------------- types ---------------------------- */
 
-type AuthService struct{ store *Store }
+type AuthService struct {
+	store  *Store
+	mailer Mailer
+}
 
-func NewAuthService(s *Store) *AuthService { return &AuthService{store: s} }
+func NewAuthService(s *Store, m Mailer) *AuthService { return &AuthService{store: s, mailer: m} }
 
 type jwtClaims struct {
 	Sub   string `json:"sub"`
 	OrgID string `json:"org_id"`
 	Role  string `json:"role"`
+	// TokenID is the personal access token the request authenticated with;
+	// empty for a cookie session. Never part of a JWT.
+	TokenID string `json:"-"`
 	jwt.RegisteredClaims
 }
 
@@ -183,25 +189,27 @@ type loginReq struct {
 
 func (a *AuthService) SignupHandler(w http.ResponseWriter, r *http.Request) {
 	var req signupReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	if !validEmail(req.Email) || !strongPassword(req.Password) || len(strings.TrimSpace(req.OrgName)) < 2 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
 
 	hash, _ := a.hashPassword(req.Password)
 	u, err := a.store.CreateUser(r.Context(), strings.ToLower(strings.TrimSpace(req.Email)), hash)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "user exists?"})
+		writeProblem(w, r, problem{
+			Status: http.StatusConflict, Code: codeConflict, Detail: "account already exists",
+			Errors: []fieldError{{Field: "email", Code: "conflict", Message: "already registered"}},
+		})
 		return
 	}
 
 	org, err := a.store.CreateOrgWithOwner(r.Context(), strings.TrimSpace(req.OrgName), u.ID)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "org create failed"})
+		writeInternal(w, r, err, "org create failed")
 		return
 	}
 
@@ -214,25 +222,24 @@ func (a *AuthService) SignupHandler(w http.ResponseWriter, r *http.Request) {
 
 func (a *AuthService) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req loginReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 	u, err := a.store.GetUserByEmail(r.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
 	if err != nil || bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)) != nil {
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
+		writeError(w, r, http.StatusUnauthorized, codeInvalidLogin, "invalid credentials")
 		return
 	}
 
 	org, err := a.store.GetUserPrimaryOrg(r.Context(), u.ID)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "no org for user"})
+		writeInternal(w, r, err, "no org for user")
 		return
 	}
 
 	at, rt, err := a.issueTokens(u.ID, org.ID, "owner")
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "token issue failed"})
+		writeInternal(w, r, err, "token issue failed")
 		return
 	}
 	a.setSessionCookies(w, at, rt)
@@ -242,18 +249,18 @@ func (a *AuthService) LoginHandler(w http.ResponseWriter, r *http.Request) {
 func (a *AuthService) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 	rc, err := r.Cookie("refresh_token")
 	if err != nil || rc.Value == "" {
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "no refresh token"})
+		writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "no refresh token")
 		return
 	}
 	claims, err := a.parseToken(rc.Value)
 	if err != nil {
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid refresh"})
+		writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid refresh token")
 		return
 	}
 
 	at, rt, err := a.issueTokens(claims.Sub, claims.OrgID, claims.Role)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "token issue failed"})
+		writeInternal(w, r, err, "token issue failed")
 		return
 	}
 	a.setSessionCookies(w, at, rt)
@@ -274,14 +281,25 @@ func (a *AuthService) MeHandler(w http.ResponseWriter, r *http.Request) {
 
 func (a *AuthService) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// scripts and CI send a personal access token instead of the cookie
+		if tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			claims, err := a.store.AuthenticateToken(r.Context(), strings.TrimSpace(tok))
+			if err != nil {
+				writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid token")
+				return
+			}
+			ctx := context.WithValue(r.Context(), ctxKeyUser{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
 		c, err := r.Cookie("access_token")
 		if err != nil || c.Value == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "authentication required")
 			return
 		}
 		claims, err := a.parseToken(c.Value)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "session expired")
 			return
 		}
 		ctx := context.WithValue(r.Context(), ctxKeyUser{}, claims)
@@ -291,6 +309,19 @@ func (a *AuthService) AuthMiddleware(next http.Handler) http.Handler {
 
 /* ---------------------------- validation ---------------------------- */
 
+func (req *signupReq) validate() (errs fieldErrors) {
+	if !validEmail(req.Email) {
+		errs.invalid("email", "not a valid email address")
+	}
+	if !strongPassword(req.Password) {
+		errs.invalid("password", "at least 8 characters with a letter and a digit")
+	}
+	if len(strings.TrimSpace(req.OrgName)) < 2 {
+		errs.invalid("org_name", "at least 2 characters")
+	}
+	return errs
+}
+
 func validEmail(s string) bool {
 	s = strings.TrimSpace(s)
 	if len(s) < 6 || len(s) > 254 {
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Online SQLite backups.
+
+A backup is a VACUUM INTO copy of the live database: a consistent snapshot
+taken inside a read transaction, so the server keeps running. Backups are
+written to BACKUP_DIR (default: "backups" next to SQLITE_PATH) as
+smelinx-<UTC timestamp>.db; the newest BACKUP_RETAIN are kept.
+
+Restoring swaps a backup in for SQLITE_PATH while the server is stopped,
+after checking the file is an intact Smelinx database whose schema this
+build can run. PostgreSQL deployments use pg_dump / pg_restore instead.
+*/
+
+const (
+	backupPrefix = "smelinx-"
+	backupSuffix = ".db"
+	backupLayout = "20060102T150405Z"
+)
+
+var (
+	errBackupUnsupported = errors.New("backups are built in for SQLite only; use pg_dump / pg_restore for PostgreSQL")
+	errDataFileLocked    = errors.New("the database is in use by a running server")
+)
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"-"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func backupDir() string {
+	return getenv("BACKUP_DIR", filepath.Join(filepath.Dir(os.Getenv("SQLITE_PATH")), "backups"))
+}
+
+// backupRetain is how many backups to keep (BACKUP_RETAIN, default 7; 0 keeps all).
+func backupRetain() int {
+	n, err := strconv.Atoi(getenv("BACKUP_RETAIN", "7"))
+	if err != nil || n < 0 {
+		return 7
+	}
+	return n
+}
+
+// Backup writes a consistent copy of the database into dir and prunes old
+// backups beyond the retention.
+func (s *Store) Backup(ctx context.Context, dir string) (*Backup, error) {
+	if s.db.driver() != "sqlite" {
+		return nil, errBackupUnsupported
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	name := backupPrefix + now.Format(backupLayout) + backupSuffix
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	// VACUUM INTO refuses an existing target; write aside and rename so a
+	// half-written file never looks like a backup
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	_ = os.Remove(tmp)
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("vacuum into: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pruneBackups(dir, backupRetain()); err != nil {
+		return nil, fmt.Errorf("prune backups: %w", err)
+	}
+	return &Backup{Name: name, Path: path, Size: fi.Size(), CreatedAt: now}, nil
+}
+
+// listBackups returns the backups in dir, newest first.
+func listBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []Backup{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		at, err := time.Parse(backupLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+		if err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Backup{Name: name, Path: filepath.Join(dir, name), Size: fi.Size(), CreatedAt: at})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// pruneBackups deletes all but the newest keep backups (keep 0 = none deleted).
+func pruneBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	list, err := listBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i := keep; i < len(list); i++ {
+		if err := os.Remove(list[i].Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, list[i].Name)
+	}
+	return removed, nil
+}
+
+/* -------------------- restore -------------------- */
+
+// validateBackupFile checks that path is an intact Smelinx SQLite database
+// whose schema this build can run, and returns its migration version
+// (0 = from before numbered migrations).
+func validateBackupFile(ctx context.Context, path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRowContext(ctx, `PRAGMA quick_check`).Scan(&check); err != nil {
+		return 0, fmt.Errorf("not a readable SQLite database: %w", err)
+	}
+	if check != "ok" {
+		return 0, fmt.Errorf("integrity check failed: %s", check)
+	}
+	has := func(table string) (bool, error) {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+		return n > 0, err
+	}
+	if ok, err := has("users"); err != nil || !ok {
+		return 0, fmt.Errorf("not a Smelinx database (no users table)")
+	}
+	if ok, err := has("schema_migrations"); err != nil || !ok {
+		return 0, err // predates numbered migrations; the baseline adopts it
+	}
+
+	var version, dirty int
+	if err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0), COALESCE(MAX(dirty), 0) FROM schema_migrations`).Scan(&version, &dirty); err != nil {
+		return 0, err
+	}
+	if dirty != 0 {
+		return version, errors.New("backup was taken while a migration was running (dirty schema)")
+	}
+	if version > latestMigration() {
+		return version, fmt.Errorf("backup schema is at migration %d, newer than this build (%d)", version, latestMigration())
+	}
+	return version, nil
+}
+
+// restoreSQLite validates src and swaps it in for the database at dst. The
+// current file is kept as dst.pre-restore-<timestamp>. The server must be
+// stopped.
+func restoreSQLite(ctx context.Context, src, dst string) (version int, previous string, err error) {
+	if version, err = validateBackupFile(ctx, src); err != nil {
+		return version, "", err
+	}
+	lock, err := lockDataFile(dst)
+	if errors.Is(err, errDataFileLocked) {
+		return version, "", fmt.Errorf("%w; stop it before restoring", err)
+	}
+	if err != nil {
+		return version, "", err
+	}
+	if lock != nil {
+		defer lock.Close()
+	}
+	// a hot journal holds writes the current file still needs; SQLite rolls
+	// it back on the next open, after which it is safe to replace
+	for _, suffix := range []string{"-journal", "-wal"} {
+		if _, err := os.Stat(dst + suffix); err == nil {
+			return version, "", fmt.Errorf("%s exists: the database was not shut down cleanly; start and stop the server once before restoring", dst+suffix)
+		}
+	}
+
+	tmp := dst + ".restore.tmp"
+	if err := copyFile(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return version, "", err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		previous = dst + ".pre-restore-" + time.Now().UTC().Format(backupLayout)
+		if err := os.Rename(dst, previous); err != nil {
+			_ = os.Remove(tmp)
+			return version, "", err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return version, previous, err
+	}
+	return version, previous, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
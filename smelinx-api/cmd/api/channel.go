@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Channel delivers a due notification to one recipient. The dispatcher picks
+// the implementation by recipient.Channel ("email", "slack", "teams").
+type Channel interface {
+	Deliver(ctx context.Context, to recipient, d dueNotification) error
+}
+
+// newChannels wires every supported channel kind.
+func newChannels(store *Store, mailer Mailer) map[string]Channel {
+	client := outboundClient(10 * time.Second)
+	return map[string]Channel{
+		"email": emailChannel{store: store, mailer: mailer},
+		"slack": slackChannel{client: client},
+		"teams": teamsChannel{client: client},
+	}
+}
+
+// validChannelKind reports whether kind can be stored as a chat channel.
+func validChannelKind(kind string) bool {
+	return kind == "slack" || kind == "teams"
+}
+
+/* -------------------- email -------------------- */
+
+// emailChannel renders the org's notification template and hands it to the Mailer.
+type emailChannel struct {
+	store  *Store
+	mailer Mailer
+}
+
+func (c emailChannel) Deliver(ctx context.Context, to recipient, d dueNotification) error {
+	return c.mailer.SendMessage(noticeEmail(ctx, c.store, d, to))
+}
+
+// noticeEmail builds the email the dispatcher sends for d to one recipient:
+// the rendered notice, the sunset calendar entry and, for consumers, the
+// preferences footer. Previews and test sends use it too.
+func noticeEmail(ctx context.Context, store *Store, d dueNotification, to recipient) Message {
+	msg := renderNotice(ctx, store, d, to)
+	out := Message{To: to.Target, Subject: msg.Subject, HTML: msg.HTML, Text: msg.Text, Tags: emailTags(d.OrgID, d.NoteID)}
+	if d.SunsetDate.Valid {
+		ev := sunsetICSEvent(d.VersionID, d.APIName, d.Version, d.SunsetDate.Time, d.DocsURL.String)
+		out.Attachments = append(out.Attachments, Attachment{
+			Filename:    "sunset.ics",
+			ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+			Content:     buildICS("", "PUBLISH", []icsEvent{ev}),
+		})
+	}
+	if to.ConsumerID != "" {
+		addPreferencesFooter(&out, to.ConsumerID, recipientLocale(to, d))
+	}
+	return out
+}
+
+// emailTags identify a notice on the provider's delivery events
+// (see EmailEventsHandler).
+func emailTags(orgID, noteID string) map[string]string {
+	tags := map[string]string{tagOrgID: orgID}
+	if noteID != "" {
+		tags[tagNotificationID] = noteID
+	}
+	return tags
+}
+
+// addPreferencesFooter appends the signed preferences/unsubscribe links every
+// email to a consumer must carry. The API's own contact gets no footer.
+func addPreferencesFooter(m *Message, consumerID, locale string) {
+	prefs := preferencesURL(consumerID)
+	m.Unsubscribe = unsubscribeURL(consumerID)
+	m.Text = m.PlainText() + "\n\n--\n" + tr(locale, "footer.manage") + ": " + prefs +
+		"\n" + tr(locale, "footer.sunset_always") + "\n"
+	footer := `<p style="color:#888;font-size:12px">` + html.EscapeString(tr(locale, "footer.subscribed")) + ` ` +
+		`<a href="` + html.EscapeString(prefs) + `">` + html.EscapeString(tr(locale, "footer.manage")) + `</a>. ` +
+		html.EscapeString(tr(locale, "footer.sunset_always")) + `</p>`
+	if i := strings.LastIndex(strings.ToLower(m.HTML), "</body>"); i >= 0 {
+		m.HTML = m.HTML[:i] + footer + m.HTML[i:]
+	} else {
+		m.HTML += footer
+	}
+}
+
+/* -------------------- shared webhook helpers -------------------- */
+
+// postJSON posts payload to url and treats any non-2xx answer as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %d%s", resp.StatusCode, upstreamReason(resp.Body))
+	}
+	return nil
+}
+
+// upstreamReason is the part of an error response worth keeping: a short
+// error code like Slack's "channel_not_found". Errors are stored and shown
+// to every org member, so the raw body never is.
+func upstreamReason(body io.Reader) string {
+	b, _ := io.ReadAll(io.LimitReader(body, 65))
+	s := string(bytes.TrimSpace(b))
+	if !errorCode.MatchString(s) {
+		return ""
+	}
+	return ": " + s
+}
+
+var errorCode = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+/* -------------------- outbound URLs -------------------- */
+
+// Chat and webhook URLs are supplied by org members, so they must not reach
+// the server's own network: loopback, private (RFC 1918, ULA), link-local
+// (cloud metadata at 169.254.169.254) and shared/reserved ranges are refused
+// when the URL is saved and again when it is dialed, which also covers DNS
+// rebinding and redirects. WEBHOOK_ALLOW_PRIVATE=true lifts this for
+// self-hosted installs that deliver inside their own network.
+
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can map to any IPv4
+}
+
+func allowPrivateEndpoints() bool {
+	return os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"
+}
+
+// publicAddr reports whether ip is a globally routable unicast address.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validWebhookURL reports whether s is an http(s) URL whose host resolves
+// only to public addresses.
+func validWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return false
+	}
+	if allowPrivateEndpoints() {
+		return true
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return publicAddr(ip)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !publicAddr(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// outboundClient is the HTTP client for org-supplied URLs. Its dialer checks
+// every address it connects to, and it ignores HTTP(S)_PROXY, which would
+// dial on its behalf.
+func outboundClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(ip) && !allowPrivateEndpoints() {
+				return fmt.Errorf("refusing to connect to non-public address %s", ip)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// noticeTitle is the human heading used by every channel.
+func noticeTitle(locale, typ string) string {
+	if typ != "deprecate" && typ != "sunset" {
+		typ = "other"
+	}
+	return tr(locale, "title."+typ)
+}
+
+// recipientLocale is the language a recipient reads notices in: their own,
+// else the org default (carried on d), else English.
+func recipientLocale(to recipient, d dueNotification) string {
+	return localeOr(to.Locale, d.Locale)
+}
+
+// noticeAckURL is the acknowledgement link for consumer-owned destinations,
+// empty for the API contact and API-level channels.
+func noticeAckURL(to recipient, d dueNotification) string {
+	if to.ConsumerID == "" {
+		return ""
+	}
+	return ackURL(to.ConsumerID, d.VersionID)
+}
+
+// noticeSunset formats the version sunset date, or "—" when none is set.
+func noticeSunset(locale string, d dueNotification) string {
+	if !d.SunsetDate.Valid {
+		return "—"
+	}
+	return formatDate(locale, d.SunsetDate.Time)
+}
+
+// noticeScheduledAt formats the scheduled time in the zone and locale the
+// recipient reads times in (see recipientZone, recipientLocale).
+func noticeScheduledAt(to recipient, d dueNotification) string {
+	return d.ScheduledAt.In(recipientZone(to, d)).Format(tr(recipientLocale(to, d), "format.datetime_short"))
+}
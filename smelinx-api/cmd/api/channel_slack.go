@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// slackChannel posts Block Kit messages to a Slack incoming webhook.
+type slackChannel struct{ client *http.Client }
+
+func (c slackChannel) Deliver(ctx context.Context, to recipient, d dueNotification) error {
+	return postJSON(ctx, c.client, to.Target, slackPayload(d, recipientLocale(to, d), noticeScheduledAt(to, d), noticeAckURL(to, d)))
+}
+
+func slackPayload(d dueNotification, locale, scheduledAt, ackURL string) map[string]any {
+	title := noticeTitle(locale, d.Type)
+	field := func(key, value string) map[string]any {
+		return map[string]any{"type": "mrkdwn", "text": "*" + tr(locale, key) + ":*\n" + value}
+	}
+	fields := []map[string]any{
+		field("label.api", slackEsc(d.APIName)),
+		field("label.version", slackEsc(d.Version)),
+		field("label.sunset_date", noticeSunset(locale, d)),
+		field("label.scheduled_at", scheduledAt),
+	}
+	if d.BaseURL.Valid && strings.TrimSpace(d.BaseURL.String) != "" {
+		fields = append(fields, field("label.base_url", slackEsc(d.BaseURL.String)))
+	}
+
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": fmt.Sprintf("%s – %s %s", title, d.APIName, d.Version)}},
+		{"type": "section", "fields": fields},
+	}
+	var buttons []map[string]any
+	if d.DocsURL.Valid && strings.TrimSpace(d.DocsURL.String) != "" {
+		buttons = append(buttons, map[string]any{
+			"type": "button",
+			"text": map[string]any{"type": "plain_text", "text": tr(locale, "action.read_docs")},
+			"url":  d.DocsURL.String,
+		})
+	}
+	if ackURL != "" {
+		buttons = append(buttons, map[string]any{
+			"type": "button",
+			"text": map[string]any{"type": "plain_text", "text": tr(locale, "action.acknowledge")},
+			"url":  ackURL,
+		})
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, map[string]any{"type": "actions", "elements": buttons})
+	}
+
+	return map[string]any{
+		// text is the fallback shown in push notifications
+		"text":   fmt.Sprintf("%s: %s %s", title, d.APIName, d.Version),
+		"blocks": blocks,
+	}
+}
+
+// slackEsc escapes the three characters Slack mrkdwn treats as control chars.
+func slackEsc(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// teamsChannel posts Adaptive Cards to a Microsoft Teams incoming webhook
+// (classic connector or Workflows "post to channel" URL).
+type teamsChannel struct{ client *http.Client }
+
+func (c teamsChannel) Deliver(ctx context.Context, to recipient, d dueNotification) error {
+	return postJSON(ctx, c.client, to.Target, teamsPayload(d, recipientLocale(to, d), noticeScheduledAt(to, d), noticeAckURL(to, d)))
+}
+
+func teamsPayload(d dueNotification, locale, scheduledAt, ackURL string) map[string]any {
+	facts := []map[string]string{
+		{"title": tr(locale, "label.api"), "value": d.APIName},
+		{"title": tr(locale, "label.version"), "value": d.Version},
+		{"title": tr(locale, "label.sunset_date"), "value": noticeSunset(locale, d)},
+		{"title": tr(locale, "label.scheduled_at"), "value": scheduledAt},
+	}
+	if d.BaseURL.Valid && strings.TrimSpace(d.BaseURL.String) != "" {
+		facts = append(facts, map[string]string{"title": tr(locale, "label.base_url"), "value": d.BaseURL.String})
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{
+				"type":   "TextBlock",
+				"text":   fmt.Sprintf("%s – %s %s", noticeTitle(locale, d.Type), d.APIName, d.Version),
+				"weight": "Bolder",
+				"size":   "Medium",
+				"wrap":   true,
+			},
+			{"type": "FactSet", "facts": facts},
+		},
+	}
+	var actions []map[string]any
+	if d.DocsURL.Valid && strings.TrimSpace(d.DocsURL.String) != "" {
+		actions = append(actions, map[string]any{
+			"type":  "Action.OpenUrl",
+			"title": tr(locale, "action.read_docs"),
+			"url":   d.DocsURL.String,
+		})
+	}
+	if ackURL != "" {
+		actions = append(actions, map[string]any{
+			"type":  "Action.OpenUrl",
+			"title": tr(locale, "action.acknowledge"),
+			"url":   ackURL,
+		})
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
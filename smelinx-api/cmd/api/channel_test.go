@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhook is an httptest incoming-webhook endpoint that records every post.
+type webhook struct {
+	*httptest.Server
+	mu     sync.Mutex
+	posts  map[string][]map[string]any // path -> decoded bodies
+	status map[string]int              // path -> status to answer (default 200)
+}
+
+func newWebhook(t *testing.T) *webhook {
+	t.Helper()
+	w := &webhook{posts: map[string][]map[string]any{}, status: map[string]int{}}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+			json.NewDecoder(r.Body).Decode(&body) != nil {
+			http.Error(rw, "want a JSON POST", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.mu.Lock()
+		w.posts[r.URL.Path] = append(w.posts[r.URL.Path], body)
+		status := w.status[r.URL.Path]
+		w.mu.Unlock()
+		if status != 0 {
+			http.Error(rw, "channel_not_found", status)
+			return
+		}
+		rw.Write([]byte("ok"))
+	}))
+	t.Cleanup(w.Close)
+	return w
+}
+
+func (w *webhook) received(path string) []map[string]any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.posts[path]
+}
+
+func (w *webhook) answer(path string, status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status[path] = status
+}
+
+func testNotice() dueNotification {
+	return dueNotification{
+		NoteID:      "n1",
+		APIID:       "a1",
+		OrgID:       "o1",
+		APIName:     "Payments <beta>",
+		VersionID:   "v1",
+		Version:     "v1",
+		SunsetDate:  sql.NullTime{Time: time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC), Valid: true},
+		Type:        "deprecate",
+		DocsURL:     sql.NullString{String: "https://docs.example.com/v1", Valid: true},
+		BaseURL:     sql.NullString{String: "https://api.example.com", Valid: true},
+		ScheduledAt: time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC),
+		Locale:      "en",
+	}
+}
+
+// jsonAt walks decoded JSON by object keys and array indexes.
+func jsonAt(v any, keys ...any) any {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[k]
+		case int:
+			a, _ := v.([]any)
+			if k >= len(a) {
+				return nil
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
+
+/* -------------------- payloads -------------------- */
+
+func TestSlackDeliver(t *testing.T) {
+	t.Setenv("PUBLIC_BASE_URL", "https://smelinx.test")
+	hook := newWebhook(t)
+	ch := slackChannel{client: hook.Client()}
+
+	to := recipient{Channel: "slack", Target: hook.URL + "/consumer", ConsumerID: "c1"}
+	if err := ch.Deliver(context.Background(), to, testNotice()); err != nil {
+		t.Fatal(err)
+	}
+	posts := hook.received("/consumer")
+	if len(posts) != 1 {
+		t.Fatalf("%d posts, want 1", len(posts))
+	}
+	p := posts[0]
+	if p["text"] != "Deprecation Notice: Payments <beta> v1" {
+		t.Errorf("fallback text = %q", p["text"])
+	}
+	if jsonAt(p, "blocks", 0, "type") != "header" || jsonAt(p, "blocks", 0, "text", "text") != "Deprecation Notice – Payments <beta> v1" {
+		t.Errorf("header block = %v", jsonAt(p, "blocks", 0))
+	}
+	if jsonAt(p, "blocks", 1, "type") != "section" || jsonAt(p, "blocks", 1, "fields", 0, "text") != "*API:*\nPayments &lt;beta&gt;" {
+		t.Errorf("section block = %v", jsonAt(p, "blocks", 1))
+	}
+	if f, _ := jsonAt(p, "blocks", 1, "fields").([]any); len(f) != 5 {
+		t.Errorf("%d fields, want API, version, sunset, scheduled and base URL", len(f))
+	}
+	if jsonAt(p, "blocks", 2, "type") != "actions" || jsonAt(p, "blocks", 2, "elements", 0, "url") != "https://docs.example.com/v1" {
+		t.Errorf("actions block = %v", jsonAt(p, "blocks", 2))
+	}
+	if u, _ := jsonAt(p, "blocks", 2, "elements", 1, "url").(string); !strings.HasPrefix(u, "https://smelinx.test/ack?token=") {
+		t.Errorf("acknowledge button url = %q", u)
+	}
+
+	// the API contact gets no acknowledge button; no docs, no actions at all
+	d := testNotice()
+	d.DocsURL, d.BaseURL = sql.NullString{}, sql.NullString{}
+	if err := ch.Deliver(context.Background(), recipient{Channel: "slack", Target: hook.URL + "/contact"}, d); err != nil {
+		t.Fatal(err)
+	}
+	p = hook.received("/contact")[0]
+	if b, _ := p["blocks"].([]any); len(b) != 2 {
+		t.Errorf("%d blocks without links, want header and section", len(b))
+	}
+}
+
+func TestTeamsDeliver(t *testing.T) {
+	t.Setenv("PUBLIC_BASE_URL", "https://smelinx.test")
+	hook := newWebhook(t)
+	ch := teamsChannel{client: hook.Client()}
+
+	to := recipient{Channel: "teams", Target: hook.URL + "/consumer", ConsumerID: "c1"}
+	if err := ch.Deliver(context.Background(), to, testNotice()); err != nil {
+		t.Fatal(err)
+	}
+	posts := hook.received("/consumer")
+	if len(posts) != 1 {
+		t.Fatalf("%d posts, want 1", len(posts))
+	}
+	p := posts[0]
+	if p["type"] != "message" || jsonAt(p, "attachments", 0, "contentType") != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("envelope = %v", p)
+	}
+	card := jsonAt(p, "attachments", 0, "content")
+	if jsonAt(card, "type") != "AdaptiveCard" || jsonAt(card, "version") != "1.4" {
+		t.Errorf("card = %v", card)
+	}
+	if jsonAt(card, "body", 0, "text") != "Deprecation Notice – Payments <beta> v1" {
+		t.Errorf("title = %v", jsonAt(card, "body", 0, "text"))
+	}
+	facts, _ := jsonAt(card, "body", 1, "facts").([]any)
+	if jsonAt(card, "body", 1, "type") != "FactSet" || len(facts) != 5 || jsonAt(facts, 0, "value") != "Payments <beta>" {
+		t.Errorf("facts = %v", facts)
+	}
+	if jsonAt(card, "actions", 0, "type") != "Action.OpenUrl" || jsonAt(card, "actions", 0, "url") != "https://docs.example.com/v1" {
+		t.Errorf("docs action = %v", jsonAt(card, "actions", 0))
+	}
+	if u, _ := jsonAt(card, "actions", 1, "url").(string); !strings.HasPrefix(u, "https://smelinx.test/ack?token=") {
+		t.Errorf("acknowledge action url = %q", u)
+	}
+
+	d := testNotice()
+	d.DocsURL = sql.NullString{}
+	if err := ch.Deliver(context.Background(), recipient{Channel: "teams", Target: hook.URL + "/contact"}, d); err != nil {
+		t.Fatal(err)
+	}
+	if a := jsonAt(hook.received("/contact")[0], "attachments", 0, "content", "actions"); a != nil {
+		t.Errorf("actions without links = %v", a)
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	hook := newWebhook(t)
+	statuses := map[string]int{"/gone": http.StatusNotFound, "/down": http.StatusServiceUnavailable}
+	for p, status := range statuses {
+		hook.answer(p, status)
+	}
+	for _, ch := range []Channel{slackChannel{client: hook.Client()}, teamsChannel{client: hook.Client()}} {
+		for p, status := range statuses {
+			err := ch.Deliver(context.Background(), recipient{Target: hook.URL + p}, testNotice())
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("returned %d: channel_not_found", status)) {
+				t.Errorf("%T to %s: err = %v, want the status and body", ch, p, err)
+			}
+		}
+	}
+	if err := postJSON(context.Background(), hook.Client(), hook.URL+"/ok", map[string]any{}); err != nil {
+		t.Errorf("200: %v", err)
+	}
+}
+
+/* -------------------- retries -------------------- */
+
+func TestDeliverAllSkipsSentTargets(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	_, org := seedOrg(t, s, "a@b.co")
+	api := seedAPI(t, s, org.ID, "Payments", nil)
+	v := seedVersion(t, s, api.ID, "v1", "deprecated", nil)
+	n, err := s.CreateNotification(ctx, api.ID, v.ID, "deprecate", time.Now().Add(-time.Minute), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := noticeFor(org, api, v, n.ID, "deprecate", n.ScheduledAt)
+
+	hook := newWebhook(t)
+	channels := map[string]Channel{
+		"slack": slackChannel{client: hook.Client()},
+		"teams": teamsChannel{client: hook.Client()},
+	}
+	recipients := []recipient{
+		{Channel: "slack", Target: hook.URL + "/a"},
+		{Channel: "teams", Target: hook.URL + "/b"},
+		{Channel: "slack", Target: hook.URL + "/c"},
+	}
+	counts := func() string {
+		return fmt.Sprintf("%d,%d,%d", len(hook.received("/a")), len(hook.received("/b")), len(hook.received("/c")))
+	}
+
+	hook.answer("/b", http.StatusBadGateway)
+	if _, err := deliverAll(ctx, s, channels, d, recipients); err == nil || !strings.HasPrefix(err.Error(), "teams:") {
+		t.Fatalf("first attempt: err = %v, want the teams failure", err)
+	}
+	if got := counts(); got != "1,1,1" {
+		t.Errorf("first attempt posts = %s, want 1,1,1", got)
+	}
+
+	// retry: only the failed target is posted to again
+	hook.answer("/b", 0)
+	if _, err := deliverAll(ctx, s, channels, d, recipients); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := counts(); got != "1,2,1" {
+		t.Errorf("after retry posts = %s, want 1,2,1", got)
+	}
+
+	if _, err := deliverAll(ctx, s, channels, d, recipients); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(); got != "1,2,1" {
+		t.Errorf("after everything was sent posts = %s, want no new posts", got)
+	}
+	done, err := s.SentDeliveryTargets(ctx, n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range recipients {
+		if !done[to.Channel+"|"+to.Target] {
+			t.Errorf("%s %s not recorded as sent", to.Channel, to.Target)
+		}
+	}
+}
+
+func TestDeliverAllRecordsSuppressionOnce(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	_, org := seedOrg(t, s, "a@b.co")
+	api := seedAPI(t, s, org.ID, "Payments", nil)
+	v := seedVersion(t, s, api.ID, "v1", "deprecated", nil)
+	n, err := s.CreateNotification(ctx, api.ID, v.ID, "deprecate", time.Now().Add(-time.Minute), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := noticeFor(org, api, v, n.ID, "deprecate", n.ScheduledAt)
+	if err := s.SuppressEmail(ctx, org.ID, "gone@acme.test", "bounce", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	recipients := []recipient{{Channel: "email", Target: "gone@acme.test"}}
+	for range 3 {
+		if _, err := deliverAll(ctx, s, map[string]Channel{}, d, recipients); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := s.ListNotificationDeliveries(ctx, n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Status != "failed" {
+		t.Errorf("deliveries after three attempts = %+v, want one failed row", rows)
+	}
+}
+
+/* -------------------- outbound URLs -------------------- */
+
+func TestValidWebhookURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"https://93.184.216.34/hook":                 true,
+		"http://[2606:4700::1111]/hook":              true,
+		"ftp://93.184.216.34/hook":                   false,
+		"https:///hook":                              false,
+		"http://127.0.0.1:8080/admin":                false,
+		"http://localhost/admin":                     false,
+		"http://10.1.2.3/":                           false,
+		"http://172.16.0.1/":                         false,
+		"http://192.168.1.1/":                        false,
+		"http://169.254.169.254/latest/meta-data/":   false,
+		"http://100.64.0.1/":                         false,
+		"http://0.0.0.0/":                            false,
+		"http://[::1]/":                              false,
+		"http://[fd00:ec2::254]/":                    false,
+		"http://[fe80::1]/":                          false,
+		"http://[::ffff:127.0.0.1]/":                 false,
+		"http://[64:ff9b::a9fe:a9fe]/":               false,
+		"https://host.does-not-resolve.invalid/hook": false,
+	} {
+		if got := validWebhookURL(url); got != want {
+			t.Errorf("validWebhookURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	if !validWebhookURL("http://10.1.2.3/hook") {
+		t.Error("WEBHOOK_ALLOW_PRIVATE=true still refuses a private host")
+	}
+}
+
+func TestOutboundClientRefusesPrivateAddresses(t *testing.T) {
+	hook := newWebhook(t) // listens on 127.0.0.1
+	client := outboundClient(5 * time.Second)
+
+	err := postJSON(context.Background(), client, hook.URL+"/a", map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Fatalf("post to loopback: err = %v, want a refusal", err)
+	}
+	if n := len(hook.received("/a")); n != 0 {
+		t.Errorf("loopback endpoint got %d posts", n)
+	}
+
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	if err := postJSON(context.Background(), client, hook.URL+"/a", map[string]any{}); err != nil {
+		t.Errorf("with WEBHOOK_ALLOW_PRIVATE=true: %v", err)
+	}
+}
+
+func TestUpstreamReason(t *testing.T) {
+	for body, want := range map[string]string{
+		"channel_not_found\n": ": channel_not_found",
+		"invalid_payload":     ": invalid_payload",
+		"":                    "",
+		"<html><body>Internal admin</body></html>": "",
+		`{"aws_secret_access_key":"..."}`:          "",
+		"Bad Request":                              "",
+		strings.Repeat("a", 65):                    "",
+	} {
+		if got := upstreamReason(strings.NewReader(body)); got != want {
+			t.Errorf("upstreamReason(%q) = %q, want %q", body, got, want)
+		}
+	}
+}
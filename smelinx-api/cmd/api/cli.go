@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// runCommand handles `smelinx <command> ...`; without arguments the binary
+// runs the server. It returns the process exit code.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "migrate":
+		return migrateCommand(args[1:])
+	case "backup":
+		return backupCommand(args[1:])
+	case "restore":
+		return restoreCommand(args[1:])
+	case "sync":
+		return syncCommand(args[1:])
+	case "openapi":
+		return openAPICommand(args[1:])
+	case "help", "-h", "--help":
+		usage()
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, `usage: %[1]s              run the API server
+       %[1]s migrate ...  manage the database schema (see %[1]s migrate help)
+       %[1]s backup [list] take a SQLite backup into BACKUP_DIR, or list them
+       %[1]s restore <file>
+                        replace the SQLite database with a backup (server stopped)
+       %[1]s sync -org <id> [-apply] [-prune] <smelinx.yaml>
+                        show (or apply) the changes that bring an org in line
+                        with a manifest
+       %[1]s openapi [check]
+                        print the OpenAPI document, or check that it lists
+                        every route
+`, name)
+}
+
+func migrateUsage() {
+	fmt.Fprintf(os.Stderr, `usage: %s migrate <command>
+  status           list migrations and whether they are applied (default)
+  up [version]     apply pending migrations, optionally only up to version
+  down [steps]     revert the newest applied migrations (default 1); the
+                   baseline (1) cannot be reverted
+  force <version>  record the schema as being at version without running
+                   anything; use it to clear a dirty schema after checking it
+`, filepath.Base(os.Args[0]))
+}
+
+func migrateCommand(args []string) int {
+	cmd := "status"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	if cmd == "help" || cmd == "-h" || cmd == "--help" {
+		migrateUsage()
+		return 0
+	}
+	num := func(def int) (int, bool) {
+		if len(args) == 0 {
+			return def, def >= 0
+		}
+		n, err := strconv.Atoi(args[0])
+		return n, err == nil && n >= 0
+	}
+
+	db, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	switch cmd {
+	case "status":
+		err = printMigrationStatus(ctx, db)
+	case "up":
+		target, ok := num(0)
+		if !ok {
+			migrateUsage()
+			return 2
+		}
+		var n int
+		n, err = migrateUp(ctx, db, target)
+		fmt.Printf("%d migration(s) applied\n", n)
+	case "down":
+		steps, ok := num(1)
+		if !ok {
+			migrateUsage()
+			return 2
+		}
+		var n int
+		n, err = migrateDown(ctx, db, steps)
+		fmt.Printf("%d migration(s) reverted\n", n)
+	case "force":
+		version, ok := num(-1)
+		if !ok {
+			migrateUsage()
+			return 2
+		}
+		if err = forceVersion(ctx, db, version); err == nil {
+			fmt.Printf("schema recorded at version %d\n", version)
+		}
+	default:
+		migrateUsage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, db *dbConn) error {
+	states, err := migrationStates(ctx, db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("driver: %s\n\n", db.driver())
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range states {
+		status, at := "pending", ""
+		switch {
+		case st.Dirty:
+			status = "DIRTY"
+		case st.Applied:
+			status = "applied"
+		}
+		if st.Applied && st.AppliedAt != nil {
+			at = st.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if _, known := findMigration(st.Version); !known {
+			status += " (unknown to this build)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", st.Version, st.Name, status, at)
+	}
+	return tw.Flush()
+}
+
+/* -------------------- backup / restore -------------------- */
+
+// backupCommand takes an online backup; the server may keep running.
+func backupCommand(args []string) int {
+	dir := backupDir()
+	if len(args) > 0 && args[0] == "list" {
+		list, err := listBackups(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSIZE\tCREATED AT")
+		for _, b := range list {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", b.Name, b.Size, b.CreatedAt.Format(time.RFC3339))
+		}
+		tw.Flush()
+		return 0
+	}
+	if len(args) > 0 {
+		usage()
+		return 2
+	}
+
+	db, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+	b, err := NewStore(db).Backup(context.Background(), dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("wrote %s (%d bytes)\n", b.Path, b.Size)
+	return 0
+}
+
+// restoreCommand swaps a backup in for SQLITE_PATH. A bare file name is
+// looked up in BACKUP_DIR.
+func restoreCommand(args []string) int {
+	if len(args) != 1 {
+		usage()
+		return 2
+	}
+	if _, ok := dbDialect().(sqliteDialect); !ok {
+		fmt.Fprintln(os.Stderr, errBackupUnsupported)
+		return 1
+	}
+	src := args[0]
+	if _, err := os.Stat(src); err != nil && filepath.Base(src) == src {
+		src = filepath.Join(backupDir(), src)
+	}
+
+	version, previous, err := restoreSQLite(context.Background(), src, os.Getenv("SQLITE_PATH"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore %s: %v\n", src, err)
+		return 1
+	}
+	if previous != "" {
+		fmt.Printf("previous database kept as %s\n", previous)
+	}
+	fmt.Printf("restored %s (schema at migration %d", src, version)
+	if version < latestMigration() {
+		fmt.Printf("; migrations up to %d run at the next start", latestMigration())
+	}
+	fmt.Println(")")
+	return 0
+}
+
+/* -------------------- sync -------------------- */
+
+// syncCommand plans a manifest against an org and, with -apply, applies it.
+func syncCommand(args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	orgID := fs.String("org", "", "org ID to sync (required)")
+	apply := fs.Bool("apply", false, "apply the plan instead of only showing it")
+	prune := fs.Bool("prune", false, "delete APIs and versions missing from the manifest")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *orgID == "" {
+		fmt.Fprintln(os.Stderr, "usage: sync -org <id> [-apply] [-prune] <smelinx.yaml>")
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	m, err := parseManifest(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	if problems := m.validate(); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", fs.Arg(0), p)
+		}
+		return 1
+	}
+
+	db, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if _, err := checkSchema(ctx, db); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	store := NewStore(db)
+	plan, err := store.PlanSync(ctx, *orgID, m, *prune)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(plan.String())
+	if !*apply || len(plan.Changes) == 0 {
+		return 0
+	}
+	if err := store.ApplySync(ctx, plan); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Println("no changes applied")
+		return 1
+	}
+	fmt.Printf("%d change(s) applied\n", len(plan.Changes))
+	return 0
+}
+
+/* -------------------- openapi -------------------- */
+
+// openAPICommand prints the OpenAPI document; `openapi check` exits 1 when a
+// route is undocumented or a documented operation has no route.
+func openAPICommand(args []string) int {
+	if len(args) == 0 {
+		doc, err := json.MarshalIndent(buildOpenAPI(), "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(doc))
+		return 0
+	}
+	if args[0] != "check" {
+		fmt.Fprintf(os.Stderr, "usage: %s openapi [check]\n", filepath.Base(os.Args[0]))
+		return 2
+	}
+	problems, err := checkOpenAPI(newRouter(&AuthService{}))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Printf("openapi: %d operations, every route documented\n", len(openAPIOps))
+	return 0
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/joho/godotenv"
 	_ "modernc.org/sqlite"
@@ -24,19 +28,86 @@ func getenv(k, def string) string {
 	return def
 }
 
-func mustOpenDB() *sql.DB {
-	path := os.Getenv("SQLITE_PATH")
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		log.Fatalf("mkdir data dir: %v", err)
+// dbDialect picks the storage backend: DB_DRIVER if set, else postgres when
+// DATABASE_URL is a postgres:// URL, else SQLite at SQLITE_PATH.
+func dbDialect() dialect {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER")))
+	if driver == "" {
+		u := strings.ToLower(os.Getenv("DATABASE_URL"))
+		if strings.HasPrefix(u, "postgres://") || strings.HasPrefix(u, "postgresql://") {
+			driver = "postgres"
+		}
 	}
-	db, err := sql.Open("sqlite", path)
+	switch driver {
+	case "", "sqlite", "sqlite3":
+		return sqliteDialect{}
+	case "postgres", "postgresql", "pg":
+		return postgresDialect{}
+	}
+	log.Fatalf("unknown DB_DRIVER %q (use sqlite or postgres)", driver)
+	return nil
+}
+
+// openDB connects to the configured database without touching the schema.
+func openDB() (*dbConn, error) {
+	d := dbDialect()
+	var dsn string
+	switch d.(type) {
+	case postgresDialect:
+		if os.Getenv("DATABASE_URL") == "" {
+			return nil, errors.New("DB_DRIVER=postgres needs DATABASE_URL")
+		}
+		dsn = postgresDSN(os.Getenv("DATABASE_URL"))
+	default:
+		path := os.Getenv("SQLITE_PATH")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("mkdir data dir: %w", err)
+		}
+		// several background workers write concurrently; wait for locks instead of failing with SQLITE_BUSY
+		dsn = path + "?_pragma=busy_timeout(5000)"
+	}
+	db, err := sql.Open(d.driver(), dsn)
 	if err != nil {
-		log.Fatalf("open sqlite: %v", err)
+		return nil, fmt.Errorf("open %s: %w", d.driver(), err)
 	}
 	if err := db.Ping(); err != nil {
-		log.Fatalf("ping sqlite: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("ping %s: %w", d.driver(), err)
+	}
+	return &dbConn{DB: db, dialect: d}, nil
+}
+
+// dataLock is the server's lock on the SQLite file; kept referenced so the
+// finalizer never closes it.
+var dataLock *os.File
+
+// mustOpenDB opens the database for the server and applies pending
+// migrations. With AUTO_MIGRATE=false they must be applied beforehand with
+// `migrate up`. A dirty schema, or one newer than this build, stops startup.
+// A SQLite file stays locked against `restore` while the server runs.
+func mustOpenDB() *dbConn {
+	db, err := openDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, ok := db.dialect.(sqliteDialect); ok {
+		if dataLock, err = lockDataFile(os.Getenv("SQLITE_PATH")); err != nil {
+			log.Fatalf("lock %s: %v", os.Getenv("SQLITE_PATH"), err)
+		}
+	}
+	ctx := context.Background()
+	if strings.EqualFold(os.Getenv("AUTO_MIGRATE"), "false") {
+		pending, err := checkSchema(ctx, db)
+		if err != nil {
+			log.Fatalf("migrations: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("migrations: %d pending and AUTO_MIGRATE=false; run `migrate up` first", len(pending))
+		}
+		return db
+	}
+	if _, err := migrateUp(ctx, db, 0); err != nil {
+		log.Fatalf("migrations: %v", err)
 	}
-	runMigrations(db)
 	return db
 }
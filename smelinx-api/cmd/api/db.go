@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/*
+Storage backends.
+
+Store queries are written once, with SQLite's ? placeholders. The few places
+where SQLite and PostgreSQL disagree (timestamp comparison, insertion order,
+DDL) go through a dialect; everything else is plain SQL both understand.
+Store satisfies Repository (repository.go) on both backends.
+
+A Store normally runs on the pool; Store.inTx binds one to a transaction
+instead. Methods that open a transaction of their own then get a savepoint
+inside it, so they commit or roll back with the caller.
+*/
+
+type dialect interface {
+	// driver is the database/sql driver name.
+	driver() string
+	// rebind rewrites ? placeholders into the driver's own syntax.
+	rebind(q string) string
+	// arg converts a query argument the driver would store differently.
+	arg(v any) any
+	// ts wraps a timestamp column (or a ? holding an RFC 3339 time) so it
+	// compares and sorts chronologically.
+	ts(expr string) string
+	// now is the current time, comparable with ts().
+	now() string
+	// insertOrder breaks ties between rows created in the same instant.
+	insertOrder(alias string) string
+	// ddl adapts a CREATE statement written for SQLite.
+	ddl(stmt string) string
+	// addColumn adds a column to table unless it already exists.
+	addColumn(ctx context.Context, tx *txConn, table, col, decl string) error
+}
+
+// conn is what Store queries run on: a dbConn or a txConn.
+type conn interface {
+	dialect
+	ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, q string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, q string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*txConn, error)
+}
+
+// dbConn is a *sql.DB that speaks the configured dialect. Store methods use
+// it exactly like *sql.DB.
+type dbConn struct {
+	*sql.DB
+	dialect
+}
+
+func (c *dbConn) ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	return c.DB.ExecContext(ctx, c.rebind(q), c.args(args)...)
+}
+
+func (c *dbConn) QueryContext(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	return c.DB.QueryContext(ctx, c.rebind(q), c.args(args)...)
+}
+
+func (c *dbConn) QueryRowContext(ctx context.Context, q string, args ...any) *sql.Row {
+	return c.DB.QueryRowContext(ctx, c.rebind(q), c.args(args)...)
+}
+
+func (c *dbConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*txConn, error) {
+	tx, err := c.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &txConn{Tx: tx, dialect: c.dialect}, nil
+}
+
+func (c *dbConn) args(in []any) []any { return dialectArgs(c.dialect, in) }
+
+// txConn is the transaction counterpart of dbConn. A txConn opened from
+// another one is a savepoint in the same transaction.
+type txConn struct {
+	*sql.Tx
+	dialect
+	savepoint string
+	done      bool
+}
+
+func (t *txConn) ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.rebind(q), t.args(args)...)
+}
+
+func (t *txConn) QueryContext(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, t.rebind(q), t.args(args)...)
+}
+
+func (t *txConn) QueryRowContext(ctx context.Context, q string, args ...any) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.rebind(q), t.args(args)...)
+}
+
+// BeginTx opens a savepoint; opts do not apply to it.
+func (t *txConn) BeginTx(ctx context.Context, _ *sql.TxOptions) (*txConn, error) {
+	sp := t.savepoint + "_"
+	if t.savepoint == "" {
+		sp = "sp"
+	}
+	if _, err := t.Tx.ExecContext(ctx, "SAVEPOINT "+sp); err != nil {
+		return nil, fmt.Errorf("savepoint: %w", err)
+	}
+	return &txConn{Tx: t.Tx, dialect: t.dialect, savepoint: sp}, nil
+}
+
+// Commit commits the transaction, or releases the savepoint.
+func (t *txConn) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	return t.endSavepoint("RELEASE SAVEPOINT ")
+}
+
+// Rollback rolls back the transaction, or everything since the savepoint.
+func (t *txConn) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	return t.endSavepoint("ROLLBACK TO SAVEPOINT ")
+}
+
+func (t *txConn) endSavepoint(stmt string) error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.ExecContext(context.Background(), stmt+t.savepoint)
+	return err
+}
+
+func (t *txConn) args(in []any) []any { return dialectArgs(t.dialect, in) }
+
+func dialectArgs(d dialect, in []any) []any {
+	out := make([]any, len(in))
+	for i, v := range in {
+		out[i] = d.arg(v)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresDialect runs the same schema on PostgreSQL (DATABASE_URL).
+// Timestamps are TIMESTAMPTZ and flags stay INTEGER, so rows look the same
+// to the store as they do on SQLite.
+type postgresDialect struct{}
+
+func (postgresDialect) driver() string { return "postgres" }
+
+// rebind numbers ? placeholders ($1, $2, ...), leaving quoted text alone.
+func (postgresDialect) rebind(q string) string {
+	if !strings.Contains(q, "?") {
+		return q
+	}
+	var b strings.Builder
+	b.Grow(len(q) + 16)
+	n, quoted := 0, false
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == '?' && !quoted:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// arg stores bools as 0/1; flag columns are INTEGER on both backends.
+func (postgresDialect) arg(v any) any {
+	if b, ok := v.(bool); ok {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return v
+}
+
+func (postgresDialect) ts(expr string) string {
+	if expr == "?" {
+		return "CAST(? AS TIMESTAMPTZ)"
+	}
+	return expr
+}
+
+func (postgresDialect) now() string { return "now()" }
+
+// insertOrder has no rowid to fall back on; created_at is precise to the
+// microsecond here, so id only decides genuine ties.
+func (postgresDialect) insertOrder(alias string) string { return qualify(alias, "id") }
+
+var postgresDDL = strings.NewReplacer(
+	"TIMESTAMP", "TIMESTAMPTZ",
+	"(datetime('now'))", "now()",
+)
+
+func (postgresDialect) ddl(stmt string) string { return postgresDDL.Replace(stmt) }
+
+func (d postgresDialect) addColumn(ctx context.Context, tx *txConn, table, col, decl string) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN IF NOT EXISTS `+d.ddl(decl))
+	return err
+}
+
+// postgresDSN pins the session time zone to UTC (unless the DSN sets one),
+// so timestamps come back in UTC as they do from SQLite.
+func postgresDSN(dsn string) string {
+	if strings.Contains(strings.ToLower(dsn), "timezone=") {
+		return dsn
+	}
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("timezone", "UTC")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return dsn + " timezone=UTC"
+}
@@ -0,0 +1,36 @@
+package main
+
+import "context"
+
+// sqliteDialect is the default backend: a single file at SQLITE_PATH.
+// Timestamps are TEXT, so comparisons go through julianday(), which accepts
+// both datetime('now') defaults and the RFC 3339 values the store writes.
+type sqliteDialect struct{}
+
+func (sqliteDialect) driver() string         { return "sqlite" }
+func (sqliteDialect) rebind(q string) string { return q }
+func (sqliteDialect) arg(v any) any          { return v }
+func (sqliteDialect) ts(expr string) string  { return "julianday(" + expr + ")" }
+func (sqliteDialect) now() string            { return "julianday('now')" }
+func (sqliteDialect) ddl(stmt string) string { return stmt }
+
+func (sqliteDialect) insertOrder(alias string) string { return qualify(alias, "rowid") }
+
+func (sqliteDialect) addColumn(ctx context.Context, tx *txConn, table, col, decl string) error {
+	var name string
+	row := tx.QueryRowContext(ctx, `SELECT name FROM pragma_table_info('`+table+`') WHERE name = ?`, col)
+	_ = row.Scan(&name)
+	if name == col {
+		return nil // already exists
+	}
+	_, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+decl)
+	return err
+}
+
+// qualify prefixes col with a table alias, if any.
+func qualify(alias, col string) string {
+	if alias == "" {
+		return col
+	}
+	return alias + "." + col
+}
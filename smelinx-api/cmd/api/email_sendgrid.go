@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
@@ -12,6 +14,36 @@ import (
 
 type Mailer interface {
 	Send(to, subject, html string) error
+	SendMessage(msg Message) error
+}
+
+// Message is a full outgoing email; Send is shorthand for a Message with only
+// To, Subject and HTML set.
+type Message struct {
+	To          string
+	Subject     string
+	HTML        string
+	Text        string // optional plain-text part; derived from HTML when empty
+	Attachments []Attachment
+	Unsubscribe string // one-click unsubscribe URL; sent as List-Unsubscribe (RFC 8058)
+	// Tags travel with the message and come back on provider events
+	// (SendGrid custom_args); other backends ignore them.
+	Tags map[string]string
+}
+
+// PlainText returns the plain-text alternative for the message.
+func (m Message) PlainText() string {
+	if strings.TrimSpace(m.Text) != "" {
+		return m.Text
+	}
+	return htmlToText(m.HTML)
+}
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Filename    string
+	ContentType string // e.g. "text/calendar; charset=utf-8; method=PUBLISH"
+	Content     []byte
 }
 
 type SendGridMailer struct {
@@ -35,12 +67,35 @@ func NewSendGridMailer() (*SendGridMailer, error) {
 }
 
 func (m *SendGridMailer) Send(to, subject, htmlStr string) error {
+	return m.SendMessage(Message{To: to, Subject: subject, HTML: htmlStr})
+}
+
+func (m *SendGridMailer) SendMessage(in Message) error {
 	from := mail.NewEmail(m.name, m.from)
-	toAddr := mail.NewEmail("", to)
-	plain := htmlToText(htmlStr)
-	msg := mail.NewSingleEmail(from, subject, toAddr, plain, htmlStr)
-	_, err := m.client.Send(msg)
-	return err
+	toAddr := mail.NewEmail("", in.To)
+	msg := mail.NewSingleEmail(from, in.Subject, toAddr, in.PlainText(), in.HTML)
+	for _, a := range in.Attachments {
+		msg.AddAttachment(mail.NewAttachment().
+			SetContent(base64.StdEncoding.EncodeToString(a.Content)).
+			SetType(a.ContentType).
+			SetFilename(a.Filename).
+			SetDisposition("attachment"))
+	}
+	if in.Unsubscribe != "" {
+		msg.SetHeader("List-Unsubscribe", "<"+in.Unsubscribe+">")
+		msg.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	for k, v := range in.Tags {
+		msg.SetCustomArg(k, v)
+	}
+	resp, err := m.client.Send(msg)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sendgrid returned %d: %s", resp.StatusCode, truncate(strings.TrimSpace(resp.Body), 300))
+	}
+	return nil
 }
 
 // naive HTML->text for plaintext part
@@ -51,6 +106,7 @@ func htmlToText(s string) string {
 	repl := strings.NewReplacer(
 		"<br>", "\n", "<br/>", "\n", "<br />", "\n",
 		"</p>", "\n\n", "</li>", "\n",
+		"</h1>", "\n\n", "</h2>", "\n\n", "</h3>", "\n\n",
 	)
 	s = repl.Replace(s)
 	// Strip other tags
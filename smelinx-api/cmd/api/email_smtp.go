@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends multipart (text + HTML) mail through any SMTP relay.
+//
+// Configuration:
+//
+//	SMTP_HOST, SMTP_PORT (default 587)
+//	SMTP_USERNAME, SMTP_PASSWORD (optional; PLAIN auth)
+//	SMTP_FROM (required), SMTP_FROM_NAME, SMTP_REPLY_TO
+//	SMTP_TLS = starttls (default) | implicit (default when port is 465) | none
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     mail.Address
+	replyTo  string
+	tlsMode  string
+	timeout  time.Duration
+	rootCAs  *x509.CertPool // nil: the system roots
+}
+
+func NewSMTPMailer() (*SMTPMailer, error) {
+	host := strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	from := strings.TrimSpace(os.Getenv("SMTP_FROM"))
+	if host == "" || from == "" {
+		return nil, errors.New("SMTP_HOST or SMTP_FROM missing")
+	}
+	port := getenv("SMTP_PORT", "587")
+
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_TLS")))
+	if mode == "" {
+		mode = "starttls"
+		if port == "465" {
+			mode = "implicit"
+		}
+	}
+	if mode != "starttls" && mode != "implicit" && mode != "none" {
+		return nil, fmt.Errorf("SMTP_TLS must be starttls, implicit or none (got %q)", mode)
+	}
+
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     mail.Address{Name: os.Getenv("SMTP_FROM_NAME"), Address: from},
+		replyTo:  strings.TrimSpace(os.Getenv("SMTP_REPLY_TO")),
+		tlsMode:  mode,
+		timeout:  time.Duration(getenvInt("SMTP_TIMEOUT_SECS", 20)) * time.Second,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(to, subject, htmlStr string) error {
+	return m.SendMessage(Message{To: to, Subject: subject, HTML: htmlStr})
+}
+
+func (m *SMTPMailer) SendMessage(in Message) error {
+	msg, err := m.buildMessage(in)
+	if err != nil {
+		return err
+	}
+
+	c, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if m.tlsMode == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(m.tlsConfig()); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if m.username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	if err := c.Rcpt(in.To); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err := wc.Write(msg); err != nil {
+		return fmt.Errorf("write body: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	return c.Quit()
+}
+
+// dial opens the connection, wrapping it in TLS first for implicit TLS (465).
+func (m *SMTPMailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.host, m.port)
+	conn, err := net.DialTimeout("tcp", addr, m.timeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(m.timeout))
+	if m.tlsMode == "implicit" {
+		conn = tls.Client(conn, m.tlsConfig())
+	}
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: m.host, RootCAs: m.rootCAs}
+}
+
+// buildMessage renders an RFC 5322 message: multipart/alternative with the
+// plain-text part (Message.PlainText) and the HTML part, wrapped in
+// multipart/mixed when there are attachments.
+func (m *SMTPMailer) buildMessage(in Message) ([]byte, error) {
+	altBoundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	domain := m.from.Address[strings.LastIndexByte(m.from.Address, '@')+1:]
+
+	var b bytes.Buffer
+	hdr := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	hdr("From", m.from.String())
+	hdr("To", (&mail.Address{Address: in.To}).String())
+	if m.replyTo != "" {
+		hdr("Reply-To", m.replyTo)
+	}
+	hdr("Subject", mime.QEncoding.Encode("utf-8", in.Subject))
+	hdr("Date", time.Now().Format(time.RFC1123Z))
+	hdr("Message-ID", fmt.Sprintf("<%s@%s>", newID(), domain))
+	if in.Unsubscribe != "" {
+		hdr("List-Unsubscribe", "<"+in.Unsubscribe+">")
+		hdr("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	hdr("MIME-Version", "1.0")
+
+	mixedBoundary := ""
+	if len(in.Attachments) > 0 {
+		if mixedBoundary, err = randomBoundary(); err != nil {
+			return nil, err
+		}
+		hdr("Content-Type", `multipart/mixed; boundary="`+mixedBoundary+`"`)
+		b.WriteString("\r\n")
+		fmt.Fprintf(&b, "--%s\r\n", mixedBoundary)
+	}
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n", altBoundary)
+
+	for _, part := range []struct{ ctype, body string }{
+		{"text/plain", in.PlainText()},
+		{"text/html", in.HTML},
+	} {
+		fmt.Fprintf(&b, "--%s\r\n", altBoundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\n", part.ctype)
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&b)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", altBoundary)
+
+	if mixedBoundary == "" {
+		return b.Bytes(), nil
+	}
+	for _, a := range in.Attachments {
+		fmt.Fprintf(&b, "--%s\r\n", mixedBoundary)
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", a.ContentType)
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=\"%s\"\r\n", a.Filename)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		enc := base64.StdEncoding.EncodeToString(a.Content)
+		for len(enc) > 76 {
+			b.WriteString(enc[:76] + "\r\n")
+			enc = enc[76:]
+		}
+		b.WriteString(enc + "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", mixedBoundary)
+	return b.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "smelinx-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake server saw on one connection.
+type smtpSession struct {
+	secure bool   // TLS was up when MAIL FROM arrived
+	auth   string // decoded AUTH PLAIN response
+	from   string
+	rcpt   []string
+	data   []byte
+}
+
+// fakeSMTP is an in-process SMTP server. tlsMode is "none", "starttls"
+// (advertised and required before MAIL) or "implicit" (TLS from the first byte).
+type fakeSMTP struct {
+	port     string
+	tlsMode  string
+	roots    *x509.CertPool
+	sessions chan smtpSession
+}
+
+func startFakeSMTP(t *testing.T, tlsMode string) *fakeSMTP {
+	t.Helper()
+	cert, roots := testCertificate(t)
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	f := &fakeSMTP{port: port, tlsMode: tlsMode, roots: roots, sessions: make(chan smtpSession, 4)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn, cfg)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn, cfg *tls.Config) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	secure := f.tlsMode == "implicit"
+	if secure {
+		conn = tls.Server(conn, cfg)
+	}
+	tp := textproto.NewConn(conn)
+	var s smtpSession
+	tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			exts := []string{"fake"}
+			if f.tlsMode == "starttls" && !secure {
+				exts = append(exts, "STARTTLS")
+			}
+			exts = append(exts, "AUTH PLAIN")
+			for i, e := range exts {
+				sep := "-"
+				if i == len(exts)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 go ahead")
+			tc := tls.Server(conn, cfg)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			conn, tp, secure = tc, textproto.NewConn(tc), true
+		case "AUTH":
+			mech, resp, _ := strings.Cut(arg, " ")
+			b, err := base64.StdEncoding.DecodeString(resp)
+			if mech != "PLAIN" || err != nil {
+				tp.PrintfLine("504 unsupported")
+				continue
+			}
+			s.auth = string(b)
+			tp.PrintfLine("235 ok")
+		case "MAIL":
+			if f.tlsMode == "starttls" && !secure {
+				tp.PrintfLine("530 must issue STARTTLS first")
+				continue
+			}
+			s.secure, s.from = secure, arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			if s.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			f.sessions <- s
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// testCertificate makes a self-signed certificate for 127.0.0.1 and a pool
+// that trusts it.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "smtp.test"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, roots
+}
+
+func testSMTPMailer(port, tlsMode string, roots *x509.CertPool) *SMTPMailer {
+	return &SMTPMailer{
+		host:     "127.0.0.1",
+		port:     port,
+		username: "smelinx",
+		password: "s3cret",
+		from:     mail.Address{Name: "Smelinx", Address: "noreply@smelinx.test"},
+		replyTo:  "support@smelinx.test",
+		tlsMode:  tlsMode,
+		timeout:  5 * time.Second,
+		rootCAs:  roots,
+	}
+}
+
+/* -------------------- transport -------------------- */
+
+func TestSMTPMailerHandshakes(t *testing.T) {
+	for _, mode := range []string{"none", "starttls", "implicit"} {
+		t.Run(mode, func(t *testing.T) {
+			srv := startFakeSMTP(t, mode)
+			m := testSMTPMailer(srv.port, mode, srv.roots)
+			if err := m.Send("dev@example.com", "Sunset", "<p>Hi</p>"); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			var s smtpSession
+			select {
+			case s = <-srv.sessions:
+			case <-time.After(5 * time.Second):
+				t.Fatal("server saw no complete session")
+			}
+			if s.secure != (mode != "none") {
+				t.Errorf("TLS during MAIL FROM = %v", s.secure)
+			}
+			if s.auth != "\x00smelinx\x00s3cret" {
+				t.Errorf("AUTH PLAIN = %q", s.auth)
+			}
+			if s.from != "FROM:<noreply@smelinx.test>" || len(s.rcpt) != 1 || s.rcpt[0] != "TO:<dev@example.com>" {
+				t.Errorf("envelope = %s %v", s.from, s.rcpt)
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(string(s.data)))
+			if err != nil {
+				t.Fatalf("message: %v", err)
+			}
+			if msg.Header.Get("To") != "<dev@example.com>" {
+				t.Errorf("To = %q", msg.Header.Get("To"))
+			}
+		})
+	}
+}
+
+func TestSMTPMailerRequiresSTARTTLS(t *testing.T) {
+	srv := startFakeSMTP(t, "none") // never advertises STARTTLS
+	m := testSMTPMailer(srv.port, "starttls", srv.roots)
+	err := m.Send("dev@example.com", "Sunset", "<p>Hi</p>")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("err = %v, want a STARTTLS error", err)
+	}
+}
+
+func TestSMTPMailerRejectsUntrustedCertificate(t *testing.T) {
+	srv := startFakeSMTP(t, "implicit")
+	m := testSMTPMailer(srv.port, "implicit", x509.NewCertPool())
+	if err := m.Send("dev@example.com", "Sunset", "<p>Hi</p>"); err == nil {
+		t.Fatal("sent over TLS to a server with an untrusted certificate")
+	}
+}
+
+/* -------------------- message -------------------- */
+
+func TestSMTPMessageHeaders(t *testing.T) {
+	m := testSMTPMailer("25", "none", nil)
+	raw, err := m.buildMessage(Message{
+		To:          "dev@example.com",
+		Subject:     "Payments v1 sunsets — 30 days left",
+		HTML:        "<p>Payments <b>v1</b> sunsets soon.</p>",
+		Unsubscribe: "https://app.smelinx.test/u/abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	for k, want := range map[string]string{
+		"From":                  `"Smelinx" <noreply@smelinx.test>`,
+		"Reply-To":              "support@smelinx.test",
+		"List-Unsubscribe":      "<https://app.smelinx.test/u/abc>",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		"MIME-Version":          "1.0",
+	} {
+		if got := msg.Header.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if subject != "Payments v1 sunsets — 30 days left" {
+		t.Errorf("Subject decodes to %q", subject)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@smelinx.test>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	parts := alternativeParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	if !strings.Contains(parts["text/plain"], "Payments v1 sunsets soon.") || strings.Contains(parts["text/plain"], "<b>") {
+		t.Errorf("text/plain part = %q", parts["text/plain"])
+	}
+	if parts["text/html"] != "<p>Payments <b>v1</b> sunsets soon.</p>" {
+		t.Errorf("text/html part = %q", parts["text/html"])
+	}
+
+	m.replyTo = ""
+	raw, _ = m.buildMessage(Message{To: "dev@example.com", Subject: "x", HTML: "<p>x</p>"})
+	msg, _ = mail.ReadMessage(strings.NewReader(string(raw)))
+	for _, k := range []string{"Reply-To", "List-Unsubscribe", "List-Unsubscribe-Post"} {
+		if v := msg.Header.Get(k); v != "" {
+			t.Errorf("%s = %q without one configured", k, v)
+		}
+	}
+}
+
+func TestSMTPMessageCalendarAttachment(t *testing.T) {
+	m := testSMTPMailer("25", "none", nil)
+	sunset := time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC)
+	ics := buildICS("", "PUBLISH", []icsEvent{sunsetICSEvent("v1", "Payments", "v1", sunset, "https://docs.example.com")})
+	raw, err := m.buildMessage(Message{
+		To:      "dev@example.com",
+		Subject: "Sunset",
+		HTML:    "<p>See the attached calendar entry.</p>",
+		Attachments: []Attachment{{
+			Filename:    "sunset.ics",
+			ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+			Content:     ics,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line of %d bytes", len(line))
+		}
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mt, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mt != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", msg.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	alt, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := alternativeParts(t, alt.Header.Get("Content-Type"), alt)
+	if parts["text/html"] != "<p>See the attached calendar entry.</p>" || parts["text/plain"] == "" {
+		t.Errorf("alternative parts = %q", parts)
+	}
+
+	att, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := att.Header.Get("Content-Type"); ct != "text/calendar; charset=utf-8; method=PUBLISH" {
+		t.Errorf("attachment Content-Type = %q", ct)
+	}
+	if att.FileName() != "sunset.ics" {
+		t.Errorf("attachment filename = %q", att.FileName())
+	}
+	body, _ := io.ReadAll(att)
+	got, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(body), "\r\n", ""))
+	if err != nil || string(got) != string(ics) {
+		t.Errorf("attachment decodes to %q (%v), want the ICS file", got, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line of %d chars", len(line))
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("after the attachment: %v, want the closing boundary", err)
+	}
+}
+
+// alternativeParts reads a multipart/alternative body into content type ->
+// decoded text, checking the plain part comes first.
+func alternativeParts(t *testing.T, contentType string, body io.Reader) map[string]string {
+	t.Helper()
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mt != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", contentType)
+	}
+	parts := map[string]string{}
+	var order []string
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		b, _ := io.ReadAll(p)
+		parts[pt] = string(b)
+		order = append(order, pt)
+	}
+	if strings.Join(order, ",") != "text/plain,text/html" {
+		t.Errorf("alternative parts %v, want text/plain then text/html", order)
+	}
+	return parts
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+/*
+Changelog feeds.
+
+Public Atom 1.0 and RSS 2.0 renderings of version lifecycle events. Entry IDs
+are urn:uuid: URIs of the underlying event, so they never change when the
+API is renamed or PUBLIC_BASE_URL moves.
+*/
+
+// changelogFeed is the format-neutral feed both renderers consume.
+type changelogFeed struct {
+	Title   string
+	SelfURL string
+	Link    string // docs URL of the API, or the public base URL
+	Entries []feedEntry
+}
+
+func (e feedEntry) title() string {
+	switch e.Type {
+	case "version.deprecated":
+		return fmt.Sprintf("%s %s deprecated", e.APIName, e.Version)
+	case "version.sunset":
+		return fmt.Sprintf("%s %s sunset", e.APIName, e.Version)
+	default:
+		return fmt.Sprintf("%s %s released", e.APIName, e.Version)
+	}
+}
+
+func (e feedEntry) summary() string {
+	switch e.Type {
+	case "version.deprecated":
+		if e.SunsetDate != nil {
+			return fmt.Sprintf("%s %s is deprecated and will be retired on %s. Please migrate before then.",
+				e.APIName, e.Version, e.SunsetDate.Format("2006-01-02"))
+		}
+		return fmt.Sprintf("%s %s is deprecated. Please plan your migration.", e.APIName, e.Version)
+	case "version.sunset":
+		return fmt.Sprintf("%s %s has been retired and no longer serves traffic.", e.APIName, e.Version)
+	default:
+		return fmt.Sprintf("%s %s is now available.", e.APIName, e.Version)
+	}
+}
+
+func (e feedEntry) link(fallback string) string {
+	return firstNonEmpty(e.DocsURL, fallback)
+}
+
+func (f changelogFeed) updated() time.Time {
+	if len(f.Entries) > 0 {
+		return f.Entries[0].CreatedAt
+	}
+	return time.Unix(0, 0)
+}
+
+/* -------------------- Atom -------------------- */
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Link     atomLink     `xml:"link"`
+	Summary  string       `xml:"summary"`
+	Category atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func (f changelogFeed) atom() ([]byte, error) {
+	out := atomFeed{
+		ID:      f.SelfURL,
+		Title:   f.Title,
+		Updated: f.updated().UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.SelfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate"},
+		},
+	}
+	for _, e := range f.Entries {
+		out.Entries = append(out.Entries, atomEntry{
+			ID:       "urn:uuid:" + e.EventID,
+			Title:    e.title(),
+			Updated:  e.CreatedAt.UTC().Format(time.RFC3339),
+			Link:     atomLink{Href: e.link(f.Link), Rel: "alternate"},
+			Summary:  e.summary(),
+			Category: atomCategory{Term: e.Type},
+		})
+	}
+	return marshalFeed(out)
+}
+
+/* -------------------- RSS -------------------- */
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+type rssItem struct {
+	GUID        rssGUID `xml:"guid"`
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func (f changelogFeed) rss() ([]byte, error) {
+	out := rssFeed{Version: "2.0"}
+	out.Channel.Title = f.Title
+	out.Channel.Link = f.Link
+	out.Channel.Description = f.Title
+	out.Channel.LastBuildDate = f.updated().UTC().Format(time.RFC1123Z)
+	for _, e := range f.Entries {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			GUID:        rssGUID{Value: "urn:uuid:" + e.EventID, IsPermaLink: "false"},
+			Title:       e.title(),
+			Link:        e.link(f.Link),
+			Description: e.summary(),
+			Category:    e.Type,
+			PubDate:     e.CreatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshalFeed(out)
+}
+
+func marshalFeed(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ackReq struct {
+	Status string `json:"status"`
+}
+
+// validate normalizes the request and reports invalid fields.
+func (req *ackReq) validate() (errs fieldErrors) {
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if !validAckStatus(req.Status) {
+		errs.invalid("status", "must be acknowledged or migrated")
+	}
+	return errs
+}
+
+/* -------------------- helpers -------------------- */
+
+// loadOrgVersion loads a version and its API, enforcing org scope.
+func (a *AuthService) loadOrgVersion(w http.ResponseWriter, r *http.Request) (*API, *APIVersion, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	v, err := a.store.GetVersionByID(r.Context(), chi.URLParam(r, "versionID"))
+	if err != nil {
+		writeNotFound(w, r)
+		return nil, nil, false
+	}
+	api, err := a.store.GetAPIByID(r.Context(), v.APIID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return nil, nil, false
+	}
+	return api, v, true
+}
+
+// ackTarget resolves the consumer/version pair a signed ack link was minted for.
+func (a *AuthService) ackTarget(w http.ResponseWriter, r *http.Request) (*Consumer, *API, *APIVersion, bool) {
+	subject, ok := verifyLink("ack", r.URL.Query().Get("token"))
+	consumerID, versionID, _ := strings.Cut(subject, ":")
+	if !ok || consumerID == "" || versionID == "" {
+		writeNotFound(w, r)
+		return nil, nil, nil, false
+	}
+	c, err := a.store.GetConsumerByID(r.Context(), consumerID)
+	if err != nil {
+		writeNotFound(w, r)
+		return nil, nil, nil, false
+	}
+	v, err := a.store.GetVersionByID(r.Context(), versionID)
+	if err != nil {
+		writeNotFound(w, r)
+		return nil, nil, nil, false
+	}
+	api, err := a.store.GetAPIByID(r.Context(), v.APIID)
+	if err != nil || api.OrgID != c.OrgID {
+		writeNotFound(w, r)
+		return nil, nil, nil, false
+	}
+	return c, api, v, true
+}
+
+func (a *AuthService) recordAck(r *http.Request, c *Consumer, api *API, v *APIVersion, status, source string) (*Acknowledgement, error) {
+	ack, err := a.store.RecordAcknowledgement(r.Context(), c.ID, v.ID, status, source)
+	if err != nil {
+		return nil, err
+	}
+	a.store.emitEvent(r.Context(), api.OrgID, "version.acknowledged", api.ID, v.ID, map[string]any{
+		"consumer":        map[string]any{"id": c.ID, "name": c.Name},
+		"api":             map[string]any{"id": api.ID, "name": api.Name},
+		"version":         v,
+		"acknowledgement": ack,
+	})
+	return ack, nil
+}
+
+var ackPage = template.Must(template.New("ack").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width,initial-scale=1">
+<title>Acknowledge {{.API.Name}} {{.Version.Version}}</title>
+<style>body{font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;max-width:560px;margin:40px auto;padding:0 16px;color:#111;line-height:1.5}
+.ok{background:#e8f7ee;padding:8px 12px;border-radius:6px}button{padding:8px 16px;margin-right:8px}</style>
+</head><body>
+<h2>{{.API.Name}} {{.Version.Version}}</h2>
+<p>Hi {{.Consumer.Name}}, {{.API.Name}} {{.Version.Version}} is <b>{{.Version.Status}}</b>{{if .Version.SunsetDate}} and will be retired on <b>{{.Version.SunsetDate.Format "2006-01-02"}}</b>{{end}}.</p>
+{{if .Ack}}<p class="ok">Recorded: <b>{{.Ack.Status}}</b> on {{.Ack.UpdatedAt.Format "2006-01-02"}}.</p>{{end}}
+<form method="post" action="{{.Action}}">
+<button type="submit" name="status" value="acknowledged">I acknowledge this notice</button>
+<button type="submit" name="status" value="migrated">I have migrated</button>
+</form>
+</body></html>`))
+
+/* -------------------- public (signed link) -------------------- */
+
+// GET /ack?token=... — confirmation page. Recording happens on POST only, so
+// mail scanners prefetching the link do not acknowledge on the consumer's behalf.
+func (a *AuthService) AckPageHandler(w http.ResponseWriter, r *http.Request) {
+	c, api, v, ok := a.ackTarget(w, r)
+	if !ok {
+		return
+	}
+	ack, _ := a.store.GetAcknowledgement(r.Context(), c.ID, v.ID)
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, map[string]any{"consumer_name": c.Name, "api_name": api.Name, "version": v, "acknowledgement": ack})
+		return
+	}
+	renderAckPage(w, r, c, api, v, ack)
+}
+
+// POST /ack?token=... — status=acknowledged|migrated (form or JSON)
+func (a *AuthService) AckHandler(w http.ResponseWriter, r *http.Request) {
+	c, api, v, ok := a.ackTarget(w, r)
+	if !ok {
+		return
+	}
+	var req ackReq
+	if wantsJSON(r) {
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+	} else {
+		req.Status = r.FormValue("status")
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	ack, err := a.recordAck(r, c, api, v, req.Status, "link")
+	if err != nil {
+		writeInternal(w, r, err, "save failed")
+		return
+	}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, ack)
+		return
+	}
+	renderAckPage(w, r, c, api, v, ack)
+}
+
+func renderAckPage(w http.ResponseWriter, r *http.Request, c *Consumer, api *API, v *APIVersion, ack *Acknowledgement) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = ackPage.Execute(w, map[string]any{
+		"Consumer": c, "API": api, "Version": v, "Ack": ack,
+		"Action": "/ack?token=" + r.URL.Query().Get("token"),
+	})
+}
+
+/* -------------------- report (authenticated) -------------------- */
+
+// GET /versions/{versionID}/acknowledgements
+func (a *AuthService) VersionAckReportHandler(w http.ResponseWriter, r *http.Request) {
+	api, v, ok := a.loadOrgVersion(w, r)
+	if !ok {
+		return
+	}
+	rows, err := a.store.ListVersionAcknowledgements(r.Context(), api.ID, v.ID)
+	if err != nil {
+		writeInternal(w, r, err, "report failed")
+		return
+	}
+	counts := map[string]int{"acknowledged": 0, "migrated": 0, "outstanding": 0}
+	for _, row := range rows {
+		counts[row.Status]++
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"api":       map[string]any{"id": api.ID, "name": api.Name},
+		"version":   v,
+		"total":     len(rows),
+		"counts":    counts,
+		"consumers": rows,
+	})
+}
+
+// PUT /versions/{versionID}/acknowledgements/{consumerID} — record on the
+// consumer's behalf (e.g. confirmed by phone)
+func (a *AuthService) SetAckHandler(w http.ResponseWriter, r *http.Request) {
+	api, v, ok := a.loadOrgVersion(w, r)
+	if !ok {
+		return
+	}
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	var req ackReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	ack, err := a.recordAck(r, c, api, v, req.Status, "manual")
+	if err != nil {
+		writeInternal(w, r, err, "save failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, ack)
+}
+
+// DELETE /versions/{versionID}/acknowledgements/{consumerID}
+func (a *AuthService) DeleteAckHandler(w http.ResponseWriter, r *http.Request) {
+	_, v, ok := a.loadOrgVersion(w, r)
+	if !ok {
+		return
+	}
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	if err := a.store.DeleteAcknowledgement(r.Context(), c.ID, v.ID); err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
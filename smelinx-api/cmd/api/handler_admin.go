@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+/* -------------------- instance admin -------------------- */
+
+// isInstanceAdmin reports whether the caller's email is listed in
+// ADMIN_EMAILS (comma-separated). Backups cover every org on the instance,
+// so org owners alone may not take or list them.
+func (a *AuthService) isInstanceAdmin(r *http.Request) bool {
+	admins := os.Getenv("ADMIN_EMAILS")
+	if admins == "" {
+		return false
+	}
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	u, err := a.store.GetUserByID(r.Context(), claims.Sub)
+	if err != nil {
+		return false
+	}
+	for _, e := range strings.Split(admins, ",") {
+		if strings.EqualFold(strings.TrimSpace(e), u.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /admin/backups
+func (a *AuthService) ListBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.isInstanceAdmin(r) {
+		writeError(w, r, http.StatusForbidden, codeForbidden, "instance admin only")
+		return
+	}
+	list, err := listBackups(backupDir())
+	if err != nil {
+		writeInternal(w, r, err, "list failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /admin/backups — take a backup now
+func (a *AuthService) CreateBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.isInstanceAdmin(r) {
+		writeError(w, r, http.StatusForbidden, codeForbidden, "instance admin only")
+		return
+	}
+	b, err := a.store.Backup(r.Context(), backupDir())
+	if errors.Is(err, errBackupUnsupported) {
+		writeError(w, r, http.StatusNotImplemented, codeNotImplemented, err.Error())
+		return
+	}
+	if err != nil {
+		writeInternal(w, r, err, "backup failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, b)
+}
@@ -2,7 +2,6 @@
 package main
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 
@@ -27,15 +26,50 @@ type updateAPIReq struct {
 	OwnerTeam    *string `json:"owner_team,omitempty"`
 }
 
+// validate normalizes the request and reports invalid fields.
+func (req *createAPIReq) validate() (errs fieldErrors) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errs.required("name")
+	}
+	if req.ContactEmail != nil && !validEmail(*req.ContactEmail) {
+		errs.invalid("contact_email", "not an email address")
+	}
+	return errs
+}
+
+func (req *updateAPIReq) validate() (errs fieldErrors) {
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		errs.invalid("name", "must not be empty")
+	}
+	if req.ContactEmail != nil && !validEmail(*req.ContactEmail) {
+		errs.invalid("contact_email", "not an email address")
+	}
+	return errs
+}
+
 // List APIs (org-scoped)
+// GET /apis?q=&owner_team=&sort=-created_at|name&limit=&cursor=
 func (a *AuthService) ListAPIsHandler(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
-	apis, err := a.store.ListAPIs(r.Context(), claims.OrgID)
+	q := r.URL.Query()
+	var errs fieldErrors
+	query := apiQuery{
+		pageQuery: parsePageQuery(q, apiSorts, "-created_at", &errs),
+		Search:    strings.TrimSpace(q.Get("q")),
+		OwnerTeam: strings.TrimSpace(q.Get("owner_team")),
+	}
+	if len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	apis, err := a.store.ListAPIs(r.Context(), claims.OrgID, query)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "list failed: " + err.Error()})
+		writeInternal(w, r, err, "list failed")
 		return
 	}
-	writeJSON(w, http.StatusOK, apis)
+	apis, next := trimPage(apis, query.pageQuery)
+	writePage(w, r, apis, next)
 }
 
 // Create API
@@ -43,18 +77,11 @@ func (a *AuthService) CreateAPIHandler(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
 
 	var req createAPIReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-
-	req.Name = strings.TrimSpace(req.Name)
-	if req.Name == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name required"})
-		return
-	}
-	if req.ContactEmail != nil && !validEmail(*req.ContactEmail) {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid contact_email"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
 
@@ -65,9 +92,11 @@ func (a *AuthService) CreateAPIHandler(w http.ResponseWriter, r *http.Request) {
 		OwnerTeam:    req.OwnerTeam,
 	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "create failed"})
+		writeInternal(w, r, err, "create failed")
 		return
 	}
+	a.store.emitEvent(r.Context(), claims.OrgID, "api.created", api.ID, "", api)
+	w.Header().Set("ETag", etag(api.Revision))
 	writeJSON(w, http.StatusCreated, api)
 }
 
@@ -78,7 +107,10 @@ func (a *AuthService) GetAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	api, err := a.store.GetAPIByID(r.Context(), id)
 	if err != nil || api.OrgID != claims.OrgID || api.DeletedAt != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
+		return
+	}
+	if notModified(w, r, etag(api.Revision)) {
 		return
 	}
 	writeJSON(w, http.StatusOK, api)
@@ -91,23 +123,25 @@ func (a *AuthService) UpdateAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	current, err := a.store.GetAPIByID(r.Context(), id)
 	if err != nil || current.OrgID != claims.OrgID || current.DeletedAt != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
+		return
+	}
+	if !checkIfMatch(w, r, current.Revision) {
 		return
 	}
 
 	var req updateAPIReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	if req.ContactEmail != nil && !validEmail(*req.ContactEmail) {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid contact_email"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
 
 	newName := current.Name
 	newDesc := current.Description
-	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+	if req.Name != nil {
 		newName = strings.TrimSpace(*req.Name)
 	}
 	if req.Description != nil {
@@ -121,11 +155,13 @@ func (a *AuthService) UpdateAPIHandler(w http.ResponseWriter, r *http.Request) {
 		OwnerTeam:    coalescePtr(current.OwnerTeam, req.OwnerTeam),
 	}
 
-	updated, err := a.store.UpdateAPI(r.Context(), id, newName, newDesc, meta)
+	updated, err := a.store.UpdateAPI(r.Context(), id, newName, newDesc, meta, current.Revision)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		writeUpdateError(w, r, err, "update failed")
 		return
 	}
+	a.store.emitEvent(r.Context(), claims.OrgID, "api.updated", updated.ID, "", updated)
+	w.Header().Set("ETag", etag(updated.Revision))
 	writeJSON(w, http.StatusOK, updated)
 }
 
@@ -136,16 +172,56 @@ func (a *AuthService) DeleteAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	api, err := a.store.GetAPIByID(r.Context(), id)
 	if err != nil || api.OrgID != claims.OrgID || api.DeletedAt != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
-	if err := a.store.DeleteAPI(r.Context(), id); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+	if !checkIfMatch(w, r, api.Revision) {
 		return
 	}
+	if err := a.store.DeleteAPI(r.Context(), id, api.Revision); err != nil {
+		writeUpdateError(w, r, err, "delete failed")
+		return
+	}
+	a.store.emitEvent(r.Context(), claims.OrgID, "api.deleted", api.ID, "", api)
 	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// GET /apis/{id}/auto-notify
+func (a *AuthService) GetAutoNotifyHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	api, err := a.store.GetAPIByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	on, err := a.store.APIAutoNotify(r.Context(), api.ID)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"enabled": on})
+}
+
+// PUT /apis/{id}/auto-notify — { "enabled": true }: moving a version to
+// deprecated or sunset (or changing its sunset date) enqueues a notice
+func (a *AuthService) SetAutoNotifyHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	api, err := a.store.GetAPIByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	on, ok := decodeEnabledSetting(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.SetAPIAutoNotify(r.Context(), api.ID, on); err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"enabled": on})
+}
+
 // coalescePtr returns b if provided (even if empty string), otherwise a.
 func coalescePtr(a, b *string) *string {
 	if b != nil {
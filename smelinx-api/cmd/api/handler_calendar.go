@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/* -------------------- helpers -------------------- */
+
+// calendarFeedURL builds the public subscription URL for an org or consumer feed.
+func calendarFeedURL(subject string) string {
+	return publicBaseURL() + "/calendar.ics?token=" + url.QueryEscape(signLink("calendar", subject))
+}
+
+func calendarEvents(entries []calendarEntry) []icsEvent {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	out := make([]icsEvent, 0, len(entries))
+	for _, e := range entries {
+		if e.Kind == "sunset" {
+			out = append(out, sunsetICSEvent(e.VersionID, e.APIName, e.Version, e.Date, e.DocsURL))
+			continue
+		}
+		out = append(out, icsEvent{
+			UID:         fmt.Sprintf("deprecation-%s@smelinx", e.RefID),
+			Date:        e.Date,
+			Summary:     fmt.Sprintf("%s %s deprecation notice", e.APIName, e.Version),
+			Description: fmt.Sprintf("%s %s is announced as deprecated on this date.", e.APIName, e.Version),
+			URL:         e.DocsURL,
+		})
+	}
+	return out
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /calendar — subscription URL for the caller's org
+func (a *AuthService) OrgCalendarLinkHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	writeJSON(w, http.StatusOK, map[string]string{"url": calendarFeedURL("org:" + claims.OrgID)})
+}
+
+// GET /consumers/{consumerID}/calendar — subscription URL for one consumer
+func (a *AuthService) ConsumerCalendarLinkHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"url": calendarFeedURL("consumer:" + c.ID)})
+}
+
+// GET /calendar.ics?token=... (public) — iCalendar feed of upcoming
+// deprecations and sunsets for the org or consumer the token was minted for.
+func (a *AuthService) CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	subject, ok := verifyLink("calendar", r.URL.Query().Get("token"))
+	if !ok {
+		writeNotFound(w, r)
+		return
+	}
+
+	var orgID, consumerID, calName string
+	switch kind, id, _ := strings.Cut(subject, ":"); kind {
+	case "org":
+		org, err := a.store.GetOrgByID(r.Context(), id)
+		if err != nil {
+			writeNotFound(w, r)
+			return
+		}
+		orgID, calName = org.ID, org.Name+" API lifecycle"
+	case "consumer":
+		c, err := a.store.GetConsumerByID(r.Context(), id)
+		if err != nil {
+			writeNotFound(w, r)
+			return
+		}
+		orgID, consumerID, calName = c.OrgID, c.ID, c.Name+" API lifecycle"
+	default:
+		writeNotFound(w, r)
+		return
+	}
+
+	entries, err := a.store.ListCalendarEntries(r.Context(), orgID, consumerID)
+	if err != nil {
+		writeInternal(w, r, err, "feed failed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="smelinx.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	_, _ = w.Write(buildICS(calName, "", calendarEvents(entries)))
+}
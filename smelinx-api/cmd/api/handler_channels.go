@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/* -------------------- request shapes -------------------- */
+
+type createChannelReq struct {
+	Kind       string `json:"kind"`        // "slack" | "teams"
+	Name       string `json:"name"`        // optional label, e.g. "#payments-dev"
+	WebhookURL string `json:"webhook_url"` // incoming webhook URL (http/https)
+}
+
+/* -------------------- helpers -------------------- */
+
+// validate normalizes the request and reports invalid fields.
+func (req *createChannelReq) validate() (errs fieldErrors) {
+	req.Kind = strings.ToLower(strings.TrimSpace(req.Kind))
+	req.Name = strings.TrimSpace(req.Name)
+	req.WebhookURL = strings.TrimSpace(req.WebhookURL)
+	if !validChannelKind(req.Kind) {
+		errs.invalid("kind", "must be slack or teams")
+	}
+	if !validWebhookURL(req.WebhookURL) {
+		errs.invalid("webhook_url", "must be an http(s) URL on a public host")
+	}
+	return errs
+}
+
+// decodeChannelReq parses and validates a channel payload; writes 400 on error.
+func decodeChannelReq(w http.ResponseWriter, r *http.Request) (*createChannelReq, bool) {
+	var req createChannelReq
+	if !decodeJSON(w, r, &req) {
+		return nil, false
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return nil, false
+	}
+	return &req, true
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /apis/{id}/channels
+func (a *AuthService) ListAPIChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+
+	list, err := a.store.ListAPIChannels(r.Context(), apiID)
+	if err != nil {
+		writeInternal(w, r, err, "list channels failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /apis/{id}/channels
+func (a *AuthService) CreateAPIChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID || api.DeletedAt != nil {
+		writeNotFound(w, r)
+		return
+	}
+	req, ok := decodeChannelReq(w, r)
+	if !ok {
+		return
+	}
+
+	ch, err := a.store.CreateChannel(r.Context(), claims.OrgID, &apiID, nil, req.Kind, req.Name, req.WebhookURL)
+	if err != nil {
+		writeInternal(w, r, err, "create channel failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, ch)
+}
+
+// GET /consumers/{consumerID}/channels
+func (a *AuthService) ListConsumerChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	list, err := a.store.ListConsumerChannels(r.Context(), c.ID)
+	if err != nil {
+		writeInternal(w, r, err, "list channels failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /consumers/{consumerID}/channels
+func (a *AuthService) CreateConsumerChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	req, ok := decodeChannelReq(w, r)
+	if !ok {
+		return
+	}
+
+	ch, err := a.store.CreateChannel(r.Context(), claims.OrgID, nil, &c.ID, req.Kind, req.Name, req.WebhookURL)
+	if err != nil {
+		writeInternal(w, r, err, "create channel failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, ch)
+}
+
+// DELETE /channels/{channelID}
+func (a *AuthService) DeleteChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	ch, err := a.store.GetChannelByID(r.Context(), chi.URLParam(r, "channelID"))
+	if err != nil || ch.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	if err := a.store.DeleteChannel(r.Context(), ch.ID); err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
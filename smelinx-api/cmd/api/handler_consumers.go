@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/* -------------------- request shapes -------------------- */
+
+type consumerReq struct {
+	Name            string  `json:"name"`
+	Email           *string `json:"email,omitempty"`
+	Timezone        *string `json:"timezone,omitempty"`
+	QuietHoursStart *string `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string `json:"quiet_hours_end,omitempty"`
+	Locale          *string `json:"locale,omitempty"`
+}
+
+func (req consumerReq) fields() consumerFields {
+	return consumerFields{
+		Name:            req.Name,
+		Email:           req.Email,
+		Timezone:        req.Timezone,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		Locale:          req.Locale,
+	}
+}
+
+/* -------------------- helpers -------------------- */
+
+// loadOrgConsumer fetches a consumer and enforces org scope; writes 404 otherwise.
+func (a *AuthService) loadOrgConsumer(w http.ResponseWriter, r *http.Request, id string) (*Consumer, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	c, err := a.store.GetConsumerByID(r.Context(), id)
+	if err != nil || c.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return nil, false
+	}
+	return c, true
+}
+
+// trimOptional trims an optional string, mapping blank to nil.
+func trimOptional(p *string) *string {
+	if p == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*p)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// validate trims fields and checks the optional email, time zone, quiet
+// hours and locale.
+func (req *consumerReq) validate() (errs fieldErrors) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errs.required("name")
+	}
+	if req.Email != nil {
+		e := strings.ToLower(strings.TrimSpace(*req.Email))
+		if e == "" {
+			req.Email = nil
+		} else if !validEmail(e) {
+			errs.invalid("email", "not an email address")
+		} else {
+			req.Email = &e
+		}
+	}
+	if req.Timezone = trimOptional(req.Timezone); req.Timezone != nil {
+		if loc, err := loadZone(*req.Timezone); err != nil {
+			errs.invalid("timezone", "%v", err)
+		} else {
+			name := loc.String()
+			req.Timezone = &name
+		}
+	}
+	req.QuietHoursStart = trimOptional(req.QuietHoursStart)
+	req.QuietHoursEnd = trimOptional(req.QuietHoursEnd)
+	if (req.QuietHoursStart == nil) != (req.QuietHoursEnd == nil) {
+		field := "quiet_hours_end"
+		if req.QuietHoursStart == nil {
+			field = "quiet_hours_start"
+		}
+		errs.add(field, "required", "quiet_hours_start and quiet_hours_end must be set together")
+	}
+	for i, p := range []*string{req.QuietHoursStart, req.QuietHoursEnd} {
+		if p == nil {
+			continue
+		}
+		h, m, err := parseClock(*p)
+		if err != nil {
+			errs.invalid([]string{"quiet_hours_start", "quiet_hours_end"}[i], "%v", err)
+			continue
+		}
+		*p = fmt.Sprintf("%02d:%02d", h, m)
+	}
+	if req.Locale = trimOptional(req.Locale); req.Locale != nil {
+		if l, err := normalizeLocale(*req.Locale); err != nil {
+			errs.invalid("locale", "%v", err)
+		} else {
+			req.Locale = &l
+		}
+	}
+	return errs
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /consumers
+func (a *AuthService) ListConsumersHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	list, err := a.store.ListConsumers(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "list consumers failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /consumers
+func (a *AuthService) CreateConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+
+	var req consumerReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+
+	c, err := a.store.CreateConsumer(r.Context(), claims.OrgID, req.fields())
+	if err != nil {
+		writeInternal(w, r, err, "create consumer failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// GET /consumers/{consumerID}
+func (a *AuthService) GetConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// PUT /consumers/{consumerID}
+func (a *AuthService) UpdateConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+
+	var req consumerReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+
+	updated, err := a.store.UpdateConsumer(r.Context(), c.ID, req.fields())
+	if err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DELETE /consumers/{consumerID}
+func (a *AuthService) DeleteConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	if err := a.store.DeleteConsumer(r.Context(), c.ID); err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /apis/{id}/consumers
+func (a *AuthService) ListAPIConsumersHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+
+	list, err := a.store.ListAPIConsumers(r.Context(), apiID)
+	if err != nil {
+		writeInternal(w, r, err, "list consumers failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// PUT /apis/{id}/consumers/{consumerID} — subscribe a consumer to an API
+func (a *AuthService) SubscribeConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID || api.DeletedAt != nil {
+		writeNotFound(w, r)
+		return
+	}
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+
+	if err := a.store.SubscribeConsumer(r.Context(), apiID, c.ID); err != nil {
+		writeInternal(w, r, err, "subscribe failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "subscribed"})
+}
+
+// DELETE /apis/{id}/consumers/{consumerID}
+func (a *AuthService) UnsubscribeConsumerHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+
+	if err := a.store.UnsubscribeConsumer(r.Context(), apiID, c.ID); err != nil {
+		writeInternal(w, r, err, "unsubscribe failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type digestSettingsReq struct {
+	Frequency   *string `json:"frequency"`
+	HorizonDays *int    `json:"horizon_days"`
+}
+
+// validate normalizes the request and reports invalid fields.
+func (req *digestSettingsReq) validate() (errs fieldErrors) {
+	if req.Frequency != nil {
+		f := strings.ToLower(strings.TrimSpace(*req.Frequency))
+		req.Frequency = &f
+		if !validDigestFrequency(f) {
+			errs.invalid("frequency", "must be off, daily or weekly")
+		}
+	}
+	if req.HorizonDays != nil && (*req.HorizonDays < 1 || *req.HorizonDays > 365) {
+		errs.invalid("horizon_days", "must be between 1 and 365")
+	}
+	return errs
+}
+
+// GET /me/digest
+func (a *AuthService) GetDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	m, err := a.store.GetMemberDigestSettings(r.Context(), claims.OrgID, claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// PUT /me/digest — { "frequency": "daily", "horizon_days": 14 }
+func (a *AuthService) UpdateDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	m, err := a.store.GetMemberDigestSettings(r.Context(), claims.OrgID, claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+
+	var req digestSettingsReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	if req.Frequency != nil {
+		m.Frequency = *req.Frequency
+	}
+	if req.HorizonDays != nil {
+		m.HorizonDays = *req.HorizonDays
+	}
+
+	if err := a.store.SaveMemberDigestSettings(r.Context(), claims.OrgID, claims.Sub, m.Frequency, m.HorizonDays); err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// GET /me/digest/preview — the digest the caller would receive right now
+func (a *AuthService) PreviewDigestHandler(w http.ResponseWriter, r *http.Request) {
+	msg, ok := a.currentDigest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"subject": msg.Subject, "html": msg.HTML, "text": msg.Text})
+}
+
+// POST /me/digest/send — email the current digest to the caller now, without
+// moving the regular schedule
+func (a *AuthService) SendDigestHandler(w http.ResponseWriter, r *http.Request) {
+	msg, ok := a.currentDigest(w, r)
+	if !ok {
+		return
+	}
+	if err := a.mailer.SendMessage(msg); err != nil {
+		log.Printf("[digest] send to %s: %v", msg.To, err)
+		writeError(w, r, http.StatusBadGateway, codeUpstream, "send failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "sent", "to": msg.To})
+}
+
+func (a *AuthService) currentDigest(w http.ResponseWriter, r *http.Request) (Message, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	m, err := a.store.GetMemberDigestSettings(r.Context(), claims.OrgID, claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return Message{}, false
+	}
+	if m.Frequency == "off" {
+		m.Frequency = "weekly" // preview as if enabled
+	}
+	d, err := buildMemberDigest(r.Context(), a.store, *m, time.Now().UTC())
+	if err != nil {
+		writeInternal(w, r, err, "build failed")
+		return Message{}, false
+	}
+	msg, err := d.Message(m.Email)
+	if err != nil {
+		writeInternal(w, r, err, "render failed")
+		return Message{}, false
+	}
+	return msg, true
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// custom_args keys set on outgoing notices (see emailTags)
+const (
+	tagOrgID          = "smelinx_org_id"
+	tagNotificationID = "smelinx_notification_id"
+)
+
+// sendgridEvent is one entry of a SendGrid Event Webhook POST.
+type sendgridEvent struct {
+	ID        string `json:"sg_event_id"`
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Event     string `json:"event"`
+	Reason    string `json:"reason"`
+	Type      string `json:"type"` // for "bounce": bounce (hard) | blocked (soft)
+
+	OrgID          string `json:"smelinx_org_id"`
+	NotificationID string `json:"smelinx_notification_id"`
+}
+
+// hardBounce reports whether e means the address will never accept mail.
+func (e sendgridEvent) hardBounce() bool {
+	switch e.Event {
+	case "bounce":
+		return e.Type != "blocked"
+	case "dropped":
+		return strings.Contains(strings.ToLower(e.Reason), "bounced address")
+	}
+	return false
+}
+
+/* -------------------- signature -------------------- */
+
+// sendgridWebhookKey parses SENDGRID_WEBHOOK_PUBLIC_KEY (the base64 DER key
+// shown when enabling the Signed Event Webhook, PEM also accepted).
+func sendgridWebhookKey() (*ecdsa.PublicKey, error) {
+	raw := strings.TrimSpace(os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY"))
+	if raw == "" {
+		return nil, errors.New("SENDGRID_WEBHOOK_PUBLIC_KEY not set")
+	}
+	var der []byte
+	if block, _ := pem.Decode([]byte(raw)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(raw); err != nil {
+			return nil, errors.New("SENDGRID_WEBHOOK_PUBLIC_KEY is not base64")
+		}
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("SENDGRID_WEBHOOK_PUBLIC_KEY is not an ECDSA key")
+	}
+	return ec, nil
+}
+
+// verifySendGridSignature checks the ECDSA signature SendGrid computes over
+// the timestamp header followed by the raw body.
+func verifySendGridSignature(pub *ecdsa.PublicKey, signature, timestamp string, body []byte) bool {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || timestamp == "" {
+		return false
+	}
+	h := sha256.New()
+	h.Write([]byte(timestamp))
+	h.Write(body)
+	return ecdsa.VerifyASN1(pub, h.Sum(nil), sig)
+}
+
+/* -------------------- handlers -------------------- */
+
+// POST /inbound/sendgrid (public, signed) — SendGrid Event Webhook. Updates
+// delivery records and suppresses hard-bounced / complaining addresses.
+func (a *AuthService) EmailEventsHandler(w http.ResponseWriter, r *http.Request) {
+	pub, err := sendgridWebhookKey()
+	if err != nil {
+		log.Printf("[email-events] rejected: %v", err)
+		writeNotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 5<<20))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "read failed")
+		return
+	}
+	if !verifySendGridSignature(pub,
+		r.Header.Get("X-Twilio-Email-Event-Webhook-Signature"),
+		r.Header.Get("X-Twilio-Email-Event-Webhook-Timestamp"), body) {
+		writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid signature")
+		return
+	}
+
+	var events []sendgridEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "invalid payload")
+		return
+	}
+	for _, e := range events {
+		if err := a.applyEmailEvent(r, e); err != nil {
+			// a 5xx makes SendGrid retry the batch; applied events are skipped as duplicates
+			writeInternal(w, r, fmt.Errorf("%s %s: %w", e.Event, e.ID, err), "apply failed")
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyEmailEvent records e together with its effects in one transaction, so
+// an event whose effects fail is not taken for a duplicate on the retry.
+func (a *AuthService) applyEmailEvent(r *http.Request, e sendgridEvent) error {
+	ctx := r.Context()
+	if e.ID == "" || e.Email == "" || e.Event == "" {
+		return nil
+	}
+	at := time.Now().UTC()
+	if e.Timestamp > 0 {
+		at = time.Unix(e.Timestamp, 0).UTC()
+	}
+	reason := truncate(strings.TrimSpace(e.Reason), 500)
+
+	return a.store.inTx(ctx, func(st *Store) error {
+		fresh, err := st.RecordEmailEvent(ctx, emailEvent{
+			ID: e.ID, OrgID: e.OrgID, NotificationID: e.NotificationID,
+			Email: e.Email, Event: e.Event, Reason: reason, OccurredAt: at,
+		})
+		if err != nil || !fresh {
+			return err
+		}
+
+		status := e.Event
+		if e.Event == "bounce" && !e.hardBounce() {
+			status = "deferred" // soft bounce; the provider keeps retrying
+		}
+		if e.NotificationID != "" {
+			if err := st.ApplyDeliveryEvent(ctx, e.NotificationID, e.Email, status, reason, at); err != nil {
+				return err
+			}
+		}
+		if e.OrgID == "" {
+			return nil
+		}
+
+		var suppress, eventType string
+		switch {
+		case e.hardBounce():
+			suppress, eventType = "bounce", "email.bounced"
+		case e.Event == "spamreport":
+			suppress, eventType = "spamreport", "email.complained"
+		default:
+			return nil
+		}
+		if err := st.SuppressEmail(ctx, e.OrgID, e.Email, suppress, reason); err != nil {
+			return err
+		}
+		email := strings.ToLower(e.Email)
+		log.Printf("[email-events] suppressed %s for org=%s (%s)", email, e.OrgID, suppress)
+		st.emitEvent(ctx, e.OrgID, eventType, "", "", map[string]any{
+			"email":           email,
+			"notification_id": e.NotificationID,
+			"reason":          reason,
+		})
+		return nil
+	})
+}
+
+// GET /suppressions
+func (a *AuthService) ListSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	list, err := a.store.ListEmailSuppressions(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "list suppressions failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// DELETE /suppressions/{email} — mail the address again
+func (a *AuthService) DeleteSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	email, err := url.PathUnescape(chi.URLParam(r, "email"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	ok, err := a.store.DeleteEmailSuppression(r.Context(), claims.OrgID, email)
+	if err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	if !ok {
+		writeNotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /notifications/{noteID}/deliveries — per-destination outcome, including
+// what the mail provider reported
+func (a *AuthService) ListNotificationDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	note, err := a.store.GetNotificationByID(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	api, err := a.store.GetAPIByID(r.Context(), note.APIID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	list, err := a.store.ListNotificationDeliveries(r.Context(), note.ID)
+	if err != nil {
+		writeInternal(w, r, err, "list deliveries failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmailEventRetriedAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	_, org := seedOrg(t, s, "a@b.co")
+	a := NewAuthService(s, nil)
+	r := httptest.NewRequest(http.MethodPost, "/inbound/sendgrid", nil)
+	e := sendgridEvent{ID: "evt-1", Email: "Dev@Acme.test", Event: "bounce", Type: "bounce", Reason: "550 no such user", OrgID: org.ID}
+
+	// the suppression cannot be written
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE email_suppressions RENAME TO email_suppressions_gone`); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.applyEmailEvent(r, e); err == nil {
+		t.Fatal("apply succeeded without a suppressions table")
+	}
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE email_suppressions_gone RENAME TO email_suppressions`); err != nil {
+		t.Fatal(err)
+	}
+
+	// SendGrid retries the batch; the event must not count as seen
+	if err := a.applyEmailEvent(r, e); err != nil {
+		t.Fatal(err)
+	}
+	sup, err := s.GetEmailSuppression(ctx, org.ID, "dev@acme.test")
+	if err != nil || sup.Reason != "bounce" {
+		t.Fatalf("suppression after the retry = %+v, %v; want a bounce", sup, err)
+	}
+	if fresh, err := s.RecordEmailEvent(ctx, emailEvent{ID: "evt-1", Email: e.Email, Event: e.Event}); err != nil || fresh {
+		t.Errorf("event after the retry: fresh = %v, %v; want it recorded once", fresh, err)
+	}
+}
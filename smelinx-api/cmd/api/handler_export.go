@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const maxBundleBytes = 16 << 20
+
+/* -------------------- helpers -------------------- */
+
+// orgParam checks that {orgID} is the caller's org; writes 404 otherwise.
+func orgParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	id := chi.URLParam(r, "orgID")
+	if id != claims.OrgID {
+		writeNotFound(w, r)
+		return "", false
+	}
+	return id, true
+}
+
+func wantsYAML(format, mediaType string) bool {
+	if format != "" {
+		return format == "yaml" || format == "yml"
+	}
+	return strings.Contains(mediaType, "yaml")
+}
+
+// validateBundle checks a bundle before anything is written and normalizes
+// it the way the create endpoints do. It returns one message per problem.
+func validateBundle(b *OrgBundle) []string {
+	var problems []string
+	bad := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if b.Format != bundleFormat {
+		bad("format: want %q", bundleFormat)
+	}
+	if b.Version != bundleSchema {
+		bad("version: unsupported bundle version %d (this server reads %d)", b.Version, bundleSchema)
+		return problems
+	}
+	if loc, err := loadZone(strings.TrimSpace(b.Org.Timezone)); err != nil {
+		bad("org.timezone: %v", err)
+	} else {
+		b.Org.Timezone = loc.String()
+	}
+	if l, err := normalizeLocale(b.Org.Locale); err != nil {
+		bad("org.locale: %v", err)
+	} else {
+		b.Org.Locale = l
+	}
+
+	ids := map[string]bool{}
+	newRef := func(path, id string) {
+		switch {
+		case id == "":
+			bad("%s.id: required", path)
+		case ids[id]:
+			bad("%s.id: duplicate id %q", path, id)
+		}
+		ids[id] = true
+	}
+
+	apiNames := map[string]bool{}
+	versionAPI := map[string]string{} // version id -> api id
+	for i := range b.APIs {
+		a := &b.APIs[i]
+		path := fmt.Sprintf("apis[%d]", i)
+		newRef(path, a.ID)
+		a.Name = strings.TrimSpace(a.Name)
+		if a.Name == "" {
+			bad("%s.name: required", path)
+		} else if apiNames[strings.ToLower(a.Name)] {
+			bad("%s.name: duplicate API %q", path, a.Name)
+		}
+		apiNames[strings.ToLower(a.Name)] = true
+		if a.ContactEmail != nil && !validEmail(*a.ContactEmail) {
+			bad("%s.contact_email: invalid", path)
+		}
+		versions := map[string]bool{}
+		for j := range a.Versions {
+			v := &a.Versions[j]
+			vpath := fmt.Sprintf("%s.versions[%d]", path, j)
+			newRef(vpath, v.ID)
+			versionAPI[v.ID] = a.ID
+			v.Version = strings.TrimSpace(v.Version)
+			if v.Version == "" {
+				bad("%s.version: required", vpath)
+			} else if versions[v.Version] {
+				bad("%s.version: duplicate version %q", vpath, v.Version)
+			}
+			versions[v.Version] = true
+			if !slices.Contains([]string{"active", "deprecated", "sunset"}, v.Status) {
+				bad("%s.status: must be active, deprecated or sunset", vpath)
+			}
+			if _, err := parseDatePtrYYYYMMDD(trimOptional(&v.SunsetDate)); err != nil {
+				bad("%s.sunset_date: want YYYY-MM-DD", vpath)
+			}
+		}
+	}
+
+	consumers := map[string]bool{}
+	for i := range b.Consumers {
+		c := &b.Consumers[i]
+		path := fmt.Sprintf("consumers[%d]", i)
+		newRef(path, c.ID)
+		req := consumerReq{Name: c.Name, Email: c.Email, Timezone: c.Timezone,
+			QuietHoursStart: c.QuietHoursStart, QuietHoursEnd: c.QuietHoursEnd, Locale: c.Locale}
+		if errs := req.validate(); len(errs) > 0 {
+			for _, e := range errs {
+				bad("%s.%s: %s", path, e.Field, e.Message)
+			}
+			continue
+		}
+		c.Name, c.Email, c.Timezone = req.Name, req.Email, req.Timezone
+		c.QuietHoursStart, c.QuietHoursEnd, c.Locale = req.QuietHoursStart, req.QuietHoursEnd, req.Locale
+		if key := consumerKey(c.Name, c.Email); consumers[key] {
+			bad("%s: duplicate consumer %q", path, c.Name)
+		} else {
+			consumers[key] = true
+		}
+		for j, sub := range c.Subscriptions {
+			if !apiIDIn(b.APIs, sub.APIID) {
+				bad("%s.subscriptions[%d].api_id: no API %q in the bundle", path, j, sub.APIID)
+			}
+		}
+		if p := c.Preferences; p != nil {
+			if p.Digest == "" {
+				p.Digest = "immediate"
+			}
+			if p.Digest != "immediate" && p.Digest != "weekly" {
+				bad("%s.preferences.digest: must be immediate or weekly", path)
+			}
+			for _, t := range p.NoticeTypes {
+				if !validNoticeType(t) {
+					bad("%s.preferences.notice_types: unknown type %q", path, t)
+				}
+			}
+		}
+	}
+
+	for i := range b.Notifications {
+		n := &b.Notifications[i]
+		path := fmt.Sprintf("notifications[%d]", i)
+		newRef(path, n.ID)
+		if api, ok := versionAPI[n.VersionID]; !ok || api != n.APIID {
+			bad("%s: version %q is not a version of API %q in the bundle", path, n.VersionID, n.APIID)
+		}
+		if !validNoticeType(n.Type) {
+			bad("%s.type: must be deprecate or sunset", path)
+		}
+		if !slices.Contains([]string{"pending", "sent", "canceled"}, n.Status) {
+			bad("%s.status: must be pending, sent or canceled", path)
+		}
+		if n.ScheduledAt.IsZero() {
+			bad("%s.scheduled_at: required", path)
+		}
+		if n.Timezone != "" {
+			if _, err := loadZone(n.Timezone); err != nil {
+				bad("%s.timezone: %v", path, err)
+			}
+		}
+	}
+
+	for i := range b.Templates {
+		t := &b.Templates[i]
+		path := fmt.Sprintf("templates[%d]", i)
+		if !validNoticeType(t.Type) {
+			bad("%s.type: must be deprecate or sunset", path)
+			continue
+		}
+		if t.Locale != "" {
+			l, err := normalizeLocale(t.Locale)
+			if err != nil {
+				bad("%s.locale: %v", path, err)
+				continue
+			}
+			t.Locale = l
+		}
+		tpl := noticeTemplate{Subject: strings.TrimSpace(t.Subject), HTML: t.HTML, Text: t.Text}
+		if tpl.Subject == "" || strings.TrimSpace(tpl.HTML) == "" {
+			bad("%s: subject and html required", path)
+			continue
+		}
+		if _, err := tpl.render(sampleTemplateData("Example Org", t.Type, t.Locale)); err != nil {
+			bad("%s: invalid template: %v", path, err)
+		}
+		t.Subject = tpl.Subject
+	}
+	return problems
+}
+
+func apiIDIn(apis []bundleAPI, id string) bool {
+	for _, a := range apis {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /orgs/{orgID}/export?format=json|yaml — the org as a portable bundle
+func (a *AuthService) ExportOrgHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := orgParam(w, r)
+	if !ok {
+		return
+	}
+	b, err := a.store.ExportOrg(r.Context(), orgID)
+	if err != nil {
+		writeInternal(w, r, err, "export failed")
+		return
+	}
+
+	name := "smelinx-export-" + b.ExportedAt.Format("20060102") + ".json"
+	if wantsYAML(r.URL.Query().Get("format"), r.Header.Get("Accept")) {
+		out, err := yaml.Marshal(b)
+		if err != nil {
+			writeInternal(w, r, err, "export failed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+strings.TrimSuffix(name, ".json")+`.yaml"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(out)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	writeJSON(w, http.StatusOK, b)
+}
+
+// POST /orgs/{orgID}/import?dry_run=true&on_conflict=fail|skip — body is a
+// bundle from /export (JSON, or YAML with a YAML Content-Type)
+func (a *AuthService) ImportOrgHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := orgParam(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	dryRun := q.Get("dry_run") == "true" || q.Get("dry_run") == "1"
+	onConflict := q.Get("on_conflict")
+	if onConflict == "" {
+		onConflict = "fail"
+	}
+	if onConflict != "fail" && onConflict != "skip" {
+		writeValidation(w, r, fieldErrors{{Field: "on_conflict", Code: "invalid", Message: "must be fail or skip"}})
+		return
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleBytes))
+	if err != nil {
+		writeError(w, r, http.StatusRequestEntityTooLarge, codeTooLarge, "bundle too large")
+		return
+	}
+	var b OrgBundle
+	if wantsYAML("", r.Header.Get("Content-Type")) {
+		err = yaml.Unmarshal(raw, &b)
+	} else {
+		err = json.Unmarshal(raw, &b)
+	}
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "invalid bundle: "+err.Error())
+		return
+	}
+	if problems := validateBundle(&b); len(problems) > 0 {
+		writeValidationStatus(w, r, http.StatusUnprocessableEntity, pathFieldErrors(problems))
+		return
+	}
+
+	rep, err := a.store.ImportOrg(r.Context(), orgID, &b, onConflict == "skip", dryRun)
+	if errors.Is(err, errImportConflicts) {
+		if dryRun {
+			writeJSON(w, http.StatusOK, rep)
+			return
+		}
+		writeProblem(w, r, problem{
+			Status: http.StatusConflict, Code: codeConflict, Detail: err.Error(),
+			Ext: map[string]any{"report": rep},
+		})
+		return
+	}
+	if err != nil {
+		writeInternal(w, r, err, "import failed")
+		return
+	}
+	if !dryRun {
+		log.Printf("[import] org %s: created %v, skipped %v", orgID, rep.Created, rep.Skipped)
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
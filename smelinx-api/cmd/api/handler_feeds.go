@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// feedEntryLimit caps how many events a feed carries.
+const feedEntryLimit = 50
+
+type enabledSettingReq struct {
+	Enabled *bool `json:"enabled"`
+}
+
+/* -------------------- helpers -------------------- */
+
+func feedURLs(path string) map[string]string {
+	base := publicBaseURL() + path
+	return map[string]string{"atom_url": base + ".atom", "rss_url": base + ".rss"}
+}
+
+func feedSettings(enabled bool, path string) map[string]any {
+	out := map[string]any{"enabled": enabled}
+	for k, v := range feedURLs(path) {
+		out[k] = v
+	}
+	return out
+}
+
+func (req *enabledSettingReq) validate() (errs fieldErrors) {
+	if req.Enabled == nil {
+		errs.required("enabled")
+	}
+	return errs
+}
+
+func decodeEnabledSetting(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	var req enabledSettingReq
+	if !decodeJSON(w, r, &req) {
+		return false, false
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return false, false
+	}
+	return *req.Enabled, true
+}
+
+// writeFeed renders f in the format named by the {format} URL param.
+func writeFeed(w http.ResponseWriter, r *http.Request, f changelogFeed) {
+	var (
+		body  []byte
+		err   error
+		ctype string
+	)
+	switch chi.URLParam(r, "format") {
+	case "atom":
+		ctype = "application/atom+xml; charset=utf-8"
+		body, err = f.atom()
+	case "rss":
+		ctype = "application/rss+xml; charset=utf-8"
+		body, err = f.rss()
+	default:
+		writeNotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeInternal(w, r, err, "feed failed")
+		return
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_, _ = w.Write(body)
+}
+
+/* -------------------- settings (authenticated) -------------------- */
+
+// GET /apis/{id}/feed
+func (a *AuthService) GetAPIFeedHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	on, err := a.store.APIFeedEnabled(r.Context(), api.ID)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, feedSettings(on, "/feeds/apis/"+api.ID))
+}
+
+// PUT /apis/{id}/feed — { "enabled": true }
+func (a *AuthService) SetAPIFeedHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	on, ok := decodeEnabledSetting(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.SetAPIFeed(r.Context(), api.ID, on); err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, feedSettings(on, "/feeds/apis/"+api.ID))
+}
+
+// GET /feed — org-wide feed settings
+func (a *AuthService) GetOrgFeedHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	on, err := a.store.OrgFeedEnabled(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, feedSettings(on, "/feeds/orgs/"+claims.OrgID))
+}
+
+// PUT /feed — { "enabled": true }
+func (a *AuthService) SetOrgFeedHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	on, ok := decodeEnabledSetting(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.SetOrgFeed(r.Context(), claims.OrgID, on); err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, feedSettings(on, "/feeds/orgs/"+claims.OrgID))
+}
+
+/* -------------------- public feeds -------------------- */
+
+// GET /feeds/apis/{id}.{format} — changelog of one API (format: atom | rss).
+// 404 unless the API opted in.
+func (a *AuthService) APIFeedHandler(w http.ResponseWriter, r *http.Request) {
+	api, err := a.store.GetAPIByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	if on, err := a.store.APIFeedEnabled(r.Context(), api.ID); err != nil || !on {
+		writeNotFound(w, r)
+		return
+	}
+	entries, err := a.store.ListFeedEntries(r.Context(), api.OrgID, api.ID, feedEntryLimit)
+	if err != nil {
+		writeInternal(w, r, err, "feed failed")
+		return
+	}
+	link := publicBaseURL()
+	if api.DocsURL != nil && *api.DocsURL != "" {
+		link = *api.DocsURL
+	}
+	writeFeed(w, r, changelogFeed{
+		Title:   api.Name + " changelog",
+		SelfURL: feedURLs("/feeds/apis/" + api.ID)[chi.URLParam(r, "format")+"_url"],
+		Link:    link,
+		Entries: entries,
+	})
+}
+
+// GET /feeds/orgs/{orgID}.{format} — changelog of every feed-enabled API of
+// the org. 404 unless the org opted in.
+func (a *AuthService) OrgFeedHandler(w http.ResponseWriter, r *http.Request) {
+	org, err := a.store.GetOrgByID(r.Context(), chi.URLParam(r, "orgID"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	if on, err := a.store.OrgFeedEnabled(r.Context(), org.ID); err != nil || !on {
+		writeNotFound(w, r)
+		return
+	}
+	entries, err := a.store.ListFeedEntries(r.Context(), org.ID, "", feedEntryLimit)
+	if err != nil {
+		writeInternal(w, r, err, "feed failed")
+		return
+	}
+	writeFeed(w, r, changelogFeed{
+		Title:   org.Name + " API changelog",
+		SelfURL: feedURLs("/feeds/orgs/" + org.ID)[chi.URLParam(r, "format")+"_url"],
+		Link:    publicBaseURL(),
+		Entries: entries,
+	})
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -15,17 +15,127 @@ import (
 /* -------------------- request/response shapes -------------------- */
 
 type createNotificationReq struct {
-	VersionID   string `json:"version_id"`   // required
-	Type        string `json:"type"`         // "deprecate" | "sunset"
-	ScheduledAt string `json:"scheduled_at"` // RFC3339 or "2006-01-02" (local date) – required
+	VersionID   string `json:"version_id"`          // required
+	Type        string `json:"type"`                // "deprecate" | "sunset"
+	ScheduledAt string `json:"scheduled_at"`        // RFC3339, "2006-01-02T15:04" or "2006-01-02" (local) – required
+	Timezone    string `json:"timezone,omitempty"`  // IANA zone; defaults to the org's
+	SendTime    string `json:"send_time,omitempty"` // "HH:MM" for a date-only scheduled_at (default 09:00)
 }
 
 type updateNotificationReq struct {
 	Status string `json:"status"` // "pending" | "sent" | "canceled"
 }
 
+// validate normalizes the request and reports invalid fields; scheduled_at
+// is parsed once the org's default zone is known.
+func (req *createNotificationReq) validate() (errs fieldErrors) {
+	req.VersionID = strings.TrimSpace(req.VersionID)
+	if req.VersionID == "" {
+		errs.required("version_id")
+	}
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if !validNoticeType(req.Type) {
+		errs.invalid("type", "must be deprecate or sunset")
+	}
+	if strings.TrimSpace(req.ScheduledAt) == "" {
+		errs.required("scheduled_at")
+	}
+	validateSchedule(&errs, req.ScheduledAt, req.Timezone, req.SendTime)
+	return errs
+}
+
+func (req *updateNotificationReq) validate() (errs fieldErrors) {
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status != "pending" && req.Status != "sent" && req.Status != "canceled" {
+		errs.invalid("status", "must be pending, sent or canceled")
+	}
+	return errs
+}
+
+func (req *previewNotificationReq) validate() (errs fieldErrors) {
+	req.VersionID = strings.TrimSpace(req.VersionID)
+	if req.VersionID == "" {
+		errs.required("version_id")
+	}
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if !validNoticeType(req.Type) {
+		errs.invalid("type", "must be deprecate or sunset")
+	}
+	if req.Locale != "" {
+		if l, err := normalizeLocale(req.Locale); err != nil {
+			errs.invalid("locale", "%v", err)
+		} else {
+			req.Locale = l
+		}
+	}
+	validateSchedule(&errs, req.ScheduledAt, req.Timezone, req.SendTime)
+	return errs
+}
+
+// validateSchedule checks the parts of a schedule that do not depend on the
+// org: the zone and the send time.
+func validateSchedule(errs *fieldErrors, scheduledAt, timezone, sendTime string) {
+	if timezone != "" {
+		if _, err := loadZone(timezone); err != nil {
+			errs.invalid("timezone", "%v", err)
+		}
+	}
+	if strings.TrimSpace(sendTime) == "" {
+		return
+	}
+	if _, _, err := parseClock(sendTime); err != nil {
+		errs.invalid("send_time", "%v", err)
+	} else if _, err := time.Parse(time.RFC3339, strings.TrimSpace(scheduledAt)); err == nil {
+		errs.invalid("send_time", "only applies to a date-only scheduled_at")
+	}
+}
+
+// scheduleIn resolves scheduled_at in the request's zone (else the org's);
+// writes 400 and returns false when it does not parse.
+func scheduleIn(w http.ResponseWriter, r *http.Request, org *Org, scheduledAt, timezone, sendTime string) (time.Time, *time.Location, bool) {
+	loc, err := loadZone(firstNonEmpty(timezone, org.Timezone))
+	if err != nil {
+		writeValidation(w, r, fieldErrors{{Field: "timezone", Code: "invalid", Message: err.Error()}})
+		return time.Time{}, nil, false
+	}
+	if strings.TrimSpace(scheduledAt) == "" {
+		return time.Now().UTC(), loc, true
+	}
+	when, err := parseWhen(scheduledAt, loc, sendTime)
+	if err != nil {
+		writeValidation(w, r, fieldErrors{{Field: "scheduled_at", Code: "invalid", Message: err.Error()}})
+		return time.Time{}, nil, false
+	}
+	return when, loc, true
+}
+
+// writeVersionNotFound answers 404 for a version_id that is not a version
+// of the API in the path.
+func writeVersionNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, problem{
+		Status: http.StatusNotFound, Code: codeNotFound, Detail: "version not found",
+		Errors: []fieldError{{Field: "version_id", Code: "not_found", Message: "no such version of this API"}},
+	})
+}
+
 /* -------------------- small helpers -------------------- */
 
+// rangeBound reads a date filter: RFC3339, or YYYY-MM-DD for midnight in loc.
+func rangeBound(q url.Values, name string, loc *time.Location, errs *fieldErrors) *time.Time {
+	s := strings.TrimSpace(q.Get(name))
+	if s == "" {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return &t
+	}
+	errs.invalid(name, "must be RFC3339 or YYYY-MM-DD")
+	return nil
+}
+
 // deref returns the string value of a *string, or "" if nil.
 func deref(p *string) string {
 	if p == nil {
@@ -39,21 +149,31 @@ func trimPtr(p *string) string {
 	return strings.TrimSpace(deref(p))
 }
 
-// parseWhen tries RFC3339 first, then YYYY-MM-DD (interpreted as 00:00:00 local).
-func parseWhen(s string) (time.Time, error) {
+// parseWhen resolves scheduled_at to an instant. RFC3339 values are absolute;
+// "2006-01-02T15:04" and "2006-01-02" are wall-clock times in loc, the latter
+// sent at sendTime ("HH:MM", default 09:00).
+func parseWhen(s string, loc *time.Location, sendTime string) (time.Time, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return time.Time{}, fmt.Errorf("scheduled_at required")
 	}
-	// Try RFC3339
 	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if strings.TrimSpace(sendTime) != "" {
+			return time.Time{}, fmt.Errorf("send_time only applies to a date-only scheduled_at")
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", s, loc); err == nil {
 		return t, nil
 	}
-	// Try YYYY-MM-DD
-	if d, err := time.Parse("2006-01-02", s); err == nil {
-		return d, nil
+	if d, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		h, m, err := parseClock(firstNonEmpty(sendTime, defaultSendTime))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("send_time: %w", err)
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc), nil
 	}
-	return time.Time{}, fmt.Errorf("scheduled_at must be RFC3339 or YYYY-MM-DD")
+	return time.Time{}, fmt.Errorf("scheduled_at must be RFC3339, YYYY-MM-DDTHH:MM or YYYY-MM-DD")
 }
 
 /*
@@ -78,24 +198,46 @@ func sendNotificationEmail(to, subject, _ string) error {
 
 /* -------------------- handlers -------------------- */
 
-// GET /apis/{id}/notifications
+// GET /apis/{id}/notifications?status=&type=&scheduled_after=&scheduled_before=
+// &sort=scheduled_at|created_at&limit=&cursor= — date-only bounds are
+// midnight in the org's time zone; scheduled_before is exclusive
 func (a *AuthService) ListNotificationsHandler(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
 	apiID := chi.URLParam(r, "id")
 
 	api, err := a.store.GetAPIByID(r.Context(), apiID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
+		return
+	}
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "load org failed")
+		return
+	}
+
+	q := r.URL.Query()
+	var errs fieldErrors
+	query := notificationQuery{
+		pageQuery: parsePageQuery(q, notificationSorts, "scheduled_at", &errs),
+		Status:    listFilter(q, "status", notificationStatuses, &errs),
+		Type:      listFilter(q, "type", notificationTypes, &errs),
+		From:      rangeBound(q, "scheduled_after", zoneOrUTC(org.Timezone), &errs),
+		To:        rangeBound(q, "scheduled_before", zoneOrUTC(org.Timezone), &errs),
+	}
+	if len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
 
 	// FIX: parameter order (apiID, orgID)
-	notes, err := a.store.ListNotifications(r.Context(), apiID, claims.OrgID)
+	notes, err := a.store.ListNotifications(r.Context(), apiID, claims.OrgID, query)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "list notifications failed: " + err.Error()})
+		writeInternal(w, r, err, "list notifications failed")
 		return
 	}
-	writeJSON(w, http.StatusOK, notes)
+	notes, next := trimPage(notes, query.pageQuery)
+	writePage(w, r, notes, next)
 }
 
 // POST /apis/{id}/notifications
@@ -105,50 +247,70 @@ func (a *AuthService) CreateNotificationHandler(w http.ResponseWriter, r *http.R
 
 	api, err := a.store.GetAPIByID(r.Context(), apiID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 
 	var req createNotificationReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
-	if req.Type != "deprecate" && req.Type != "sunset" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "type must be 'deprecate' or 'sunset'"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
-	versionID := strings.TrimSpace(req.VersionID)
-	if versionID == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "version_id required"})
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "load org failed")
 		return
 	}
-	when, err := parseWhen(req.ScheduledAt)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	when, loc, ok := scheduleIn(w, r, org, req.ScheduledAt, req.Timezone, req.SendTime)
+	if !ok {
 		return
 	}
 
 	// org-scope check for the version
+	versionID := req.VersionID
 	v, err := a.store.GetVersionByID(r.Context(), versionID)
 	if err != nil || v.APIID != apiID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeVersionNotFound(w, r)
 		return
 	}
 
-	note, err := a.store.CreateNotification(r.Context(), apiID, versionID, req.Type, when)
+	note, err := a.store.CreateNotification(r.Context(), apiID, versionID, req.Type, when, loc.String())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "create failed: " + err.Error()})
+		writeInternal(w, r, err, "create failed")
 		return
 	}
 
 	// CHANGE: do NOT send here; let the background dispatcher send when due.
 	// This avoids duplicate logic and respects centralized retry behavior.
+	a.store.emitEvent(r.Context(), claims.OrgID, "notification.scheduled", apiID, versionID, note)
 
+	w.Header().Set("ETag", etag(note.Revision))
 	writeJSON(w, http.StatusCreated, note)
 }
 
+// GET /notifications/{noteID}
+func (a *AuthService) GetNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+
+	note, err := a.store.GetNotificationByID(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	api, err := a.store.GetAPIByID(r.Context(), note.APIID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	if notModified(w, r, etag(note.Revision)) {
+		return
+	}
+	writeJSON(w, http.StatusOK, note)
+}
+
 // PUT /notifications/{noteID}
 func (a *AuthService) UpdateNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
@@ -156,33 +318,39 @@ func (a *AuthService) UpdateNotificationHandler(w http.ResponseWriter, r *http.R
 
 	note, err := a.store.GetNotificationByID(r.Context(), noteID)
 	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 
 	// Ensure the note's API is within the user's org
 	api, err := a.store.GetAPIByID(r.Context(), note.APIID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
+		return
+	}
+	if !checkIfMatch(w, r, note.Revision) {
 		return
 	}
 
 	var req updateNotificationReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	status := strings.ToLower(strings.TrimSpace(req.Status))
-	if status != "pending" && status != "sent" && status != "canceled" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be pending|sent|canceled"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
+	status := req.Status
 
-	updated, err := a.store.UpdateNotificationStatus(r.Context(), noteID, status)
+	updated, err := a.store.UpdateNotificationStatus(r.Context(), noteID, status, note.Revision)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed: " + err.Error()})
+		writeUpdateError(w, r, err, "update failed")
 		return
 	}
+	if status == "canceled" && note.Status != "canceled" {
+		a.store.emitEvent(r.Context(), claims.OrgID, "notification.canceled", note.APIID, note.VersionID, updated)
+	}
+	w.Header().Set("ETag", etag(updated.Revision))
 	writeJSON(w, http.StatusOK, updated)
 }
 
@@ -194,3 +362,136 @@ func safeDash(s string) string {
 	}
 	return s
 }
+
+/* -------------------- preview & test send -------------------- */
+
+type previewNotificationReq struct {
+	VersionID   string `json:"version_id"`            // required
+	Type        string `json:"type"`                  // "deprecate" | "sunset"
+	ConsumerID  string `json:"consumer_id,omitempty"` // optional; renders {{.ConsumerName}} in their zone/locale
+	Locale      string `json:"locale,omitempty"`      // optional override
+	ScheduledAt string `json:"scheduled_at,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	SendTime    string `json:"send_time,omitempty"`
+}
+
+// noticePreview is the email a notice would be sent as, attachments included.
+type noticePreview struct {
+	renderedNotice
+	Attachments []previewAttachment `json:"attachments"`
+}
+
+type previewAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // the calendar entry, as text
+}
+
+// POST /apis/{id}/notifications/preview
+// Renders exactly what the dispatcher would send for this version and type.
+func (a *AuthService) PreviewNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	apiID := chi.URLParam(r, "id")
+
+	api, err := a.store.GetAPIByID(r.Context(), apiID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+
+	var req previewNotificationReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "load org failed")
+		return
+	}
+	when, loc, ok := scheduleIn(w, r, org, req.ScheduledAt, req.Timezone, req.SendTime)
+	if !ok {
+		return
+	}
+
+	v, err := a.store.GetVersionByID(r.Context(), req.VersionID)
+	if err != nil || v.APIID != apiID {
+		writeVersionNotFound(w, r)
+		return
+	}
+
+	to := recipient{Channel: "email"}
+	if req.ConsumerID != "" {
+		c, ok := a.loadOrgConsumer(w, r, req.ConsumerID)
+		if !ok {
+			return
+		}
+		to = consumerRecipient(to, c)
+	}
+	if req.Locale != "" {
+		to.Locale = req.Locale
+	}
+
+	d := noticeFor(org, api, v, "", req.Type, when)
+	d.Timezone = loc.String()
+	msg := noticeEmail(r.Context(), a.store, d, to)
+	out := noticePreview{
+		renderedNotice: renderedNotice{Subject: msg.Subject, HTML: msg.HTML, Text: msg.PlainText()},
+		Attachments:    []previewAttachment{},
+	}
+	for _, att := range msg.Attachments {
+		out.Attachments = append(out.Attachments, previewAttachment{Filename: att.Filename, ContentType: att.ContentType, Content: string(att.Content)})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// POST /notifications/{noteID}/test-send
+// Sends the rendered notice to the requesting user only. The notification's
+// status, attempts and delivery log are left untouched.
+func (a *AuthService) TestSendNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	noteID := chi.URLParam(r, "noteID")
+
+	note, err := a.store.GetNotificationByID(r.Context(), noteID)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	api, err := a.store.GetAPIByID(r.Context(), note.APIID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return
+	}
+	v, err := a.store.GetVersionByID(r.Context(), note.VersionID)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "load org failed")
+		return
+	}
+	user, err := a.store.GetUserByID(r.Context(), claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "load user failed")
+		return
+	}
+
+	d := noticeFor(org, api, v, note.ID, note.Type, note.ScheduledAt)
+	if note.Timezone != "" {
+		d.Timezone = note.Timezone
+	}
+	msg := noticeEmail(r.Context(), a.store, d, recipient{Channel: "email", Target: user.Email})
+	msg.Subject = "[TEST] " + msg.Subject
+	msg.Tags = emailTags(d.OrgID, "") // provider events must not touch the real delivery log
+	if err := a.mailer.SendMessage(msg); err != nil {
+		log.Printf("[notify] test-send note=%s to=%s failed: %v", note.ID, user.Email, err)
+		writeError(w, r, http.StatusBadGateway, codeUpstream, "send failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "sent", "to": user.Email})
+}
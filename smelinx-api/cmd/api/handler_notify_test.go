@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// recordingMailer keeps every message instead of sending it.
+type recordingMailer struct{ sent []Message }
+
+func (m *recordingMailer) Send(to, subject, html string) error {
+	return m.SendMessage(Message{To: to, Subject: subject, HTML: html})
+}
+
+func (m *recordingMailer) SendMessage(msg Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// serveAs calls h as the signed-in claims with chi URL params set.
+func serveAs(h http.HandlerFunc, claims jwtClaims, method, body string, params map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	rc := chi.NewRouteContext()
+	for k, v := range params {
+		rc.URLParams.Add(k, v)
+	}
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rc)
+	ctx = context.WithValue(ctx, ctxKeyUser{}, claims)
+	w := httptest.NewRecorder()
+	h(w, r.WithContext(ctx))
+	return w
+}
+
+func TestPreviewAndTestSendMatchTheDispatcher(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	u, org := seedOrg(t, s, "owner@b.co")
+	api := seedAPI(t, s, org.ID, "Payments", nil)
+	sunset := time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC)
+	v := seedVersion(t, s, api.ID, "v1", "deprecated", &sunset)
+	c, err := s.CreateConsumer(ctx, org.ID, consumerFields{Name: "Acme", Email: ptr("dev@acme.test")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateTemplateVersion(ctx, org.ID, "sunset", "", noticeTemplate{
+		Subject: "Sunset of {{.APIName}}",
+		HTML:    "<p>HTML for {{.APIName}}</p>",
+		Text:    "Custom text for {{.APIName}}",
+	}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	mailer := &recordingMailer{}
+	a := NewAuthService(s, mailer)
+	claims := jwtClaims{Sub: u.ID, OrgID: org.ID, Role: "owner"}
+
+	w := serveAs(a.PreviewNotificationHandler, claims, http.MethodPost,
+		`{"version_id":"`+v.ID+`","type":"sunset","consumer_id":"`+c.ID+`"}`, map[string]string{"id": api.ID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: %d %s", w.Code, w.Body)
+	}
+	var p noticePreview
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(p.Text, "Custom text for Payments") || !strings.Contains(p.Text, "/preferences?token=") {
+		t.Errorf("preview text = %q, want the custom text with the preferences footer", p.Text)
+	}
+	if !strings.Contains(p.HTML, "HTML for Payments") || !strings.Contains(p.HTML, "/preferences?token=") {
+		t.Errorf("preview html = %q", p.HTML)
+	}
+	if len(p.Attachments) != 1 || p.Attachments[0].Filename != "sunset.ics" || !strings.Contains(p.Attachments[0].Content, "BEGIN:VCALENDAR") {
+		t.Errorf("preview attachments = %+v, want the sunset calendar entry", p.Attachments)
+	}
+
+	n, err := s.CreateNotification(ctx, api.ID, v.ID, "sunset", time.Now().Add(time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = serveAs(a.TestSendNotificationHandler, claims, http.MethodPost, "", map[string]string{"noteID": n.ID})
+	if w.Code != http.StatusOK || len(mailer.sent) != 1 {
+		t.Fatalf("test-send: %d %s, %d messages", w.Code, w.Body, len(mailer.sent))
+	}
+	msg := mailer.sent[0]
+	if msg.To != "owner@b.co" || msg.Subject != "[TEST] Sunset of Payments" || msg.Text != "Custom text for Payments" {
+		t.Errorf("test message = to %q subject %q text %q", msg.To, msg.Subject, msg.Text)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "sunset.ics" {
+		t.Errorf("test message attachments = %d, want the sunset calendar entry", len(msg.Attachments))
+	}
+	if _, ok := msg.Tags[tagNotificationID]; ok {
+		t.Error("test message is tagged with the notification; its events would touch the delivery log")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+)
+
+type updateOrgReq struct {
+	Timezone *string `json:"timezone"`
+	Locale   *string `json:"locale"`
+}
+
+// validate normalizes the request and reports invalid fields.
+func (req *updateOrgReq) validate() (errs fieldErrors) {
+	if req.Timezone != nil {
+		if loc, err := loadZone(*req.Timezone); err != nil {
+			errs.invalid("timezone", "%v", err)
+		} else {
+			name := loc.String()
+			req.Timezone = &name
+		}
+	}
+	if req.Locale != nil {
+		if l, err := normalizeLocale(*req.Locale); err != nil {
+			errs.invalid("locale", "%v", err)
+		} else {
+			req.Locale = &l
+		}
+	}
+	return errs
+}
+
+func orgView(o *Org) map[string]any {
+	return map[string]any{
+		"id":                o.ID,
+		"name":              o.Name,
+		"timezone":          o.Timezone,
+		"locale":            o.Locale,
+		"supported_locales": supportedLocales(),
+	}
+}
+
+// GET /org — the caller's org and its settings
+func (a *AuthService) GetOrgHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, orgView(org))
+}
+
+// PUT /org — { "timezone": "Asia/Tokyo", "locale": "ja" }
+func (a *AuthService) UpdateOrgHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	org, err := a.store.GetOrgByID(r.Context(), claims.OrgID)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+
+	var req updateOrgReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	if req.Timezone != nil {
+		if err := a.store.SetOrgTimezone(r.Context(), org.ID, *req.Timezone); err != nil {
+			writeInternal(w, r, err, "update failed")
+			return
+		}
+		org.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		if err := a.store.SetOrgLocale(r.Context(), org.ID, *req.Locale); err != nil {
+			writeInternal(w, r, err, "update failed")
+			return
+		}
+		org.Locale = *req.Locale
+	}
+	writeJSON(w, http.StatusOK, orgView(org))
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// optionalNoticeTypes are the notice types a consumer may opt out of; sunset
+// notices are always delivered.
+var optionalNoticeTypes = []string{"deprecate"}
+
+type preferencesReq struct {
+	Unsubscribed *bool    `json:"unsubscribed"`
+	NoticeTypes  []string `json:"notice_types"`
+	Digest       string   `json:"digest"`
+	MutedAPIIDs  []string `json:"muted_api_ids"`
+}
+
+// preferencesView is the JSON shape of a consumer's preferences.
+type preferencesView struct {
+	*ConsumerPreferences
+	ConsumerName string        `json:"consumer_name"`
+	APIs         []consumerAPI `json:"apis"`
+}
+
+/* -------------------- helpers -------------------- */
+
+func (a *AuthService) preferencesView(r *http.Request, c *Consumer) (*preferencesView, error) {
+	p, err := a.store.GetConsumerPreferences(r.Context(), c.ID)
+	if err != nil {
+		return nil, err
+	}
+	apis, err := a.store.ListConsumerAPIs(r.Context(), c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &preferencesView{ConsumerPreferences: p, ConsumerName: c.Name, APIs: apis}, nil
+}
+
+// validate reports invalid fields.
+func (req *preferencesReq) validate() (errs fieldErrors) {
+	for i, t := range req.NoticeTypes {
+		if !validNoticeType(t) {
+			errs.invalid(fmt.Sprintf("notice_types[%d]", i), "unknown notice type %q", t)
+		}
+	}
+	if req.Digest != "" && req.Digest != "immediate" && req.Digest != "weekly" {
+		errs.invalid("digest", "must be immediate or weekly")
+	}
+	return errs
+}
+
+// applyPreferencesReq merges a validated req into p.
+func applyPreferencesReq(p *ConsumerPreferences, req preferencesReq) {
+	if req.Unsubscribed != nil {
+		p.Unsubscribed = *req.Unsubscribed
+	}
+	if req.NoticeTypes != nil {
+		p.NoticeTypes = req.NoticeTypes
+	}
+	if req.Digest != "" {
+		p.Digest = req.Digest
+	}
+	if req.MutedAPIIDs != nil {
+		p.MutedAPIIDs = req.MutedAPIIDs
+	}
+}
+
+// preferencesFromForm maps the HTML form (checked = wanted) onto a request.
+func preferencesFromForm(r *http.Request, apis []consumerAPI) preferencesReq {
+	unsub := r.PostForm.Get("unsubscribed") != ""
+	req := preferencesReq{Unsubscribed: &unsub, Digest: r.PostForm.Get("digest"), MutedAPIIDs: []string{}}
+	for _, api := range apis {
+		if !slices.Contains(r.PostForm["api"], api.ID) {
+			req.MutedAPIIDs = append(req.MutedAPIIDs, api.ID)
+		}
+	}
+	wanted := r.PostForm["type"]
+	req.NoticeTypes = []string{} // every type
+	if slices.ContainsFunc(optionalNoticeTypes, func(t string) bool { return !slices.Contains(wanted, t) }) {
+		// opted out of something: store the explicit allow-list
+		for _, t := range optionalNoticeTypes {
+			if slices.Contains(wanted, t) {
+				req.NoticeTypes = append(req.NoticeTypes, t)
+			}
+		}
+		req.NoticeTypes = append(req.NoticeTypes, "sunset")
+	}
+	return req
+}
+
+// consumerFromPreferencesToken resolves the consumer a signed link was minted for.
+func (a *AuthService) consumerFromPreferencesToken(w http.ResponseWriter, r *http.Request) (*Consumer, bool) {
+	id, ok := verifyLink("preferences", r.URL.Query().Get("token"))
+	if !ok {
+		writeNotFound(w, r)
+		return nil, false
+	}
+	c, err := a.store.GetConsumerByID(r.Context(), id)
+	if err != nil {
+		writeNotFound(w, r)
+		return nil, false
+	}
+	return c, true
+}
+
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+}
+
+var preferencesPage = template.Must(template.New("prefs").Funcs(template.FuncMap{
+	"has": func(list []string, s string) bool { return slices.Contains(list, s) },
+}).Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width,initial-scale=1">
+<title>Notification preferences</title>
+<style>body{font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;max-width:560px;margin:40px auto;padding:0 16px;color:#111;line-height:1.5}
+fieldset{border:1px solid #ddd;border-radius:8px;margin:0 0 16px;padding:12px 16px}label{display:block;margin:4px 0}
+.note{color:#666;font-size:13px}.ok{background:#e8f7ee;padding:8px 12px;border-radius:6px}button{padding:8px 16px}</style>
+</head><body>
+<h2>Notification preferences for {{.View.ConsumerName}}</h2>
+{{if .Saved}}<p class="ok">Your preferences have been saved.</p>{{end}}
+<form method="post" action="{{.Action}}">
+<fieldset><legend>APIs</legend>
+{{range .View.APIs}}<label><input type="checkbox" name="api" value="{{.ID}}"{{if not .Muted}} checked{{end}}> {{.Name}}</label>
+{{else}}<p class="note">You are not subscribed to any API.</p>{{end}}
+</fieldset>
+<fieldset><legend>Notices</legend>
+{{range .Optional}}<label><input type="checkbox" name="type" value="{{.}}"{{if or (not $.View.NoticeTypes) (has $.View.NoticeTypes .)}} checked{{end}}> {{.}} notices</label>
+{{end}}<label><input type="checkbox" checked disabled> sunset notices</label>
+<p class="note">Sunset notices announce the retirement of an API you depend on and are always sent.</p>
+</fieldset>
+<fieldset><legend>Delivery</legend>
+<label><input type="radio" name="digest" value="immediate"{{if eq .View.Digest "immediate"}} checked{{end}}> Email each notice as it is sent</label>
+<label><input type="radio" name="digest" value="weekly"{{if eq .View.Digest "weekly"}} checked{{end}}> One weekly digest email</label>
+</fieldset>
+<fieldset><legend>Unsubscribe</legend>
+<label><input type="checkbox" name="unsubscribed" value="1"{{if .View.Unsubscribed}} checked{{end}}> Unsubscribe from everything except sunset notices</label>
+</fieldset>
+<button type="submit">Save preferences</button>
+</form>
+</body></html>`))
+
+func renderPreferencesPage(w http.ResponseWriter, r *http.Request, view *preferencesView, saved bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = preferencesPage.Execute(w, map[string]any{
+		"View":     view,
+		"Saved":    saved,
+		"Optional": optionalNoticeTypes,
+		"Action":   "/preferences?token=" + r.URL.Query().Get("token"),
+	})
+}
+
+/* -------------------- public (signed link) -------------------- */
+
+// GET /preferences?token=... — preferences page (HTML, or JSON with Accept: application/json)
+func (a *AuthService) PreferencesPageHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.consumerFromPreferencesToken(w, r)
+	if !ok {
+		return
+	}
+	view, err := a.preferencesView(r, c)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, view)
+		return
+	}
+	renderPreferencesPage(w, r, view, false)
+}
+
+// POST /preferences?token=... — save from the HTML form or a JSON body
+func (a *AuthService) SavePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.consumerFromPreferencesToken(w, r)
+	if !ok {
+		return
+	}
+	view, err := a.preferencesView(r, c)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+
+	var req preferencesReq
+	if wantsJSON(r) {
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidBody, "invalid form")
+			return
+		}
+		req = preferencesFromForm(r, view.APIs)
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	applyPreferencesReq(view.ConsumerPreferences, req)
+	if err := a.store.SaveConsumerPreferences(r.Context(), view.ConsumerPreferences); err != nil {
+		writeInternal(w, r, err, "save failed")
+		return
+	}
+
+	if view, err = a.preferencesView(r, c); err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, view)
+		return
+	}
+	renderPreferencesPage(w, r, view, true)
+}
+
+// POST /preferences/unsubscribe?token=... — RFC 8058 one-click unsubscribe
+// (the List-Unsubscribe target). Sunset notices keep flowing.
+func (a *AuthService) OneClickUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.consumerFromPreferencesToken(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.Unsubscribe(r.Context(), c.ID); err != nil {
+		writeInternal(w, r, err, "unsubscribe failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "unsubscribed"})
+}
+
+// GET /preferences/unsubscribe?token=... — a browser following the header
+// link lands on the preferences page rather than unsubscribing on GET.
+func (a *AuthService) UnsubscribeLandingHandler(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/preferences?token="+r.URL.Query().Get("token"), http.StatusSeeOther)
+}
+
+/* -------------------- org view (authenticated) -------------------- */
+
+// GET /consumers/{consumerID}/preferences
+func (a *AuthService) GetConsumerPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	view, err := a.preferencesView(r, c)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"preferences": view, "preferences_url": preferencesURL(c.ID)})
+}
+
+// PUT /consumers/{consumerID}/preferences
+func (a *AuthService) UpdateConsumerPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := a.loadOrgConsumer(w, r, chi.URLParam(r, "consumerID"))
+	if !ok {
+		return
+	}
+	var req preferencesReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	p, err := a.store.GetConsumerPreferences(r.Context(), c.ID)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	applyPreferencesReq(p, req)
+	if err := a.store.SaveConsumerPreferences(r.Context(), p); err != nil {
+		writeInternal(w, r, err, "save failed")
+		return
+	}
+	view, err := a.preferencesView(r, c)
+	if err != nil {
+		writeInternal(w, r, err, "load failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"preferences": view, "preferences_url": preferencesURL(c.ID)})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+/* -------------------- manifest sync -------------------- */
+
+// planFromRequest parses and validates the manifest body and plans it
+// against the caller's org; writes the error response otherwise.
+func (a *AuthService) planFromRequest(w http.ResponseWriter, r *http.Request) (*syncPlan, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleBytes))
+	if err != nil {
+		writeError(w, r, http.StatusRequestEntityTooLarge, codeTooLarge, "manifest too large")
+		return nil, false
+	}
+	m, err := parseManifest(bytes.NewReader(raw))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "invalid manifest: "+err.Error())
+		return nil, false
+	}
+	if problems := m.validate(); len(problems) > 0 {
+		writeValidationStatus(w, r, http.StatusUnprocessableEntity, pathFieldErrors(problems))
+		return nil, false
+	}
+	plan, err := a.store.PlanSync(r.Context(), claims.OrgID, m, r.URL.Query().Get("prune") == "true")
+	if err != nil {
+		writeInternal(w, r, err, "plan failed")
+		return nil, false
+	}
+	return plan, true
+}
+
+// POST /sync/plan?prune=true — body: smelinx.yaml (YAML or JSON); the changes
+// apply would make, without making them
+func (a *AuthService) PlanSyncHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := a.planFromRequest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"changes": plan.Changes, "diff": plan.String(), "applied": false})
+}
+
+// POST /sync/apply?prune=true — plans and applies the manifest
+func (a *AuthService) ApplySyncHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := a.planFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.ApplySync(r.Context(), plan); err != nil {
+		log.Printf("[error] %s %s %s: apply failed: %v", middleware.GetReqID(r.Context()), r.Method, r.URL.Path, err)
+		writeProblem(w, r, problem{
+			Status: http.StatusInternalServerError, Code: codeInternal, Detail: "apply failed; no changes were made",
+			Ext: map[string]any{"applied": false},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"changes": plan.Changes, "diff": plan.String(), "applied": true})
+}
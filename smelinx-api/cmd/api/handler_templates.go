@@ -0,0 +1,291 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/* -------------------- request/response shapes -------------------- */
+
+type saveTemplateReq struct {
+	Subject string `json:"subject"` // text/template
+	HTML    string `json:"html"`    // html/template
+	Text    string `json:"text"`    // optional text/template; derived from html when empty
+}
+
+type previewTemplateReq struct {
+	Type    string  `json:"type"`              // "deprecate" | "sunset"
+	Locale  string  `json:"locale,omitempty"`  // defaults to the org locale
+	Subject *string `json:"subject,omitempty"` // omitted parts use the active template
+	HTML    *string `json:"html,omitempty"`
+	Text    *string `json:"text,omitempty"`
+}
+
+// templateView is the active template for a type plus where it comes from.
+type templateView struct {
+	Type     string                `json:"type"`
+	Locale   string                `json:"locale,omitempty"`
+	Source   string                `json:"source"` // custom | default
+	Version  int                   `json:"version,omitempty"`
+	Template noticeTemplate        `json:"template"`
+	Stored   *NotificationTemplate `json:"stored,omitempty"`
+}
+
+// validate normalizes the request and reports invalid fields. Whether the
+// template renders is checked separately, against the type and locale.
+func (req *saveTemplateReq) validate() (errs fieldErrors) {
+	req.Subject = strings.TrimSpace(req.Subject)
+	if req.Subject == "" {
+		errs.required("subject")
+	}
+	if strings.TrimSpace(req.HTML) == "" {
+		errs.required("html")
+	}
+	return errs
+}
+
+func (req *previewTemplateReq) validate() (errs fieldErrors) {
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if !validNoticeType(req.Type) {
+		errs.invalid("type", "must be deprecate or sunset")
+	}
+	if req.Locale = strings.TrimSpace(req.Locale); req.Locale != "" {
+		if l, err := normalizeLocale(req.Locale); err != nil {
+			errs.invalid("locale", "%v", err)
+		} else {
+			req.Locale = l
+		}
+	}
+	return errs
+}
+
+// renderErrors reports a template that does not render ("html: ...")
+// against the part that failed.
+func renderErrors(err error) fieldErrors {
+	errs := pathFieldErrors([]string{err.Error()})
+	errs[0].Code = "invalid_template"
+	return errs
+}
+
+/* -------------------- helpers -------------------- */
+
+func validNoticeType(t string) bool { return t == "deprecate" || t == "sunset" }
+
+// activeTemplateView resolves the template the dispatcher would use for typ
+// and locale ("" = the locale-neutral template). A custom template reports
+// its own locale, which is "" when the requested one falls back to it.
+func (a *AuthService) activeTemplateView(r *http.Request, orgID, typ, locale string) templateView {
+	if t, err := a.store.GetActiveTemplate(r.Context(), orgID, typ, locale); err == nil {
+		return templateView{Type: typ, Locale: t.Locale, Source: "custom", Version: t.Version, Template: t.Template(), Stored: t}
+	}
+	return templateView{Type: typ, Locale: locale, Source: "default", Template: defaultTemplate(typ)}
+}
+
+// templateLocaleParam reads the optional ?locale= query; writes 400 for
+// unsupported locales.
+func templateLocaleParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	q := strings.TrimSpace(r.URL.Query().Get("locale"))
+	if q == "" {
+		return "", true
+	}
+	l, err := normalizeLocale(q)
+	if err != nil {
+		writeValidation(w, r, fieldErrors{{Field: "locale", Code: "invalid", Message: err.Error()}})
+		return "", false
+	}
+	return l, true
+}
+
+// sampleTemplateData is the preview/validation data rendered in locale.
+func sampleTemplateData(orgName, typ, locale string) templateData {
+	d, successor, consumer := sampleNotification(orgName, typ)
+	consumer.Locale = locale
+	return newTemplateData(d, successor, consumer)
+}
+
+// templateTypeParam reads and validates {type}; writes 404 for unknown types.
+func templateTypeParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	typ := strings.ToLower(chi.URLParam(r, "type"))
+	if !validNoticeType(typ) {
+		writeNotFound(w, r)
+		return "", false
+	}
+	return typ, true
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /templates?locale=de
+func (a *AuthService) ListTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	locale, ok := templateLocaleParam(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"templates": []templateView{
+			a.activeTemplateView(r, claims.OrgID, "deprecate", locale),
+			a.activeTemplateView(r, claims.OrgID, "sunset", locale),
+		},
+		"variables": templateVariables,
+		"locales":   supportedLocales(),
+	})
+}
+
+// GET /templates/{type}?locale=de
+func (a *AuthService) GetTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	typ, ok := templateTypeParam(w, r)
+	if !ok {
+		return
+	}
+	locale, ok := templateLocaleParam(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, a.activeTemplateView(r, claims.OrgID, typ, locale))
+}
+
+// PUT /templates/{type}?locale=de — saves a new version and makes it active
+// for that locale (no locale: the locale-neutral template)
+func (a *AuthService) SaveTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	typ, ok := templateTypeParam(w, r)
+	if !ok {
+		return
+	}
+	locale, ok := templateLocaleParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req saveTemplateReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	tpl := noticeTemplate{Subject: req.Subject, HTML: req.HTML, Text: req.Text}
+	// reject templates that do not parse or reference unknown variables
+	if _, err := tpl.render(sampleTemplateData("Example Org", typ, locale)); err != nil {
+		writeValidation(w, r, renderErrors(err))
+		return
+	}
+
+	saved, err := a.store.CreateTemplateVersion(r.Context(), claims.OrgID, typ, locale, tpl, claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "save template failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DELETE /templates/{type}?locale=de — drops all versions for the locale; the
+// locale-neutral template or the built-in default applies again
+func (a *AuthService) DeleteTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	typ, ok := templateTypeParam(w, r)
+	if !ok {
+		return
+	}
+	locale, ok := templateLocaleParam(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.DeleteTemplates(r.Context(), claims.OrgID, typ, locale); err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /templates/{type}/versions?locale=de
+func (a *AuthService) ListTemplateVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	typ, ok := templateTypeParam(w, r)
+	if !ok {
+		return
+	}
+	locale, ok := templateLocaleParam(w, r)
+	if !ok {
+		return
+	}
+	list, err := a.store.ListTemplateVersions(r.Context(), claims.OrgID, typ, locale)
+	if err != nil {
+		writeInternal(w, r, err, "list versions failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /templates/{type}/versions/{version}/restore — copies an old version into a new active one
+func (a *AuthService) RestoreTemplateVersionHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	typ, ok := templateTypeParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+	old, err := a.store.GetTemplateVersion(r.Context(), claims.OrgID, typ, n)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+
+	saved, err := a.store.CreateTemplateVersion(r.Context(), claims.OrgID, typ, old.Locale, old.Template(), claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "restore failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// POST /templates/preview — renders a draft (or the active template) with sample data
+func (a *AuthService) PreviewTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+
+	var req previewTemplateReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	typ := req.Type
+
+	orgName, locale := "", ""
+	if org, err := a.store.GetOrgByID(r.Context(), claims.OrgID); err == nil {
+		orgName, locale = org.Name, org.Locale
+	}
+	if req.Locale != "" {
+		locale = req.Locale
+	}
+
+	tpl := a.activeTemplateView(r, claims.OrgID, typ, locale).Template
+	if req.Subject != nil {
+		tpl.Subject = *req.Subject
+	}
+	if req.HTML != nil {
+		tpl.HTML = *req.HTML
+	}
+	if req.Text != nil {
+		tpl.Text = *req.Text
+	}
+
+	out, err := tpl.render(sampleTemplateData(orgName, typ, locale))
+	if err != nil {
+		writeValidation(w, r, renderErrors(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
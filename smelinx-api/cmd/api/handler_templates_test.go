@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestActiveTemplateViewReportsStoredLocale(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	u, org := seedOrg(t, s, "a@b.co")
+	if _, err := s.CreateTemplateVersion(ctx, org.ID, "sunset", "", noticeTemplate{
+		Subject: "Sunset of {{.APIName}}", HTML: "<p>{{.APIName}}</p>",
+	}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthService(s, nil)
+	r := httptest.NewRequest(http.MethodGet, "/templates/sunset?locale=de", nil)
+
+	// no German template: the locale-neutral one is used and says so
+	if v := a.activeTemplateView(r, org.ID, "sunset", "de"); v.Source != "custom" || v.Locale != "" {
+		t.Errorf("fallback view = source %q locale %q, want custom with no locale", v.Source, v.Locale)
+	}
+
+	if _, err := s.CreateTemplateVersion(ctx, org.ID, "sunset", "de", noticeTemplate{
+		Subject: "Abschaltung von {{.APIName}}", HTML: "<p>{{.APIName}}</p>",
+	}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if v := a.activeTemplateView(r, org.ID, "sunset", "de"); v.Locale != "de" || v.Template.Subject != "Abschaltung von {{.APIName}}" {
+		t.Errorf("German view = locale %q subject %q, want the German template", v.Locale, v.Template.Subject)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type createTokenReq struct {
+	Name          string `json:"name"`
+	ExpiresInDays int    `json:"expires_in_days,omitempty"` // 0 = never
+}
+
+// validate normalizes the request and reports invalid fields.
+func (req *createTokenReq) validate() (errs fieldErrors) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errs.required("name")
+	} else if len(req.Name) > 100 {
+		errs.invalid("name", "at most 100 characters")
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 3650 {
+		errs.invalid("expires_in_days", "must be between 0 and 3650")
+	}
+	return errs
+}
+
+// GET /me/tokens — the caller's personal access tokens (secrets not included)
+func (a *AuthService) ListTokensHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	list, err := a.store.ListAPITokens(r.Context(), claims.OrgID, claims.Sub)
+	if err != nil {
+		writeInternal(w, r, err, "list failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /me/tokens — body: {"name":"ci","expires_in_days":90}; the response
+// carries the token, which is not shown again. Only a signed-in session may
+// create tokens, so a leaked token cannot mint a longer-lived one.
+func (a *AuthService) CreateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	if claims.TokenID != "" {
+		writeError(w, r, http.StatusForbidden, codeForbidden, "tokens can only be created from a signed-in session")
+		return
+	}
+	var req createTokenReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	var exp *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().UTC().AddDate(0, 0, req.ExpiresInDays).Truncate(time.Second)
+		exp = &t
+	}
+	tok, err := a.store.CreateAPIToken(r.Context(), claims.OrgID, claims.Sub, claims.Role, req.Name, exp)
+	if err != nil {
+		writeInternal(w, r, err, "create failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, tok)
+}
+
+// DELETE /me/tokens/{tokenID} — revokes the token immediately
+func (a *AuthService) RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	err := a.store.RevokeAPIToken(r.Context(), claims.Sub, chi.URLParam(r, "tokenID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeNotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeInternal(w, r, err, "revoke failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateTokenNeedsSession(t *testing.T) {
+	t.Setenv("JWT_SIGNING_KEY", "test-key")
+	s := NewStore(migratedDB(t, openTestSQLite(t)))
+	u, org := seedOrg(t, s, "a@b.co")
+	a := NewAuthService(s, nil)
+	create := a.AuthMiddleware(http.HandlerFunc(a.CreateTokenHandler))
+
+	post := func(auth func(*http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(`{"name":"ci","expires_in_days":0}`))
+		r.Header.Set("Content-Type", "application/json")
+		auth(r)
+		w := httptest.NewRecorder()
+		create.ServeHTTP(w, r)
+		return w
+	}
+
+	access, _, err := a.issueTokens(u.ID, org.ID, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := post(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "access_token", Value: access}) })
+	if w.Code != http.StatusCreated {
+		t.Fatalf("from a session: %d %s", w.Code, w.Body)
+	}
+	var tok APIToken
+	if err := json.NewDecoder(w.Body).Decode(&tok); err != nil || tok.Token == "" {
+		t.Fatalf("token response: %v %+v", err, tok)
+	}
+
+	w = post(func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+tok.Token) })
+	if w.Code != http.StatusForbidden {
+		t.Errorf("from a token: %d %s, want 403", w.Code, w.Body)
+	}
+	list, err := s.ListAPITokens(context.Background(), org.ID, u.ID)
+	if err != nil || len(list) != 1 {
+		t.Errorf("tokens after the refused create: %d (%v), want 1", len(list), err)
+	}
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -22,6 +23,35 @@ type updateVersionReq struct {
 	SunsetDate *string `json:"sunset_date,omitempty"` // optional, "YYYY-MM-DD"
 }
 
+// validate normalizes the request and reports invalid fields.
+func (req *createVersionReq) validate() (errs fieldErrors) {
+	req.Version = strings.TrimSpace(req.Version)
+	if req.Version == "" {
+		errs.required("version")
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status == "" {
+		req.Status = "active"
+	}
+	validateVersionFields(&errs, req.Status, req.SunsetDate)
+	return errs
+}
+
+func (req *updateVersionReq) validate() (errs fieldErrors) {
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	validateVersionFields(&errs, req.Status, req.SunsetDate)
+	return errs
+}
+
+func validateVersionFields(errs *fieldErrors, status string, sunsetDate *string) {
+	if status != "active" && status != "deprecated" && status != "sunset" {
+		errs.invalid("status", "must be active, deprecated or sunset")
+	}
+	if _, err := parseDatePtrYYYYMMDD(sunsetDate); err != nil {
+		errs.invalid("sunset_date", "want YYYY-MM-DD")
+	}
+}
+
 /* -------------------- helpers -------------------- */
 
 func parseDatePtrYYYYMMDD(s *string) (*time.Time, error) {
@@ -35,26 +65,113 @@ func parseDatePtrYYYYMMDD(s *string) (*time.Time, error) {
 	return &t, nil
 }
 
+// versionUpdateEvent names the event for a status transition: entering
+// deprecated or sunset gets its own type, anything else is version.updated.
+func versionUpdateEvent(oldStatus, newStatus string) string {
+	if oldStatus != newStatus {
+		switch newStatus {
+		case "deprecated":
+			return "version.deprecated"
+		case "sunset":
+			return "version.sunset"
+		}
+	}
+	return "version.updated"
+}
+
+// autoNotice decides which notice, if any, an edit from old to updated
+// enqueues for an auto-notify API: entering deprecated or sunset, or moving
+// the sunset date of a version that already is. The key includes the sunset
+// date so re-saving the same state is a no-op.
+func autoNotice(old, updated *APIVersion) (typ, dedupeKey string) {
+	switch updated.Status {
+	case "deprecated":
+		typ = "deprecate"
+	case "sunset":
+		typ = "sunset"
+	default:
+		return "", ""
+	}
+	if old.Status == updated.Status && sameDate(old.SunsetDate, updated.SunsetDate) {
+		return "", ""
+	}
+	date := "none"
+	if updated.SunsetDate != nil {
+		date = updated.SunsetDate.UTC().Format("2006-01-02")
+	}
+	return typ, "auto:" + updated.ID + ":" + typ + ":" + date
+}
+
+// enqueueAutoNotice enqueues the notice autoNotice picks for an edit, if the
+// API has auto-notify on. Failures are logged; the edit itself stands.
+func enqueueAutoNotice(ctx context.Context, store *Store, api *API, old, updated *APIVersion) {
+	typ, key := autoNotice(old, updated)
+	if typ == "" {
+		return
+	}
+	on, err := store.APIAutoNotify(ctx, api.ID)
+	if err != nil {
+		log.Printf("[notify] auto-notify lookup failed api=%s: %v", api.ID, err)
+		return
+	}
+	if !on {
+		return
+	}
+	note, err := store.CreateAutoNotification(ctx, api.ID, updated.ID, typ, key)
+	switch {
+	case err != nil:
+		log.Printf("[notify] auto-notify failed version=%s: %v", updated.ID, err)
+	case note != nil:
+		store.emitEvent(ctx, api.OrgID, "notification.scheduled", api.ID, updated.ID, note)
+	}
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.UTC().Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
+
+// versionEventData is the webhook payload for version.* events.
+func versionEventData(api *API, v *APIVersion) map[string]any {
+	return map[string]any{
+		"api":     map[string]any{"id": api.ID, "name": api.Name, "docs_url": api.DocsURL},
+		"version": v,
+	}
+}
+
 /* -------------------- handlers -------------------- */
 
-// GET /apis/{id}/versions
+// GET /apis/{id}/versions?status=deprecated,sunset&sort=-created_at|version&limit=&cursor=
 func (a *AuthService) ListVersionsHandler(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
 	apiID := chi.URLParam(r, "id")
+	q := r.URL.Query()
+	var errs fieldErrors
+	query := versionQuery{
+		pageQuery: parsePageQuery(q, versionSorts, "-created_at", &errs),
+		Status:    listFilter(q, "status", versionStatuses, &errs),
+	}
+	if len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
 
 	// org-scope check
 	api, err := a.store.GetAPIByID(r.Context(), apiID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 
-	vers, err := a.store.ListVersions(r.Context(), apiID)
+	vers, err := a.store.ListVersions(r.Context(), apiID, query)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "list versions failed: " + err.Error()})
+		writeInternal(w, r, err, "list versions failed")
 		return
 	}
-	writeJSON(w, http.StatusOK, vers)
+	vers, next := trimPage(vers, query.pageQuery)
+	writePage(w, r, vers, next)
 }
 
 // POST /apis/{id}/versions
@@ -65,43 +182,48 @@ func (a *AuthService) CreateVersionHandler(w http.ResponseWriter, r *http.Reques
 	// org-scope check
 	api, err := a.store.GetAPIByID(r.Context(), apiID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 
 	var req createVersionReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-
-	ver := strings.TrimSpace(req.Version)
-	if ver == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "version required"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
+	sunsetTime, _ := parseDatePtrYYYYMMDD(req.SunsetDate)
 
-	status := strings.ToLower(strings.TrimSpace(req.Status))
-	if status == "" {
-		status = "active"
-	}
-	if status != "active" && status != "deprecated" && status != "sunset" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+	v, err := a.store.CreateVersion(r.Context(), apiID, req.Version, req.Status, sunsetTime)
+	if err != nil {
+		writeInternal(w, r, err, "create version failed")
 		return
 	}
+	a.store.emitEvent(r.Context(), claims.OrgID, "version.created", apiID, v.ID, versionEventData(api, v))
+	w.Header().Set("ETag", etag(v.Revision))
+	writeJSON(w, http.StatusCreated, v)
+}
+
+// GET /versions/{versionID}
+func (a *AuthService) GetVersionHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
 
-	sunsetTime, err := parseDatePtrYYYYMMDD(req.SunsetDate)
+	v, err := a.store.GetVersionByID(r.Context(), chi.URLParam(r, "versionID"))
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid sunset_date (YYYY-MM-DD)"})
+		writeNotFound(w, r)
 		return
 	}
-
-	v, err := a.store.CreateVersion(r.Context(), apiID, ver, status, sunsetTime)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "create version failed: " + err.Error()})
+	api, err := a.store.GetAPIByID(r.Context(), v.APIID)
+	if err != nil || api.OrgID != claims.OrgID {
+		writeNotFound(w, r)
 		return
 	}
-	writeJSON(w, http.StatusCreated, v)
+	if notModified(w, r, etag(v.Revision)) {
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
 }
 
 // PUT /versions/{versionID}
@@ -112,36 +234,37 @@ func (a *AuthService) UpdateVersionHandler(w http.ResponseWriter, r *http.Reques
 	// load version + api to enforce org scope
 	v, err := a.store.GetVersionByID(r.Context(), versionID)
 	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 	api, err := a.store.GetAPIByID(r.Context(), v.APIID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
-
-	var req updateVersionReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	if !checkIfMatch(w, r, v.Revision) {
 		return
 	}
-	status := strings.ToLower(strings.TrimSpace(req.Status))
-	if status != "active" && status != "deprecated" && status != "sunset" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+
+	var req updateVersionReq
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	sunsetTime, err := parseDatePtrYYYYMMDD(req.SunsetDate)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid sunset_date (YYYY-MM-DD)"})
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
 		return
 	}
+	sunsetTime, _ := parseDatePtrYYYYMMDD(req.SunsetDate)
 
-	updated, err := a.store.UpdateVersionStatus(r.Context(), versionID, status, sunsetTime)
+	updated, err := a.store.UpdateVersionStatus(r.Context(), versionID, req.Status, sunsetTime, v.Revision)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed: " + err.Error()})
+		writeUpdateError(w, r, err, "update failed")
 		return
 	}
+	a.store.emitEvent(r.Context(), claims.OrgID, versionUpdateEvent(v.Status, updated.Status), api.ID, updated.ID, versionEventData(api, updated))
+
+	enqueueAutoNotice(r.Context(), a.store, api, v, updated)
+	w.Header().Set("ETag", etag(updated.Revision))
 	writeJSON(w, http.StatusOK, updated)
 }
 
@@ -153,18 +276,22 @@ func (a *AuthService) DeleteVersionHandler(w http.ResponseWriter, r *http.Reques
 	// load version + api to enforce org scope
 	v, err := a.store.GetVersionByID(r.Context(), versionID)
 	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 	api, err := a.store.GetAPIByID(r.Context(), v.APIID)
 	if err != nil || api.OrgID != claims.OrgID {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeNotFound(w, r)
 		return
 	}
 
-	if err := a.store.DeleteVersion(r.Context(), versionID); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed: " + err.Error()})
+	if !checkIfMatch(w, r, v.Revision) {
+		return
+	}
+	if err := a.store.DeleteVersion(r.Context(), versionID, v.Revision); err != nil {
+		writeUpdateError(w, r, err, "delete failed")
 		return
 	}
+	a.store.emitEvent(r.Context(), claims.OrgID, "version.deleted", api.ID, v.ID, versionEventData(api, v))
 	w.WriteHeader(http.StatusNoContent)
 }
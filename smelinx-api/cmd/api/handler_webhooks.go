@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/* -------------------- request shapes -------------------- */
+
+type createWebhookReq struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"` // e.g. ["version.deprecated","version.*"]; empty = all
+}
+
+type updateWebhookReq struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+/* -------------------- helpers -------------------- */
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// validate normalizes the request and reports invalid fields.
+func (req *createWebhookReq) validate() (errs fieldErrors) {
+	req.URL = strings.TrimSpace(req.URL)
+	if !validWebhookURL(req.URL) {
+		errs.invalid("url", "must be an http(s) URL on a public host")
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	events, ok := normalizeEventFilter(req.Events)
+	if !ok {
+		errs.invalid("events", "unknown event type; known: %s", strings.Join(eventTypes, ", "))
+	}
+	req.Events = events
+	return errs
+}
+
+func (req *updateWebhookReq) validate() (errs fieldErrors) {
+	if req.URL != nil {
+		u := strings.TrimSpace(*req.URL)
+		req.URL = &u
+		if !validWebhookURL(u) {
+			errs.invalid("url", "must be an http(s) URL on a public host")
+		}
+	}
+	if req.Description != nil {
+		d := strings.TrimSpace(*req.Description)
+		req.Description = &d
+	}
+	if req.Events != nil {
+		events, ok := normalizeEventFilter(*req.Events)
+		if !ok {
+			errs.invalid("events", "unknown event type; known: %s", strings.Join(eventTypes, ", "))
+		}
+		req.Events = &events
+	}
+	return errs
+}
+
+// normalizeEventFilter validates subscribed event names ("*", exact types or "prefix.*").
+func normalizeEventFilter(in []string) ([]string, bool) {
+	var out []string
+	for _, e := range in {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		ok := e == "*"
+		for _, t := range eventTypes {
+			if e == t || (strings.HasSuffix(e, ".*") && strings.HasPrefix(t, strings.TrimSuffix(e, "*"))) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+		out = append(out, e)
+	}
+	return out, true
+}
+
+// loadOrgWebhook fetches an endpoint and enforces org scope; writes 404 otherwise.
+func (a *AuthService) loadOrgWebhook(w http.ResponseWriter, r *http.Request) (*WebhookEndpoint, bool) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	ep, err := a.store.GetWebhookEndpointByID(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil || ep.OrgID != claims.OrgID {
+		writeNotFound(w, r)
+		return nil, false
+	}
+	return ep, true
+}
+
+/* -------------------- handlers -------------------- */
+
+// GET /webhooks
+func (a *AuthService) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	list, err := a.store.ListWebhookEndpoints(r.Context(), claims.OrgID)
+	if err != nil {
+		writeInternal(w, r, err, "list webhooks failed")
+		return
+	}
+	// secrets are only shown once, on create
+	for i := range list {
+		list[i].Secret = ""
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /webhooks
+func (a *AuthService) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+
+	var req createWebhookReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		writeInternal(w, r, err, "secret generation failed")
+		return
+	}
+	ep, err := a.store.CreateWebhookEndpoint(r.Context(), claims.OrgID, req.URL, secret, req.Description, req.Events)
+	if err != nil {
+		writeInternal(w, r, err, "create webhook failed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, ep)
+}
+
+// GET /webhooks/{webhookID}
+func (a *AuthService) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ep, ok := a.loadOrgWebhook(w, r)
+	if !ok {
+		return
+	}
+	ep.Secret = ""
+	writeJSON(w, http.StatusOK, ep)
+}
+
+// PUT /webhooks/{webhookID}
+func (a *AuthService) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ep, ok := a.loadOrgWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateWebhookReq
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		writeValidation(w, r, errs)
+		return
+	}
+	url, desc, events, active := ep.URL, ep.Description, ep.Events, ep.Active
+	if req.URL != nil {
+		url = *req.URL
+	}
+	if req.Description != nil {
+		desc = *req.Description
+	}
+	if req.Events != nil {
+		events = *req.Events
+	}
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	updated, err := a.store.UpdateWebhookEndpoint(r.Context(), ep.ID, url, desc, events, active)
+	if err != nil {
+		writeInternal(w, r, err, "update failed")
+		return
+	}
+	updated.Secret = ""
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DELETE /webhooks/{webhookID}
+func (a *AuthService) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ep, ok := a.loadOrgWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.DeleteWebhookEndpoint(r.Context(), ep.ID); err != nil {
+		writeInternal(w, r, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /webhooks/{webhookID}/deliveries
+func (a *AuthService) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	ep, ok := a.loadOrgWebhook(w, r)
+	if !ok {
+		return
+	}
+	list, err := a.store.ListWebhookDeliveries(r.Context(), ep.ID, 100)
+	if err != nil {
+		writeInternal(w, r, err, "list deliveries failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /webhooks/{webhookID}/test — sends a signed "webhook.test" event right
+// away and returns the resulting delivery record. Failed tests are retried
+// like any other delivery.
+func (a *AuthService) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(ctxKeyUser{}).(jwtClaims)
+	ep, ok := a.loadOrgWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	ev, err := a.store.RecordTestEvent(r.Context(), claims.OrgID, map[string]any{
+		"message":     "This is a test event from Smelinx.",
+		"endpoint_id": ep.ID,
+	})
+	if err != nil {
+		writeInternal(w, r, err, "record test event failed")
+		return
+	}
+	// leased past the inline attempt's timeout so the dispatcher cannot send it too
+	const attempt = 15 * time.Second
+	deliveryID, err := a.store.QueueWebhookDelivery(r.Context(), ep.ID, ev, 4*attempt)
+	if err != nil {
+		writeInternal(w, r, err, "queue test delivery failed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), attempt)
+	defer cancel()
+	due, err := a.store.GetDueWebhookDelivery(ctx, deliveryID)
+	if err == nil {
+		deliverWebhook(ctx, a.store, *due, loadRetryPolicy())
+	}
+
+	d, err := a.store.GetWebhookDeliveryByID(r.Context(), deliveryID)
+	if err != nil {
+		writeInternal(w, r, err, "load delivery failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
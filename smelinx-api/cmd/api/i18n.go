@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Message catalogs for notices.
+
+Every built-in string of a notice (default templates, chat cards, the
+preferences footer) is looked up by key in the recipient's locale: the
+consumer's own, else the org default, else English. Missing keys fall back
+to English, then to the key itself. Templates reach the catalog with
+{{t "key"}}.
+
+The format.* keys are Go time layouts, so dates follow the locale too.
+*/
+
+const defaultLocale = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": {
+		"title.deprecate":       "Deprecation Notice",
+		"title.sunset":          "Sunset Notice",
+		"title.other":           "API Notice",
+		"subject.deprecate":     "Deprecation notice",
+		"subject.sunset":        "Sunset notice",
+		"subject.other":         "Notice",
+		"label.api":             "API",
+		"label.version":         "Version",
+		"label.scheduled_at":    "Scheduled at",
+		"label.sunset_date":     "Sunset date",
+		"label.base_url":        "Base URL",
+		"label.docs":            "Docs",
+		"action.read_docs":      "Read the docs",
+		"action.acknowledge":    "Acknowledge",
+		"ack.link":              "I acknowledge this notice / I have migrated",
+		"footer.questions":      "If you have questions, please reply to this email.",
+		"footer.subscribed":     "You receive this because you are subscribed to API lifecycle notices.",
+		"footer.manage":         "Manage preferences or unsubscribe",
+		"footer.sunset_always":  "Sunset notices are always sent.",
+		"digest.subject":        "[Smelinx] Weekly API lifecycle digest – %d notice(s)",
+		"digest.heading":        "Your weekly API lifecycle digest",
+		"digest.intro":          "Hi %s, here is what changed in the APIs you use this week.",
+		"format.date":           "2006-01-02",
+		"format.datetime":       time.RFC1123,
+		"format.datetime_short": "2006-01-02 15:04 MST",
+	},
+	"de": {
+		"title.deprecate":       "Abkündigungshinweis",
+		"title.sunset":          "Abschaltungshinweis",
+		"title.other":           "API-Hinweis",
+		"subject.deprecate":     "Abkündigungshinweis",
+		"subject.sunset":        "Abschaltungshinweis",
+		"subject.other":         "Hinweis",
+		"label.api":             "API",
+		"label.version":         "Version",
+		"label.scheduled_at":    "Geplant für",
+		"label.sunset_date":     "Abschaltdatum",
+		"label.base_url":        "Basis-URL",
+		"label.docs":            "Dokumentation",
+		"action.read_docs":      "Dokumentation lesen",
+		"action.acknowledge":    "Bestätigen",
+		"ack.link":              "Ich bestätige diesen Hinweis / Ich bin bereits migriert",
+		"footer.questions":      "Bei Fragen antworten Sie bitte auf diese E-Mail.",
+		"footer.subscribed":     "Sie erhalten diese Nachricht, weil Sie Hinweise zum API-Lebenszyklus abonniert haben.",
+		"footer.manage":         "Einstellungen verwalten oder abbestellen",
+		"footer.sunset_always":  "Abschaltungshinweise werden immer zugestellt.",
+		"digest.subject":        "[Smelinx] Wöchentliche Übersicht zum API-Lebenszyklus – %d Hinweis(e)",
+		"digest.heading":        "Ihre wöchentliche Übersicht zum API-Lebenszyklus",
+		"digest.intro":          "Hallo %s, das hat sich diese Woche an den von Ihnen genutzten APIs geändert.",
+		"format.date":           "02.01.2006",
+		"format.datetime":       "02.01.2006, 15:04 Uhr MST",
+		"format.datetime_short": "02.01.2006 15:04 MST",
+	},
+	"ja": {
+		"title.deprecate":       "非推奨のお知らせ",
+		"title.sunset":          "提供終了のお知らせ",
+		"title.other":           "APIのお知らせ",
+		"subject.deprecate":     "非推奨のお知らせ",
+		"subject.sunset":        "提供終了のお知らせ",
+		"subject.other":         "お知らせ",
+		"label.api":             "API",
+		"label.version":         "バージョン",
+		"label.scheduled_at":    "予定日時",
+		"label.sunset_date":     "提供終了日",
+		"label.base_url":        "ベースURL",
+		"label.docs":            "ドキュメント",
+		"action.read_docs":      "ドキュメントを見る",
+		"action.acknowledge":    "確認済みにする",
+		"ack.link":              "このお知らせを確認しました／移行済みです",
+		"footer.questions":      "ご不明な点がございましたら、このメールにご返信ください。",
+		"footer.subscribed":     "APIライフサイクル通知を購読しているため、このメールをお送りしています。",
+		"footer.manage":         "配信設定の変更・配信停止",
+		"footer.sunset_always":  "提供終了のお知らせは常に送信されます。",
+		"digest.subject":        "[Smelinx] APIライフサイクル週間ダイジェスト – お知らせ%d件",
+		"digest.heading":        "APIライフサイクル週間ダイジェスト",
+		"digest.intro":          "%s 様、今週ご利用中のAPIに以下の変更がありました。",
+		"format.date":           "2006年01月02日",
+		"format.datetime":       "2006年01月02日 15:04 MST",
+		"format.datetime_short": "2006年01月02日 15:04 MST",
+	},
+}
+
+// supportedLocales lists the catalog locales, sorted.
+func supportedLocales() []string {
+	out := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalizeLocale maps a language tag ("de", "de-AT", "ja_JP") onto a
+// catalog locale.
+func normalizeLocale(tag string) (string, error) {
+	l := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(l, "-_"); i >= 0 {
+		l = l[:i]
+	}
+	if _, ok := catalogs[l]; !ok {
+		return "", fmt.Errorf("unsupported locale %q (supported: %s)", tag, strings.Join(supportedLocales(), ", "))
+	}
+	return l, nil
+}
+
+// localeOr returns the first supported locale among tags, else English.
+func localeOr(tags ...string) string {
+	for _, t := range tags {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		if l, err := normalizeLocale(t); err == nil {
+			return l
+		}
+	}
+	return defaultLocale
+}
+
+// tr looks key up in locale, falling back to English and then the key.
+func tr(locale, key string) string {
+	if s, ok := catalogs[locale][key]; ok {
+		return s
+	}
+	if s, ok := catalogs[defaultLocale][key]; ok {
+		return s
+	}
+	return key
+}
+
+// formatDate and formatDateTime render t with the locale's layouts.
+func formatDate(locale string, t time.Time) string {
+	return t.Format(tr(locale, "format.date"))
+}
+
+func formatDateTime(locale string, t time.Time) string {
+	return t.Format(tr(locale, "format.datetime"))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// icsEvent is one all-day VEVENT.
+type icsEvent struct {
+	UID         string
+	Date        time.Time // all-day; only the date part is used
+	Summary     string
+	Description string
+	URL         string
+	Updated     time.Time
+}
+
+// buildICS renders an RFC 5545 calendar. method is "PUBLISH" for email
+// attachments and "" for subscribable feeds.
+func buildICS(calName, method string, events []icsEvent) []byte {
+	var b bytes.Buffer
+	line := func(s string) { b.WriteString(icsFold(s) + "\r\n") }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Smelinx//API lifecycle//EN")
+	line("CALSCALE:GREGORIAN")
+	if method != "" {
+		line("METHOD:" + method)
+	}
+	if calName != "" {
+		line("X-WR-CALNAME:" + icsEscape(calName))
+	}
+	now := time.Now().UTC().Format("20060102T150405Z")
+	for _, ev := range events {
+		stamp := now
+		if !ev.Updated.IsZero() {
+			stamp = ev.Updated.UTC().Format("20060102T150405Z")
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + ev.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + ev.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + ev.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + icsEscape(ev.Summary))
+		if ev.Description != "" {
+			line("DESCRIPTION:" + icsEscape(ev.Description))
+		}
+		if ev.URL != "" {
+			line("URL:" + ev.URL)
+		}
+		line("TRANSP:TRANSPARENT")
+		// remind a week ahead
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:" + icsEscape(ev.Summary))
+		line("TRIGGER:-P7D")
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// icsEscape escapes TEXT values (RFC 5545 §3.3.11).
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold folds content lines longer than 75 octets without splitting UTF-8 runes.
+func icsFold(s string) string {
+	if len(s) <= 75 {
+		return s
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
+
+// sunsetICSEvent describes the sunset date of one version.
+func sunsetICSEvent(versionID, apiName, version string, sunset time.Time, docsURL string) icsEvent {
+	return icsEvent{
+		UID:         fmt.Sprintf("sunset-%s@smelinx", versionID),
+		Date:        sunset,
+		Summary:     fmt.Sprintf("%s %s sunset", apiName, version),
+		Description: fmt.Sprintf("%s %s is retired on this date. Migrate before then.", apiName, version),
+		URL:         docsURL,
+	}
+}
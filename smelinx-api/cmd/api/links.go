@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"strings"
+)
+
+/*
+Signed links.
+
+Public endpoints reached from emails and calendar apps (calendar feeds,
+unsubscribe, acknowledgements, ...) authenticate with an opaque token instead
+of a session. A token is base64url(payload) + "." + base64url(HMAC-SHA256),
+where payload is "<purpose>:<subject>". The purpose stops a token minted for
+one endpoint being replayed against another.
+
+The key is LINK_SIGNING_KEY, falling back to JWT_SIGNING_KEY; rotating it
+revokes every outstanding link.
+*/
+
+func linkKey() []byte {
+	if k := os.Getenv("LINK_SIGNING_KEY"); k != "" {
+		return []byte(k)
+	}
+	return []byte(os.Getenv("JWT_SIGNING_KEY"))
+}
+
+func signLink(purpose, subject string) string {
+	payload := purpose + ":" + subject
+	mac := hmac.New(sha256.New, linkKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyLink checks token and returns its subject if it was minted for purpose.
+func verifyLink(purpose, token string) (string, bool) {
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return "", false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, linkKey())
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return "", false
+	}
+	subject, ok := strings.CutPrefix(string(payload), purpose+":")
+	return subject, ok
+}
+
+// publicBaseURL is the externally reachable base URL of this API, used to
+// build links in emails and feeds (PUBLIC_BASE_URL, e.g. https://api.smelinx.com).
+func publicBaseURL() string {
+	return strings.TrimRight(getenv("PUBLIC_BASE_URL", "http://localhost:"+getenv("PORT", "8080")), "/")
+}
+
+// preferencesURL is the self-service preferences page of a consumer.
+func preferencesURL(consumerID string) string {
+	return publicBaseURL() + "/preferences?token=" + url.QueryEscape(signLink("preferences", consumerID))
+}
+
+// unsubscribeURL is the one-click unsubscribe target of a consumer.
+func unsubscribeURL(consumerID string) string {
+	return publicBaseURL() + "/preferences/unsubscribe?token=" + url.QueryEscape(signLink("preferences", consumerID))
+}
+
+// ackURL is the page where a consumer acknowledges a deprecated version.
+func ackURL(consumerID, versionID string) string {
+	return publicBaseURL() + "/ack?token=" + url.QueryEscape(signLink("ack", consumerID+":"+versionID))
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockDataFile takes an exclusive advisory lock on path+".lock", held until
+// the returned file is closed (or the process exits). The server holds it
+// for the life of the process so restore can tell it is running.
+func lockDataFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errDataFileLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// newMailerFromEnv picks the outgoing mail backend.
+//
+// MAIL_BACKEND selects it explicitly (sendgrid | smtp | console). When unset,
+// SendGrid is used if SENDGRID_API_KEY/SENDGRID_FROM are set, then SMTP if
+// SMTP_HOST/SMTP_FROM are set, otherwise the console logger. A backend that
+// fails to initialise falls back to the console logger.
+func newMailerFromEnv() Mailer {
+	backend := strings.ToLower(strings.TrimSpace(getenv("MAIL_BACKEND", "")))
+	if backend == "" {
+		switch {
+		case getenv("SENDGRID_API_KEY", "") != "" && getenv("SENDGRID_FROM", "") != "":
+			backend = "sendgrid"
+		case getenv("SMTP_HOST", "") != "" && getenv("SMTP_FROM", "") != "":
+			backend = "smtp"
+		default:
+			backend = "console"
+		}
+	}
+
+	switch backend {
+	case "sendgrid":
+		m, err := NewSendGridMailer()
+		if err != nil {
+			log.Printf("[mailer] sendgrid init failed: %v; falling back to console", err)
+			return consoleMailer{}
+		}
+		log.Printf("[mailer] using SendGrid sender")
+		return m
+	case "smtp":
+		m, err := NewSMTPMailer()
+		if err != nil {
+			log.Printf("[mailer] smtp init failed: %v; falling back to console", err)
+			return consoleMailer{}
+		}
+		log.Printf("[mailer] using SMTP sender (%s:%s, tls=%s)", m.host, m.port, m.tlsMode)
+		return m
+	case "console":
+		log.Printf("[mailer] no mail backend configured; using console sender")
+		return consoleMailer{}
+	default:
+		log.Printf("[mailer] unknown MAIL_BACKEND %q; using console sender", backend)
+		return consoleMailer{}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,34 +17,37 @@ import (
 
 func main() {
 	loadConfig()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
 	db := mustOpenDB()
 	store := NewStore(db)
-	auth := NewAuthService(store)
 
 	// --- Mailer wiring ---
-	var mailer Mailer
-	sgKey := getenv("SENDGRID_API_KEY", "")
-	sgFrom := getenv("SENDGRID_FROM", "")
-	if sgKey != "" && sgFrom != "" {
-		m, err := NewSendGridMailer()
-		if err != nil {
-			log.Printf("[mailer] sendgrid init failed: %v; falling back to console", err)
-			mailer = consoleMailer{}
-		} else {
-			log.Printf("[mailer] using SendGrid sender")
-			mailer = m
-		}
-	} else {
-		log.Printf("[mailer] SENDGRID_API_KEY/SENDGRID_FROM not set; using console sender")
-		mailer = consoleMailer{}
-	}
+	mailer := newMailerFromEnv()
+	auth := NewAuthService(store, mailer)
+
+	// Start background dispatcher (email + chat channels)
+	startNotificationDispatcher(store, newChannels(store, mailer))
+	startWebhookDispatcher(store)
+	startConsumerDigestWorker(store, mailer)
+	startMemberDigestWorker(store, mailer)
+	startBackupWorker(store)
 
-	// Start background dispatcher
-	startNotificationDispatcher(store, mailer)
+	r := newRouter(auth)
 
-	// --- HTTP router ---
+	addr := ":" + getenv("PORT", "8080")
+	log.Printf("API listening on %s", addr)
+	log.Fatal(http.ListenAndServe(addr, r))
+}
+
+// newRouter wires every route. The OpenAPI document (openapi.go) must list
+// each of them; `openapi check` compares the two.
+func newRouter(auth *AuthService) *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.RequestID, middleware.RealIP, middleware.Logger, middleware.Recoverer)
+	r.Use(middleware.RequestID, requestIDHeader, middleware.RealIP, middleware.Logger, recoverer)
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler)
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(middleware.StripSlashes)
 
@@ -57,8 +61,8 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Set-Cookie"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"Set-Cookie", "X-Request-ID", "ETag", "Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -70,6 +74,9 @@ func main() {
 	})
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
 
+	// Machine-readable description of this API
+	r.Get("/openapi.json", OpenAPIHandler)
+
 	// Public auth
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/signup", auth.SignupHandler)
@@ -78,11 +85,51 @@ func main() {
 		r.Post("/logout", auth.LogoutHandler)
 	})
 
+	// Public calendar feed (signed token)
+	r.Get("/calendar.ics", auth.CalendarFeedHandler)
+
+	// Public consumer preferences / unsubscribe (signed token)
+	r.Get("/preferences", auth.PreferencesPageHandler)
+	r.Post("/preferences", auth.SavePreferencesHandler)
+	r.Get("/preferences/unsubscribe", auth.UnsubscribeLandingHandler)
+	r.Post("/preferences/unsubscribe", auth.OneClickUnsubscribeHandler)
+
+	// Public consumer acknowledgements (signed token)
+	r.Get("/ack", auth.AckPageHandler)
+	r.Post("/ack", auth.AckHandler)
+
+	// Public mail provider event webhook (ECDSA-signed)
+	r.Post("/inbound/sendgrid", auth.EmailEventsHandler)
+
+	// Public changelog feeds (opt-in)
+	r.Get("/feeds/apis/{id}.{format}", auth.APIFeedHandler)
+	r.Get("/feeds/orgs/{orgID}.{format}", auth.OrgFeedHandler)
+
 	// Protected
 	r.Group(func(r chi.Router) {
 		r.Use(auth.AuthMiddleware)
 
 		r.Get("/me", auth.MeHandler)
+		r.Get("/org", auth.GetOrgHandler)
+		r.Put("/org", auth.UpdateOrgHandler)
+
+		// Declarative sync from a smelinx.yaml manifest
+		r.Post("/sync/plan", auth.PlanSyncHandler)
+		r.Post("/sync/apply", auth.ApplySyncHandler)
+
+		// Portable org export / import
+		r.Get("/orgs/{orgID}/export", auth.ExportOrgHandler)
+		r.Post("/orgs/{orgID}/import", auth.ImportOrgHandler)
+
+		// Personal access tokens (Authorization: Bearer smx_pat_...)
+		r.Get("/me/tokens", auth.ListTokensHandler)
+		r.Post("/me/tokens", auth.CreateTokenHandler)
+		r.Delete("/me/tokens/{tokenID}", auth.RevokeTokenHandler)
+
+		r.Get("/me/digest", auth.GetDigestSettingsHandler)
+		r.Put("/me/digest", auth.UpdateDigestSettingsHandler)
+		r.Get("/me/digest/preview", auth.PreviewDigestHandler)
+		r.Post("/me/digest/send", auth.SendDigestHandler)
 
 		// APIs collection
 		r.Get("/apis", auth.ListAPIsHandler)
@@ -101,19 +148,94 @@ func main() {
 			// Notifications (nested under API)
 			r.Get("/notifications", auth.ListNotificationsHandler)
 			r.Post("/notifications", auth.CreateNotificationHandler)
+			r.Post("/notifications/preview", auth.PreviewNotificationHandler)
+
+			// Consumer subscriptions + chat channels (nested under API)
+			r.Get("/consumers", auth.ListAPIConsumersHandler)
+			r.Put("/consumers/{consumerID}", auth.SubscribeConsumerHandler)
+			r.Delete("/consumers/{consumerID}", auth.UnsubscribeConsumerHandler)
+			r.Get("/channels", auth.ListAPIChannelsHandler)
+			r.Post("/channels", auth.CreateAPIChannelHandler)
+
+			// Automatic notices on version changes (opt-in)
+			r.Get("/auto-notify", auth.GetAutoNotifyHandler)
+			r.Put("/auto-notify", auth.SetAutoNotifyHandler)
+
+			// Public changelog feed settings
+			r.Get("/feed", auth.GetAPIFeedHandler)
+			r.Put("/feed", auth.SetAPIFeedHandler)
 		})
 
 		// Version item
+		r.Get("/versions/{versionID}", auth.GetVersionHandler)
 		r.Put("/versions/{versionID}", auth.UpdateVersionHandler)
 		r.Delete("/versions/{versionID}", auth.DeleteVersionHandler)
+		r.Get("/versions/{versionID}/acknowledgements", auth.VersionAckReportHandler)
+		r.Put("/versions/{versionID}/acknowledgements/{consumerID}", auth.SetAckHandler)
+		r.Delete("/versions/{versionID}/acknowledgements/{consumerID}", auth.DeleteAckHandler)
 
 		// Notification item
+		r.Get("/notifications/{noteID}", auth.GetNotificationHandler)
 		r.Put("/notifications/{noteID}", auth.UpdateNotificationHandler)
+		r.Post("/notifications/{noteID}/test-send", auth.TestSendNotificationHandler)
+		r.Get("/notifications/{noteID}/deliveries", auth.ListNotificationDeliveriesHandler)
+
+		// Suppressed email addresses (hard bounces, spam complaints)
+		r.Get("/suppressions", auth.ListSuppressionsHandler)
+		r.Delete("/suppressions/{email}", auth.DeleteSuppressionHandler)
+
+		// Consumers
+		r.Get("/consumers", auth.ListConsumersHandler)
+		r.Post("/consumers", auth.CreateConsumerHandler)
+		r.Route("/consumers/{consumerID}", func(r chi.Router) {
+			r.Get("/", auth.GetConsumerHandler)
+			r.Put("/", auth.UpdateConsumerHandler)
+			r.Delete("/", auth.DeleteConsumerHandler)
+			r.Get("/channels", auth.ListConsumerChannelsHandler)
+			r.Post("/channels", auth.CreateConsumerChannelHandler)
+			r.Get("/calendar", auth.ConsumerCalendarLinkHandler)
+			r.Get("/preferences", auth.GetConsumerPreferencesHandler)
+			r.Put("/preferences", auth.UpdateConsumerPreferencesHandler)
+		})
+
+		// Calendar subscription link for the org
+		r.Get("/calendar", auth.OrgCalendarLinkHandler)
+
+		// Org-wide changelog feed settings
+		r.Get("/feed", auth.GetOrgFeedHandler)
+		r.Put("/feed", auth.SetOrgFeedHandler)
+
+		// Channel item
+		r.Delete("/channels/{channelID}", auth.DeleteChannelHandler)
+
+		// Outbound webhooks
+		r.Get("/webhooks", auth.ListWebhooksHandler)
+		r.Post("/webhooks", auth.CreateWebhookHandler)
+		r.Route("/webhooks/{webhookID}", func(r chi.Router) {
+			r.Get("/", auth.GetWebhookHandler)
+			r.Put("/", auth.UpdateWebhookHandler)
+			r.Delete("/", auth.DeleteWebhookHandler)
+			r.Get("/deliveries", auth.ListWebhookDeliveriesHandler)
+			r.Post("/test", auth.TestWebhookHandler)
+		})
+
+		// Notification templates
+		r.Get("/templates", auth.ListTemplatesHandler)
+		r.Post("/templates/preview", auth.PreviewTemplateHandler)
+		r.Route("/templates/{type}", func(r chi.Router) {
+			r.Get("/", auth.GetTemplateHandler)
+			r.Put("/", auth.SaveTemplateHandler)
+			r.Delete("/", auth.DeleteTemplateHandler)
+			r.Get("/versions", auth.ListTemplateVersionsHandler)
+			r.Post("/versions/{version}/restore", auth.RestoreTemplateVersionHandler)
+		})
+
+		// Instance administration (ADMIN_EMAILS)
+		r.Get("/admin/backups", auth.ListBackupsHandler)
+		r.Post("/admin/backups", auth.CreateBackupHandler)
 	})
 
-	addr := ":" + getenv("PORT", "8080")
-	log.Printf("API listening on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, r))
+	return r
 }
 
 // ---------- middlewares ----------
@@ -162,7 +284,7 @@ func rateLimit(max int, per time.Duration) func(http.Handler) http.Handler {
 			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(resetSecs))
 
 			if b.count > max {
-				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				writeError(w, r, http.StatusTooManyRequests, codeRateLimited, "rate limit exceeded")
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Schema migrations.
+
+Each migration has a number, an up and a down step. Applied migrations are
+recorded in schema_migrations; each step runs in one transaction together
+with its bookkeeping, so a failed step leaves no trace.
+
+Before a step starts, its row is marked dirty; the mark is cleared when the
+step commits or rolls back. A dirty row therefore means a run was cut off
+half-way (crash, kill, lost connection). The server refuses to start on a
+dirty schema until someone has checked it and run `migrate force`.
+*/
+
+type migration struct {
+	Version int
+	Name    string
+	Up      func(m *schemaTx) error
+	Down    func(m *schemaTx) error
+}
+
+// schemaTx is the transaction a migration step runs in.
+type schemaTx struct {
+	ctx context.Context
+	tx  *txConn
+}
+
+// exec runs DDL written for SQLite, adapted to the dialect.
+func (m *schemaTx) exec(stmts ...string) error {
+	for _, s := range stmts {
+		if _, err := m.tx.ExecContext(m.ctx, m.tx.ddl(s)); err != nil {
+			return fmt.Errorf("%w\n%s", err, strings.TrimSpace(s))
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column unless it already exists.
+func (m *schemaTx) addColumn(table, col, decl string) error {
+	if err := m.tx.addColumn(m.ctx, m.tx, table, col, decl); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, col, err)
+	}
+	return nil
+}
+
+func (m *schemaTx) sqlite() bool {
+	_, ok := m.tx.dialect.(sqliteDialect)
+	return ok
+}
+
+// rebuildTable recreates a SQLite table with a new definition, which is how
+// SQLite changes CHECK constraints, column types or drops constrained
+// columns: create <table>_new from columns (the part inside CREATE TABLE's
+// parentheses), copy the columns both versions share, swap, then recreate
+// the table's indexes followed by any extra index statements. Drop indexes
+// on columns that go away before rebuilding. PostgreSQL migrations ALTER
+// the table in place.
+func (m *schemaTx) rebuildTable(table, columns string, indexes ...string) error {
+	if !m.sqlite() {
+		return errors.New("rebuildTable is SQLite-only; ALTER the table on PostgreSQL")
+	}
+	existing, err := m.sqliteIndexes(table)
+	if err != nil {
+		return err
+	}
+	tmp := table + "_new"
+	if err := m.exec(`CREATE TABLE ` + tmp + ` (` + columns + `)`); err != nil {
+		return err
+	}
+	oldCols, err := m.sqliteColumns(table)
+	if err != nil {
+		return err
+	}
+	newCols, err := m.sqliteColumns(tmp)
+	if err != nil {
+		return err
+	}
+	var shared []string
+	for _, c := range newCols {
+		for _, o := range oldCols {
+			if c == o {
+				shared = append(shared, c)
+			}
+		}
+	}
+	list := strings.Join(shared, ", ")
+	stmts := []string{
+		`INSERT INTO ` + tmp + ` (` + list + `) SELECT ` + list + ` FROM ` + table,
+		`DROP TABLE ` + table,
+		`ALTER TABLE ` + tmp + ` RENAME TO ` + table,
+	}
+	stmts = append(stmts, existing...)
+	return m.exec(append(stmts, indexes...)...)
+}
+
+// sqliteIndexes returns the CREATE INDEX statements of a table's explicit
+// indexes (not those backing PRIMARY KEY / UNIQUE constraints).
+func (m *schemaTx) sqliteIndexes(table string) ([]string, error) {
+	rows, err := m.tx.QueryContext(m.ctx, `
+		SELECT sql FROM sqlite_master
+		WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL
+		ORDER BY name`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		out = append(out, stmt)
+	}
+	return out, rows.Err()
+}
+
+func (m *schemaTx) sqliteColumns(table string) ([]string, error) {
+	rows, err := m.tx.QueryContext(m.ctx, `SELECT name FROM pragma_table_info('`+table+`') ORDER BY cid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+/* -------------------- state -------------------- */
+
+// migrationState is one known or recorded migration.
+type migrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Dirty     bool       `json:"dirty,omitempty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func ensureMigrationsTable(ctx context.Context, db *dbConn) error {
+	_, err := db.ExecContext(ctx, db.ddl(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     INTEGER PRIMARY KEY,
+			name        TEXT NOT NULL,
+			dirty       INTEGER NOT NULL DEFAULT 0,
+			applied_at  TIMESTAMP NOT NULL DEFAULT (datetime('now'))
+		)`))
+	return err
+}
+
+// migrationStates lists every known migration plus any recorded one this
+// build does not know (a newer schema), ordered by version.
+func migrationStates(ctx context.Context, db *dbConn) ([]migrationState, error) {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, name, dirty, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byVersion := map[int]*migrationState{}
+	for _, m := range migrations {
+		byVersion[m.Version] = &migrationState{Version: m.Version, Name: m.Name}
+	}
+	for rows.Next() {
+		var st migrationState
+		var at sql.NullTime
+		if err := rows.Scan(&st.Version, &st.Name, &st.Dirty, &at); err != nil {
+			return nil, err
+		}
+		st.Applied = !st.Dirty
+		if at.Valid {
+			st.AppliedAt = &at.Time
+		}
+		byVersion[st.Version] = &st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]migrationState, 0, len(byVersion))
+	for _, st := range byVersion {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// latestMigration is the schema version this build expects.
+func latestMigration() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// checkSchema fails on a dirty schema or one newer than this build, and
+// returns the migrations still pending.
+func checkSchema(ctx context.Context, db *dbConn) ([]migration, error) {
+	states, err := migrationStates(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]bool{}
+	for _, st := range states {
+		if st.Dirty {
+			return nil, fmt.Errorf("schema is dirty: migration %d (%s) was interrupted; check the database, then run `migrate force %d` if it was fully applied or `migrate force %d` if not",
+				st.Version, st.Name, st.Version, st.Version-1)
+		}
+		if st.Applied && st.Version > latestMigration() {
+			return nil, fmt.Errorf("schema is at migration %d (%s), newer than this build (%d)", st.Version, st.Name, latestMigration())
+		}
+		applied[st.Version] = st.Applied
+	}
+	var pending []migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+/* -------------------- apply -------------------- */
+
+// migrateUp applies pending migrations up to target (0 = all) and returns
+// how many ran.
+func migrateUp(ctx context.Context, db *dbConn, target int) (int, error) {
+	pending, err := checkSchema(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range pending {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if err := runMigration(ctx, db, m, true); err != nil {
+			return n, err
+		}
+		log.Printf("[migrate] applied %d_%s", m.Version, m.Name)
+		n++
+	}
+	return n, nil
+}
+
+// migrateDown reverts the newest steps applied migrations.
+func migrateDown(ctx context.Context, db *dbConn, steps int) (int, error) {
+	if _, err := checkSchema(ctx, db); err != nil {
+		return 0, err
+	}
+	states, err := migrationStates(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := len(states) - 1; i >= 0 && n < steps; i-- {
+		if !states[i].Applied {
+			continue
+		}
+		m, ok := findMigration(states[i].Version)
+		if !ok {
+			return n, fmt.Errorf("migration %d is not part of this build", states[i].Version)
+		}
+		if err := runMigration(ctx, db, m, false); err != nil {
+			return n, err
+		}
+		log.Printf("[migrate] reverted %d_%s", m.Version, m.Name)
+		n++
+	}
+	return n, nil
+}
+
+func findMigration(version int) (migration, bool) {
+	for _, m := range migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// runMigration runs one step in a transaction, with the dirty mark around it.
+func runMigration(ctx context.Context, db *dbConn, m migration, up bool) error {
+	step, verb := m.Up, "up"
+	if !up {
+		step, verb = m.Down, "down"
+	}
+	if err := markDirty(ctx, db, m, up); err != nil {
+		return err
+	}
+
+	err := func() error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := step(&schemaTx{ctx: ctx, tx: tx}); err != nil {
+			return err
+		}
+		if up {
+			_, err = tx.ExecContext(ctx, `UPDATE schema_migrations SET dirty = 0, applied_at = ? WHERE version = ?`,
+				time.Now().UTC().Format(time.RFC3339), m.Version)
+		} else {
+			_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		// the transaction rolled back, so the schema is as it was before
+		if cerr := clearDirty(ctx, db, m, up); cerr != nil {
+			log.Printf("[migrate] could not clear dirty mark on %d: %v", m.Version, cerr)
+		}
+		return fmt.Errorf("migration %d_%s %s: %w", m.Version, m.Name, verb, err)
+	}
+	return nil
+}
+
+func markDirty(ctx context.Context, db *dbConn, m migration, up bool) error {
+	var err error
+	if up {
+		_, err = db.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, 1)`, m.Version, m.Name)
+	} else {
+		_, err = db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = 1 WHERE version = ?`, m.Version)
+	}
+	return err
+}
+
+func clearDirty(ctx context.Context, db *dbConn, m migration, up bool) error {
+	var err error
+	if up {
+		_, err = db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ? AND dirty = 1`, m.Version)
+	} else {
+		_, err = db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = 0 WHERE version = ?`, m.Version)
+	}
+	return err
+}
+
+// forceVersion records the schema as being exactly at version without
+// running anything; it is how an operator clears a dirty mark.
+func forceVersion(ctx context.Context, db *dbConn, version int) error {
+	if version != 0 {
+		if _, ok := findMigration(version); !ok {
+			return fmt.Errorf("unknown migration %d", version)
+		}
+	}
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version > ?`, version); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, 0)
+			ON CONFLICT (version) DO UPDATE SET dirty = 0`, m.Version, m.Name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
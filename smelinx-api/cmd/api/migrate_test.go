@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// runSchemaStep runs step in a transaction like runMigration does, and
+// commits it when it succeeds.
+func runSchemaStep(t *testing.T, db *dbConn, step func(m *schemaTx) error) error {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := step(&schemaTx{ctx: ctx, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func TestRebuildTableChangesCheck(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	if err := runSchemaStep(t, db, func(m *schemaTx) error {
+		return m.exec(
+			`CREATE TABLE jobs (
+				id     TEXT PRIMARY KEY,
+				status TEXT NOT NULL CHECK (status IN ('pending','done')),
+				note   TEXT
+			)`,
+			`CREATE INDEX idx_jobs_status ON jobs(status)`,
+			`INSERT INTO jobs (id, status, note) VALUES ('1','pending','a'), ('2','done','b')`,
+		)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO jobs (id, status) VALUES ('3','failed')`); err == nil {
+		t.Fatal("old CHECK accepted 'failed'")
+	}
+
+	// allow 'failed', drop note, add attempts and an index on it
+	err := runSchemaStep(t, db, func(m *schemaTx) error {
+		return m.rebuildTable("jobs", `
+			id       TEXT PRIMARY KEY,
+			status   TEXT NOT NULL CHECK (status IN ('pending','done','failed')),
+			attempts INTEGER NOT NULL DEFAULT 0`,
+			`CREATE INDEX idx_jobs_attempts ON jobs(attempts)`)
+	})
+	if err != nil {
+		t.Fatalf("rebuildTable: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO jobs (id, status) VALUES ('3','failed')`); err != nil {
+		t.Errorf("new CHECK rejects 'failed': %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO jobs (id, status) VALUES ('4','lost')`); err == nil {
+		t.Error("new CHECK accepts 'lost'")
+	}
+
+	var rows []string
+	r, err := db.QueryContext(ctx, `SELECT id || ':' || status || ':' || attempts FROM jobs ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for r.Next() {
+		var s string
+		r.Scan(&s)
+		rows = append(rows, s)
+	}
+	if got, want := strings.Join(rows, " "), "1:pending:0 2:done:0 3:failed:0"; got != want {
+		t.Errorf("rows after rebuild = %s, want %s", got, want)
+	}
+
+	var indexes []string
+	ir, err := db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'jobs' AND sql IS NOT NULL ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ir.Close()
+	for ir.Next() {
+		var n string
+		ir.Scan(&n)
+		indexes = append(indexes, n)
+	}
+	if got, want := strings.Join(indexes, ","), "idx_jobs_attempts,idx_jobs_status"; got != want {
+		t.Errorf("indexes after rebuild = %s, want %s", got, want)
+	}
+
+	var leftover int
+	db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'jobs_new'`).Scan(&leftover)
+	if leftover != 0 {
+		t.Error("jobs_new left behind")
+	}
+}
+
+func TestRebuildTableRollsBack(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	if err := runSchemaStep(t, db, func(m *schemaTx) error {
+		return m.exec(
+			`CREATE TABLE jobs (id TEXT PRIMARY KEY, status TEXT NOT NULL)`,
+			`INSERT INTO jobs (id, status) VALUES ('1','archived')`,
+		)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// an existing row violates the new CHECK: the whole step must undo
+	err := runSchemaStep(t, db, func(m *schemaTx) error {
+		return m.rebuildTable("jobs", `id TEXT PRIMARY KEY, status TEXT NOT NULL CHECK (status IN ('pending','done'))`)
+	})
+	if err == nil {
+		t.Fatal("rebuild with a violating row succeeded")
+	}
+	var status string
+	if err := db.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = '1'`).Scan(&status); err != nil || status != "archived" {
+		t.Errorf("after failed rebuild: status = %q, %v; want the table untouched", status, err)
+	}
+}
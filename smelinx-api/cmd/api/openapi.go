@@ -0,0 +1,592 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+OpenAPI description of this API, served at GET /openapi.json.
+
+Operations are listed by hand in openAPIOps; request and response schemas
+are derived from the Go types the handlers decode and encode, so a field
+added to createVersionReq or APIVersion shows up without touching this file.
+Handlers that answer with a map get a doc-only struct below describing it.
+
+`smelinx openapi check` (run in CI) walks the chi router and fails when a
+route is missing here or an operation here no longer has a route.
+*/
+
+const openAPIVersion = "3.1.0"
+
+// apiOp is one documented operation. Body and Resp are zero values of the
+// Go type that is decoded / encoded (nil = none), or a rawContent for
+// non-JSON payloads. Path parameters are taken from the {braces} in Path.
+type apiOp struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Public  bool     // no session or token required
+	Query   []string // "name: description"
+	Body    any
+	Status  int // success status; default 200
+	Resp    any
+	Errors  []int // error statuses beyond 400/401/404/429
+	// Conditional: GETs answer ETag / 304 on If-None-Match; PUTs and
+	// DELETEs require If-Match (412 when stale, 428 when missing).
+	Conditional bool
+}
+
+// rawContent is a non-JSON request or response body of the given media types.
+type rawContent []string
+
+/* -------------------- doc-only response shapes -------------------- */
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+type sentResponse struct {
+	Status string `json:"status"`
+	To     string `json:"to"`
+}
+
+type urlResponse struct {
+	URL string `json:"url"`
+}
+
+type enabledResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+type feedSettingsResponse struct {
+	Enabled bool   `json:"enabled"`
+	AtomURL string `json:"atom_url"`
+	RSSURL  string `json:"rss_url"`
+}
+
+type infoResponse struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type signupResponse struct {
+	Message string `json:"message"`
+	UserID  string `json:"user_id"`
+	OrgID   string `json:"org_id"`
+	Role    string `json:"role"`
+}
+
+type meResponse struct {
+	UserID string `json:"user_id"`
+	OrgID  string `json:"org_id"`
+	Role   string `json:"role"`
+}
+
+type orgResponse struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Timezone         string   `json:"timezone"`
+	Locale           string   `json:"locale"`
+	SupportedLocales []string `json:"supported_locales"`
+}
+
+type templateListResponse struct {
+	Templates []templateView    `json:"templates"`
+	Variables map[string]string `json:"variables"`
+	Locales   []string          `json:"locales"`
+}
+
+type consumerPreferencesResponse struct {
+	Preferences    preferencesView `json:"preferences"`
+	PreferencesURL string          `json:"preferences_url"`
+}
+
+type ackPageResponse struct {
+	ConsumerName    string           `json:"consumer_name"`
+	APIName         string           `json:"api_name"`
+	Version         APIVersion       `json:"version"`
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+}
+
+type ackReportResponse struct {
+	API struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"api"`
+	Version   APIVersion     `json:"version"`
+	Total     int            `json:"total"`
+	Counts    map[string]int `json:"counts"`
+	Consumers []ackReportRow `json:"consumers"`
+}
+
+type syncResponse struct {
+	Changes []syncChange `json:"changes"`
+	Diff    string       `json:"diff"`
+	Applied bool         `json:"applied"`
+}
+
+type apiPage struct {
+	Items      []API  `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type versionPage struct {
+	Items      []APIVersion `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type notificationPage struct {
+	Items      []APINotification `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+/* -------------------- operations -------------------- */
+
+var (
+	qToken  = "token: signed link token"
+	qLocale = "locale: template locale (empty = locale-neutral)"
+	qPrune  = "prune: true to delete APIs and versions the manifest no longer lists"
+	yamlDoc = rawContent{"application/yaml", "application/json"}
+	qPage   = []string{"limit: page size, 1-200 (default 50)", "cursor: next_cursor of the previous page"}
+)
+
+// pageParams is extra followed by the paging parameters.
+func pageParams(extra ...string) []string { return append(extra, qPage...) }
+
+var openAPIOps = []apiOp{
+	// meta
+	{Method: "GET", Path: "/", Tag: "meta", Summary: "Service name and status", Public: true, Resp: infoResponse{}},
+	{Method: "GET", Path: "/health", Tag: "meta", Summary: "Liveness probe", Public: true},
+	{Method: "GET", Path: "/openapi.json", Tag: "meta", Summary: "This document", Public: true, Resp: rawContent{"application/json"}},
+
+	// auth
+	{Method: "POST", Path: "/auth/signup", Tag: "auth", Summary: "Create a user and their org", Public: true, Body: signupReq{}, Status: 201, Resp: signupResponse{}, Errors: []int{http.StatusConflict}},
+	{Method: "POST", Path: "/auth/login", Tag: "auth", Summary: "Sign in; sets session cookies", Public: true, Body: loginReq{}, Resp: statusResponse{}},
+	{Method: "POST", Path: "/auth/refresh", Tag: "auth", Summary: "Renew the session from the refresh cookie", Public: true, Resp: statusResponse{}},
+	{Method: "POST", Path: "/auth/logout", Tag: "auth", Summary: "Clear the session cookies", Public: true, Resp: statusResponse{}},
+	{Method: "GET", Path: "/me", Tag: "auth", Summary: "Who the caller is", Resp: meResponse{}},
+	{Method: "GET", Path: "/me/tokens", Tag: "auth", Summary: "List the caller's personal access tokens", Resp: []APIToken{}},
+	{Method: "POST", Path: "/me/tokens", Tag: "auth", Summary: "Create a personal access token (cookie session only); the secret is only returned here", Body: createTokenReq{}, Status: 201, Resp: APIToken{}},
+	{Method: "DELETE", Path: "/me/tokens/{tokenID}", Tag: "auth", Summary: "Revoke a personal access token", Status: 204},
+
+	// org
+	{Method: "GET", Path: "/org", Tag: "org", Summary: "The caller's org and its settings", Resp: orgResponse{}},
+	{Method: "PUT", Path: "/org", Tag: "org", Summary: "Change the org time zone or locale", Body: updateOrgReq{}, Resp: orgResponse{}},
+	{Method: "GET", Path: "/me/digest", Tag: "org", Summary: "The caller's digest settings", Resp: MemberDigestSettings{}},
+	{Method: "PUT", Path: "/me/digest", Tag: "org", Summary: "Change the caller's digest settings", Body: digestSettingsReq{}, Resp: MemberDigestSettings{}},
+	{Method: "GET", Path: "/me/digest/preview", Tag: "org", Summary: "The digest the caller would receive now", Resp: renderedNotice{}},
+	{Method: "POST", Path: "/me/digest/send", Tag: "org", Summary: "Email the current digest to the caller", Resp: sentResponse{}},
+	{Method: "GET", Path: "/calendar", Tag: "org", Summary: "Calendar subscription URL for the org", Resp: urlResponse{}},
+	{Method: "GET", Path: "/feed", Tag: "org", Summary: "Org-wide changelog feed settings", Resp: feedSettingsResponse{}},
+	{Method: "PUT", Path: "/feed", Tag: "org", Summary: "Publish or hide the org-wide changelog feed", Body: enabledSettingReq{}, Resp: feedSettingsResponse{}},
+	{Method: "GET", Path: "/orgs/{orgID}/export", Tag: "org", Summary: "Export the org as a portable bundle", Query: []string{"format: json (default) or yaml"}, Resp: OrgBundle{}},
+	{Method: "POST", Path: "/orgs/{orgID}/import", Tag: "org", Summary: "Import a bundle under new IDs", Query: []string{"dry_run: true to report without writing", "on_conflict: fail (default) or skip"}, Body: OrgBundle{}, Resp: importReport{}, Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+	{Method: "POST", Path: "/sync/plan", Tag: "org", Summary: "Changes needed to match a smelinx.yaml manifest", Query: []string{qPrune}, Body: yamlDoc, Resp: syncResponse{}, Errors: []int{http.StatusUnprocessableEntity}},
+	{Method: "POST", Path: "/sync/apply", Tag: "org", Summary: "Apply a smelinx.yaml manifest", Query: []string{qPrune}, Body: yamlDoc, Resp: syncResponse{}, Errors: []int{http.StatusUnprocessableEntity}},
+
+	// apis
+	{Method: "GET", Path: "/apis", Tag: "apis", Summary: "List APIs", Query: pageParams("q: name contains (case-insensitive)", "owner_team: exact owner team", "sort: -created_at (default), created_at, name or -name"), Resp: apiPage{}, Conditional: true},
+	{Method: "POST", Path: "/apis", Tag: "apis", Summary: "Register an API", Body: createAPIReq{}, Status: 201, Resp: API{}},
+	{Method: "GET", Path: "/apis/{id}", Tag: "apis", Summary: "Get an API", Resp: API{}, Conditional: true},
+	{Method: "PUT", Path: "/apis/{id}", Tag: "apis", Summary: "Update an API", Body: updateAPIReq{}, Resp: API{}, Conditional: true},
+	{Method: "DELETE", Path: "/apis/{id}", Tag: "apis", Summary: "Delete an API with its versions and notifications", Resp: statusResponse{}, Conditional: true},
+	{Method: "GET", Path: "/apis/{id}/auto-notify", Tag: "apis", Summary: "Whether version changes enqueue notices", Resp: enabledResponse{}},
+	{Method: "PUT", Path: "/apis/{id}/auto-notify", Tag: "apis", Summary: "Turn automatic notices on or off", Body: enabledSettingReq{}, Resp: enabledResponse{}},
+	{Method: "GET", Path: "/apis/{id}/feed", Tag: "apis", Summary: "Changelog feed settings of an API", Resp: feedSettingsResponse{}},
+	{Method: "PUT", Path: "/apis/{id}/feed", Tag: "apis", Summary: "Publish or hide the changelog feed of an API", Body: enabledSettingReq{}, Resp: feedSettingsResponse{}},
+
+	// versions
+	{Method: "GET", Path: "/apis/{id}/versions", Tag: "versions", Summary: "List versions of an API", Query: pageParams("status: comma-separated active, deprecated, sunset", "sort: -created_at (default), created_at, version or -version"), Resp: versionPage{}, Conditional: true},
+	{Method: "POST", Path: "/apis/{id}/versions", Tag: "versions", Summary: "Add a version", Body: createVersionReq{}, Status: 201, Resp: APIVersion{}},
+	{Method: "GET", Path: "/versions/{versionID}", Tag: "versions", Summary: "Get a version", Resp: APIVersion{}, Conditional: true},
+	{Method: "PUT", Path: "/versions/{versionID}", Tag: "versions", Summary: "Change status or sunset date", Body: updateVersionReq{}, Resp: APIVersion{}, Conditional: true},
+	{Method: "DELETE", Path: "/versions/{versionID}", Tag: "versions", Summary: "Delete a version", Status: 204, Conditional: true},
+	{Method: "GET", Path: "/versions/{versionID}/acknowledgements", Tag: "versions", Summary: "Which consumers acknowledged or migrated", Resp: ackReportResponse{}},
+	{Method: "PUT", Path: "/versions/{versionID}/acknowledgements/{consumerID}", Tag: "versions", Summary: "Record an acknowledgement for a consumer", Body: ackReq{}, Resp: Acknowledgement{}},
+	{Method: "DELETE", Path: "/versions/{versionID}/acknowledgements/{consumerID}", Tag: "versions", Summary: "Clear a consumer's acknowledgement", Status: 204},
+
+	// notifications
+	{Method: "GET", Path: "/apis/{id}/notifications", Tag: "notifications", Summary: "List notifications of an API", Query: pageParams("status: comma-separated pending, sent, canceled", "type: comma-separated deprecate, sunset", "scheduled_after: RFC3339 or YYYY-MM-DD (org time zone), inclusive", "scheduled_before: RFC3339 or YYYY-MM-DD (org time zone), exclusive", "sort: scheduled_at (default), -scheduled_at, created_at or -created_at"), Resp: notificationPage{}, Conditional: true},
+	{Method: "POST", Path: "/apis/{id}/notifications", Tag: "notifications", Summary: "Schedule a notice", Body: createNotificationReq{}, Status: 201, Resp: APINotification{}},
+	{Method: "POST", Path: "/apis/{id}/notifications/preview", Tag: "notifications", Summary: "Render a notice without scheduling it", Body: previewNotificationReq{}, Resp: noticePreview{}},
+	{Method: "GET", Path: "/notifications/{noteID}", Tag: "notifications", Summary: "Get a notice", Resp: APINotification{}, Conditional: true},
+	{Method: "PUT", Path: "/notifications/{noteID}", Tag: "notifications", Summary: "Reschedule or cancel a notice", Body: updateNotificationReq{}, Resp: APINotification{}, Conditional: true},
+	{Method: "POST", Path: "/notifications/{noteID}/test-send", Tag: "notifications", Summary: "Send the notice to the caller only", Resp: sentResponse{}},
+	{Method: "GET", Path: "/notifications/{noteID}/deliveries", Tag: "notifications", Summary: "Per-destination outcome of a notice", Resp: []NotificationDelivery{}},
+	{Method: "GET", Path: "/suppressions", Tag: "notifications", Summary: "Addresses no longer mailed", Resp: []EmailSuppression{}},
+	{Method: "DELETE", Path: "/suppressions/{email}", Tag: "notifications", Summary: "Mail an address again", Status: 204},
+
+	// consumers
+	{Method: "GET", Path: "/consumers", Tag: "consumers", Summary: "List consumers", Resp: []Consumer{}},
+	{Method: "POST", Path: "/consumers", Tag: "consumers", Summary: "Add a consumer", Body: consumerReq{}, Status: 201, Resp: Consumer{}},
+	{Method: "GET", Path: "/consumers/{consumerID}", Tag: "consumers", Summary: "Get a consumer", Resp: Consumer{}},
+	{Method: "PUT", Path: "/consumers/{consumerID}", Tag: "consumers", Summary: "Replace a consumer", Body: consumerReq{}, Resp: Consumer{}},
+	{Method: "DELETE", Path: "/consumers/{consumerID}", Tag: "consumers", Summary: "Delete a consumer", Status: 204},
+	{Method: "GET", Path: "/consumers/{consumerID}/calendar", Tag: "consumers", Summary: "Calendar subscription URL for a consumer", Resp: urlResponse{}},
+	{Method: "GET", Path: "/consumers/{consumerID}/preferences", Tag: "consumers", Summary: "A consumer's notice preferences", Resp: consumerPreferencesResponse{}},
+	{Method: "PUT", Path: "/consumers/{consumerID}/preferences", Tag: "consumers", Summary: "Change a consumer's notice preferences", Body: preferencesReq{}, Resp: consumerPreferencesResponse{}},
+	{Method: "GET", Path: "/apis/{id}/consumers", Tag: "consumers", Summary: "Consumers subscribed to an API", Resp: []Consumer{}},
+	{Method: "PUT", Path: "/apis/{id}/consumers/{consumerID}", Tag: "consumers", Summary: "Subscribe a consumer to an API", Resp: statusResponse{}},
+	{Method: "DELETE", Path: "/apis/{id}/consumers/{consumerID}", Tag: "consumers", Summary: "Unsubscribe a consumer from an API", Status: 204},
+
+	// channels
+	{Method: "GET", Path: "/apis/{id}/channels", Tag: "channels", Summary: "Chat channels of an API", Resp: []NotificationChannel{}},
+	{Method: "POST", Path: "/apis/{id}/channels", Tag: "channels", Summary: "Add a Slack or Teams channel to an API", Body: createChannelReq{}, Status: 201, Resp: NotificationChannel{}},
+	{Method: "GET", Path: "/consumers/{consumerID}/channels", Tag: "channels", Summary: "Chat channels of a consumer", Resp: []NotificationChannel{}},
+	{Method: "POST", Path: "/consumers/{consumerID}/channels", Tag: "channels", Summary: "Add a Slack or Teams channel to a consumer", Body: createChannelReq{}, Status: 201, Resp: NotificationChannel{}},
+	{Method: "DELETE", Path: "/channels/{channelID}", Tag: "channels", Summary: "Delete a channel", Status: 204},
+
+	// webhooks
+	{Method: "GET", Path: "/webhooks", Tag: "webhooks", Summary: "List webhook endpoints", Resp: []WebhookEndpoint{}},
+	{Method: "POST", Path: "/webhooks", Tag: "webhooks", Summary: "Add a webhook endpoint; the secret is only returned here", Body: createWebhookReq{}, Status: 201, Resp: WebhookEndpoint{}},
+	{Method: "GET", Path: "/webhooks/{webhookID}", Tag: "webhooks", Summary: "Get a webhook endpoint", Resp: WebhookEndpoint{}},
+	{Method: "PUT", Path: "/webhooks/{webhookID}", Tag: "webhooks", Summary: "Update a webhook endpoint", Body: updateWebhookReq{}, Resp: WebhookEndpoint{}},
+	{Method: "DELETE", Path: "/webhooks/{webhookID}", Tag: "webhooks", Summary: "Delete a webhook endpoint", Status: 204},
+	{Method: "GET", Path: "/webhooks/{webhookID}/deliveries", Tag: "webhooks", Summary: "Recent deliveries to an endpoint", Resp: []WebhookDelivery{}},
+	{Method: "POST", Path: "/webhooks/{webhookID}/test", Tag: "webhooks", Summary: "Send a signed webhook.test event now", Resp: WebhookDelivery{}},
+
+	// templates
+	{Method: "GET", Path: "/templates", Tag: "templates", Summary: "Active template of every notice type", Query: []string{qLocale}, Resp: templateListResponse{}},
+	{Method: "POST", Path: "/templates/preview", Tag: "templates", Summary: "Render a draft template with sample data", Body: previewTemplateReq{}, Resp: renderedNotice{}},
+	{Method: "GET", Path: "/templates/{type}", Tag: "templates", Summary: "Active template of a notice type", Query: []string{qLocale}, Resp: templateView{}},
+	{Method: "PUT", Path: "/templates/{type}", Tag: "templates", Summary: "Save a new active template version", Query: []string{qLocale}, Body: saveTemplateReq{}, Resp: NotificationTemplate{}},
+	{Method: "DELETE", Path: "/templates/{type}", Tag: "templates", Summary: "Drop custom templates and fall back to the default", Query: []string{qLocale}, Status: 204},
+	{Method: "GET", Path: "/templates/{type}/versions", Tag: "templates", Summary: "Stored versions of a template", Query: []string{qLocale}, Resp: []NotificationTemplate{}},
+	{Method: "POST", Path: "/templates/{type}/versions/{version}/restore", Tag: "templates", Summary: "Make an old version active again", Resp: NotificationTemplate{}},
+
+	// admin
+	{Method: "GET", Path: "/admin/backups", Tag: "admin", Summary: "List SQLite backups (ADMIN_EMAILS only)", Resp: []Backup{}},
+	{Method: "POST", Path: "/admin/backups", Tag: "admin", Summary: "Take a SQLite backup now (ADMIN_EMAILS only)", Status: 201, Resp: Backup{}},
+
+	// public, reached through signed links
+	{Method: "GET", Path: "/calendar.ics", Tag: "public", Summary: "iCalendar feed of upcoming sunsets", Public: true, Query: []string{qToken}, Resp: rawContent{"text/calendar"}},
+	{Method: "GET", Path: "/preferences", Tag: "public", Summary: "Consumer preferences page (JSON with Accept: application/json)", Public: true, Query: []string{qToken}, Resp: preferencesView{}},
+	{Method: "POST", Path: "/preferences", Tag: "public", Summary: "Save consumer preferences (form or JSON)", Public: true, Query: []string{qToken}, Body: preferencesReq{}, Resp: preferencesView{}},
+	{Method: "GET", Path: "/preferences/unsubscribe", Tag: "public", Summary: "Redirect a browser to the preferences page", Public: true, Query: []string{qToken}, Status: 303},
+	{Method: "POST", Path: "/preferences/unsubscribe", Tag: "public", Summary: "RFC 8058 one-click unsubscribe", Public: true, Query: []string{qToken}, Resp: statusResponse{}},
+	{Method: "GET", Path: "/ack", Tag: "public", Summary: "Acknowledgement page (JSON with Accept: application/json)", Public: true, Query: []string{qToken}, Resp: ackPageResponse{}},
+	{Method: "POST", Path: "/ack", Tag: "public", Summary: "Acknowledge a deprecation or report a migration (form or JSON)", Public: true, Query: []string{qToken}, Body: ackReq{}, Resp: Acknowledgement{}},
+	{Method: "POST", Path: "/inbound/sendgrid", Tag: "public", Summary: "SendGrid Signed Event Webhook", Public: true, Body: []sendgridEvent{}, Status: 204},
+	{Method: "GET", Path: "/feeds/apis/{id}.{format}", Tag: "public", Summary: "Changelog of one API (format: atom or rss)", Public: true, Resp: rawContent{"application/atom+xml", "application/rss+xml"}},
+	{Method: "GET", Path: "/feeds/orgs/{orgID}.{format}", Tag: "public", Summary: "Changelog of an org (format: atom or rss)", Public: true, Resp: rawContent{"application/atom+xml", "application/rss+xml"}},
+}
+
+/* -------------------- document -------------------- */
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+)
+
+// GET /openapi.json — OpenAPI 3.1 description of this API
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		doc, err := json.MarshalIndent(buildOpenAPI(), "", "  ")
+		if err != nil {
+			panic(err) // the document is built from static types only
+		}
+		openAPIDoc = doc
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPIDoc)
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+func buildOpenAPI() map[string]any {
+	g := &schemaGen{components: map[string]any{}}
+	errRef := g.schema(reflect.TypeOf(problem{}))
+	paths := map[string]map[string]any{}
+
+	for _, op := range openAPIOps {
+		o := map[string]any{
+			"operationId": operationID(op),
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+		}
+		if op.Public {
+			o["security"] = []any{}
+		}
+
+		var params []any
+		for _, m := range pathParamRe.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			name, desc, _ := strings.Cut(q, ": ")
+			params = append(params, map[string]any{
+				"name": name, "in": "query", "description": desc, "schema": map[string]any{"type": "string"},
+			})
+		}
+		if op.Conditional {
+			if op.Method == "GET" {
+				params = append(params, map[string]any{
+					"name": "If-None-Match", "in": "header", "description": "ETag of a previous response; 304 while it is current", "schema": map[string]any{"type": "string"},
+				})
+			} else {
+				params = append(params, map[string]any{
+					"name": "If-Match", "in": "header", "required": true, "description": `ETag ("<revision>") of the version being changed, or *`, "schema": map[string]any{"type": "string"},
+				})
+			}
+		}
+		if len(params) > 0 {
+			o["parameters"] = params
+		}
+
+		if op.Body != nil {
+			o["requestBody"] = map[string]any{"required": true, "content": g.content(op.Body)}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := map[string]any{"description": http.StatusText(status)}
+		if op.Resp != nil {
+			ok["content"] = g.content(op.Resp)
+		}
+		errResp := func(status int) map[string]any {
+			return map[string]any{
+				"description": http.StatusText(status),
+				"content":     map[string]any{"application/problem+json": map[string]any{"schema": errRef}},
+			}
+		}
+		responses := map[string]any{fmt.Sprint(status): ok}
+		if op.Body != nil || len(op.Query) > 0 {
+			responses["400"] = errResp(http.StatusBadRequest)
+		}
+		if !op.Public {
+			responses["401"] = errResp(http.StatusUnauthorized)
+		}
+		if strings.Contains(op.Path, "{") {
+			responses["404"] = errResp(http.StatusNotFound)
+		}
+		for _, e := range op.Errors {
+			responses[fmt.Sprint(e)] = errResp(e)
+		}
+		if op.Conditional {
+			if op.Method == "GET" {
+				ok["headers"] = map[string]any{"ETag": map[string]any{"schema": map[string]any{"type": "string"}}}
+				responses["304"] = map[string]any{"description": http.StatusText(http.StatusNotModified)}
+			} else {
+				responses["412"] = errResp(http.StatusPreconditionFailed)
+				responses["428"] = errResp(http.StatusPreconditionRequired)
+			}
+		}
+		limited := errResp(http.StatusTooManyRequests)
+		limited["description"] = "Rate limited; retry after X-RateLimit-Reset seconds"
+		responses["429"] = limited
+		o["responses"] = responses
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = o
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "Smelinx API",
+			"version":     "1",
+			"description": "Register APIs, track versions, schedule deprecations and notify consumers.",
+			"license":     map[string]any{"name": "MIT", "identifier": "MIT"},
+		},
+		"servers": []any{map[string]any{"url": publicBaseURL()}},
+		"security": []any{
+			map[string]any{"bearerAuth": []string{}},
+			map[string]any{"cookieAuth": []string{}},
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "description": "Personal access token (smx_pat_...) from POST /me/tokens"},
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": "access_token", "description": "Session cookie set by POST /auth/login"},
+			},
+		},
+	}
+}
+
+// operationID is e.g. "getApisIdVersions" for GET /apis/{id}/versions.
+func operationID(op apiOp) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if op.Path == "/" {
+		b.WriteString("Root")
+	}
+	return b.String()
+}
+
+/* -------------------- schemas -------------------- */
+
+// schemaGen turns Go types into JSON Schema, registering named structs as
+// components/schemas under their Go name.
+type schemaGen struct {
+	components map[string]any
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGen) content(v any) map[string]any {
+	if raw, ok := v.(rawContent); ok {
+		out := map[string]any{}
+		for _, mt := range raw {
+			out[mt] = map[string]any{}
+		}
+		return out
+	}
+	return map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(v))}}
+}
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if _, seen := g.components[name]; !seen {
+			g.components[name] = map[string]any{} // placeholder for recursive types
+			g.components[name] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{} // interface{}: any JSON value
+}
+
+// object describes a struct the way encoding/json writes it: embedded
+// structs are flattened, and fields without omitempty are required. Request
+// bodies (the *Req types) have defaults instead, so nothing is required there.
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	request := strings.HasSuffix(t.Name(), "Req")
+	props := map[string]any{}
+	var required []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			omitempty := strings.Contains(opts, "omitempty")
+			props[name] = g.schema(f.Type)
+			switch {
+			case f.Type.Kind() == reflect.Pointer && !omitempty:
+				props[name] = map[string]any{"oneOf": []any{props[name], map[string]any{"type": "null"}}}
+			case f.Type.Kind() != reflect.Pointer && !omitempty && !request:
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+/* -------------------- coverage check -------------------- */
+
+// checkOpenAPI compares the routes registered on r with openAPIOps and
+// returns one line per undocumented route or stale operation.
+func checkOpenAPI(r chi.Routes) ([]string, error) {
+	documented := map[string]bool{}
+	for _, op := range openAPIOps {
+		key := op.Method + " " + op.Path
+		if documented[key] {
+			return nil, fmt.Errorf("%s is documented twice", key)
+		}
+		documented[key] = true
+	}
+
+	var problems []string
+	routed := map[string]bool{}
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		key := method + " " + route
+		routed[key] = true
+		if !documented[key] {
+			problems = append(problems, "undocumented route: "+key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range openAPIOps {
+		if key := op.Method + " " + op.Path; !routed[key] {
+			problems = append(problems, "documented but not routed: "+key)
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
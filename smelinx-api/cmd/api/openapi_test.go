@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestOpenAPIDocumentsEveryRoute fails when a chi route is missing from
+// openAPIOps, or an operation is documented without a route.
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	problems, err := checkOpenAPI(newRouter(&AuthService{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Errorf("%s (see openAPIOps in openapi.go)", p)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := buildOpenAPI()
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	ids := map[string]string{}
+	for _, op := range openAPIOps {
+		id := operationID(op)
+		if prev, ok := ids[id]; ok {
+			t.Errorf("operationId %s is shared by %s and %s %s", id, prev, op.Method, op.Path)
+		}
+		ids[id] = op.Method + " " + op.Path
+	}
+}
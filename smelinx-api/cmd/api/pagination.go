@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+List pagination.
+
+List endpoints take ?limit= (default 50, at most 200), ?sort= and ?cursor=
+and answer
+
+	{"items": [...], "next_cursor": "eyJzIjoi..."}
+
+with the same cursor in a Link: <...>; rel="next" header. next_cursor is
+omitted on the last page.
+
+Pages are keyset-based: the cursor holds the sort key and id of the last
+row served, and the next page starts after it, so rows created or deleted
+while a client pages through neither repeat nor go missing. Cursors are
+opaque and only valid with the sort (and filters) they were issued for.
+*/
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// sortField is one ?sort= option of a list: the column it orders by and
+// whether that column is a timestamp (compared through ts()).
+type sortField struct {
+	col  string
+	time bool
+}
+
+// pageQuery is the paging part of a list query. Limit 0 means all rows.
+type pageQuery struct {
+	Limit int
+	Field string // key of the sorts map
+	Desc  bool
+	After *pageCursor
+}
+
+type pageCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+// pageItem is a row of a paged list; cursorKey returns its value for a
+// sort field, formatted as the cursor stores it.
+type pageItem interface {
+	cursorKey(field string) string
+	cursorID() string
+}
+
+func (p pageQuery) sort() string {
+	if p.Desc {
+		return "-" + p.Field
+	}
+	return p.Field
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*pageCursor, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	var c pageCursor
+	if json.Unmarshal(b, &c) != nil || c.ID == "" {
+		return nil, false
+	}
+	return &c, true
+}
+
+// cursorTime formats a timestamp sort key.
+func cursorTime(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) }
+
+// parsePageQuery reads limit, sort and cursor. sort is a key of sorts,
+// "-" prefixed for descending; def is used when it is absent.
+func parsePageQuery(q url.Values, sorts map[string]sortField, def string, errs *fieldErrors) pageQuery {
+	p := pageQuery{Limit: defaultPageLimit}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			errs.invalid("limit", "must be 1-%d", maxPageLimit)
+		} else {
+			p.Limit = n
+		}
+	}
+
+	by := q.Get("sort")
+	if by == "" {
+		by = def
+	}
+	p.Field, p.Desc = strings.TrimPrefix(by, "-"), strings.HasPrefix(by, "-")
+	if _, ok := sorts[p.Field]; !ok {
+		names := make([]string, 0, len(sorts))
+		for name := range sorts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		errs.invalid("sort", "must be one of %s (prefix - for descending)", strings.Join(names, ", "))
+	}
+
+	if s := q.Get("cursor"); s != "" {
+		c, ok := decodeCursor(s)
+		switch {
+		case !ok:
+			errs.invalid("cursor", "malformed cursor")
+		case c.Sort != p.sort():
+			errs.invalid("cursor", "cursor was issued for sort=%s", c.Sort)
+		default:
+			p.After = c
+		}
+	}
+	return p
+}
+
+// keyset returns the condition selecting rows after p.After (empty on the
+// first page) with its arguments, and the ORDER BY clause. id breaks ties,
+// in the same direction as the sort.
+func (s *Store) keyset(alias string, sorts map[string]sortField, p pageQuery) (string, []any, string) {
+	f := sorts[p.Field]
+	key, val := qualify(alias, f.col), "?"
+	if f.time {
+		key, val = s.db.ts(key), s.db.ts("?")
+	}
+	id := qualify(alias, "id")
+	op, dir := ">", "ASC"
+	if p.Desc {
+		op, dir = "<", "DESC"
+	}
+	order := key + " " + dir + ", " + id + " " + dir
+	if p.After == nil {
+		return "", nil, order
+	}
+	cond := "(" + key + " " + op + " " + val + " OR (" + key + " = " + val + " AND " + id + " " + op + " ?))"
+	return cond, []any{p.After.Key, p.After.Key, p.After.ID}, order
+}
+
+// limitClause is the LIMIT for a page: one row more than asked, so
+// trimPage can tell whether another page follows.
+func (p pageQuery) limitClause() string {
+	if p.Limit == 0 {
+		return ""
+	}
+	return " LIMIT " + strconv.Itoa(p.Limit+1)
+}
+
+// trimPage drops the extra row fetched past the limit and returns the
+// cursor for the next page, or "" on the last one.
+func trimPage[T pageItem](items []T, p pageQuery) ([]T, string) {
+	if items == nil {
+		items = []T{}
+	}
+	if p.Limit == 0 || len(items) <= p.Limit {
+		return items, ""
+	}
+	items = items[:p.Limit]
+	last := items[len(items)-1]
+	return items, encodeCursor(pageCursor{Sort: p.sort(), Key: last.cursorKey(p.Field), ID: last.cursorID()})
+}
+
+// writePage answers with a page envelope, a Link header to the next page
+// (the request URL with cursor replaced) and a weak ETag.
+func writePage(w http.ResponseWriter, r *http.Request, items any, next string) {
+	out := map[string]any{"items": items}
+	if next != "" {
+		q := r.URL.Query()
+		q.Set("cursor", next)
+		w.Header().Set("Link", "<"+publicBaseURL()+r.URL.Path+"?"+q.Encode()+`>; rel="next"`)
+		out["next_cursor"] = next
+	}
+	writeJSONTagged(w, r, out)
+}
+
+// listFilter splits a comma-separated filter (?status=deprecated,sunset)
+// and reports values outside allowed.
+func listFilter(q url.Values, name string, allowed []string, errs *fieldErrors) []string {
+	raw := strings.TrimSpace(q.Get(name))
+	if raw == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.TrimSpace(v)
+		ok := false
+		for _, a := range allowed {
+			ok = ok || v == a
+		}
+		if !ok {
+			errs.invalid(name, "%q is not one of %s", v, strings.Join(allowed, ", "))
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// likeContains is a LIKE pattern matching s anywhere, with LIKE's
+// wildcards in s escaped (use with ESCAPE '\').
+func likeContains(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(s)) + "%"
+}
+
+// inClause is "col IN (?, ?)" with its arguments.
+func inClause(col string, values []string) (string, []any) {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return col + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", args
+}
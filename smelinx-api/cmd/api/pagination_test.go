@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestListSortsUseIndexes checks on SQLite that every list sort, on the
+// first page and after a cursor, is read in order from an index rather
+// than sorted in a temp B-tree.
+func TestListSortsUseIndexes(t *testing.T) {
+	db := migratedDB(t, openTestSQLite(t))
+	s := NewStore(db)
+	lists := []struct {
+		from, scope string
+		sorts       map[string]sortField
+	}{
+		{"apis", "org_id = ? AND deleted_at IS NULL", apiSorts},
+		{"api_versions", "api_id = ? AND deleted_at IS NULL", versionSorts},
+		{"notifications", "api_id = ?", notificationSorts},
+	}
+	for _, l := range lists {
+		for field := range l.sorts {
+			if l.from == "notifications" && field == "created_at" {
+				continue // rare sort of a short list; not indexed
+			}
+			for _, desc := range []bool{false, true} {
+				for _, after := range []*pageCursor{nil, {Key: "2030-01-01T00:00:00Z", ID: "x"}} {
+					p := pageQuery{Limit: 10, Field: field, Desc: desc, After: after}
+					cond, args, order := s.keyset("", l.sorts, p)
+					where := l.scope
+					if cond != "" {
+						where += " AND " + cond
+					}
+					q := `EXPLAIN QUERY PLAN SELECT id FROM ` + l.from + ` WHERE ` + where + ` ORDER BY ` + order + p.limitClause()
+					plan := explain(t, db, q, append([]any{"scope"}, args...)...)
+					if strings.Contains(plan, "TEMP B-TREE") || !strings.Contains(plan, "USING") {
+						t.Errorf("%s sort=%s after=%v:\n%s", l.from, p.sort(), after != nil, plan)
+					}
+				}
+			}
+		}
+	}
+}
+
+func explain(t *testing.T, db *dbConn, q string, args ...any) string {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), q, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", q, err)
+	}
+	defer rows.Close()
+	var b strings.Builder
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		b.WriteString(detail + "\n")
+	}
+	return b.String()
+}
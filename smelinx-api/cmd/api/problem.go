@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+/*
+Error responses.
+
+Every error is an RFC 7807 problem (Content-Type: application/problem+json):
+
+	{
+	  "type": "urn:smelinx:problem:validation_failed",
+	  "title": "Validation failed",
+	  "status": 400,
+	  "code": "validation_failed",
+	  "detail": "1 field is invalid",
+	  "instance": "/apis/7f3c.../versions",
+	  "request_id": "api-1/x8Kq2Lr0bM-000042",
+	  "errors": [{"field": "sunset_date", "code": "invalid", "message": "want YYYY-MM-DD"}]
+	}
+
+code is stable and meant for programs; title and detail are for people and
+may change. request_id is also sent as X-Request-ID on every response and
+prefixes the server log line, so a report can be matched to the log.
+
+Failures on our side are logged with the underlying error and answered with
+a generic detail: database and provider errors never reach the client.
+*/
+
+// Stable problem codes.
+const (
+	codeInvalidBody      = "invalid_body"      // not JSON, or the wrong JSON types
+	codeValidation       = "validation_failed" // errors lists the fields
+	codeUnauthorized     = "unauthorized"
+	codeInvalidLogin     = "invalid_credentials"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeTooLarge         = "payload_too_large"
+	codeRateLimited      = "rate_limited"
+	codeInternal         = "internal_error"
+	codeNotImplemented   = "not_implemented"
+	codeUpstream         = "upstream_failed" // mail server, chat or webhook endpoint refused
+
+	codePreconditionFailed   = "precondition_failed"   // If-Match does not match the current revision
+	codePreconditionRequired = "precondition_required" // If-Match missing on PUT / DELETE
+)
+
+var problemTitles = map[string]string{
+	codeInvalidBody:      "Malformed request body",
+	codeValidation:       "Validation failed",
+	codeUnauthorized:     "Authentication required",
+	codeInvalidLogin:     "Invalid email or password",
+	codeForbidden:        "Forbidden",
+	codeNotFound:         "Not found",
+	codeMethodNotAllowed: "Method not allowed",
+	codeConflict:         "Conflict",
+	codeTooLarge:         "Payload too large",
+	codeRateLimited:      "Too many requests",
+	codeInternal:         "Internal server error",
+	codeNotImplemented:   "Not implemented",
+	codeUpstream:         "Upstream delivery failed",
+
+	codePreconditionFailed:   "Precondition failed",
+	codePreconditionRequired: "Precondition required",
+}
+
+// problem is the RFC 7807 body. Ext holds extension members specific to
+// one error (e.g. the import report on a conflict).
+type problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Code      string         `json:"code"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []fieldError   `json:"errors,omitempty"`
+	Ext       map[string]any `json:"-"`
+}
+
+func (p problem) MarshalJSON() ([]byte, error) {
+	type plain problem
+	b, err := json.Marshal(plain(p))
+	if err != nil || len(p.Ext) == 0 {
+		return b, err
+	}
+	ext, err := json.Marshal(p.Ext)
+	if err != nil {
+		return nil, err
+	}
+	// both are non-empty objects: splice the extension members in
+	return append(append(b[:len(b)-1], ','), ext[1:]...), nil
+}
+
+// fieldError is one invalid field of a request body. Field is the JSON
+// path ("name", "apis[0].versions[1].status"); Code is one of required,
+// invalid, not_found or conflict.
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type fieldErrors []fieldError
+
+func (e *fieldErrors) add(field, code, message string) {
+	*e = append(*e, fieldError{Field: field, Code: code, Message: message})
+}
+
+func (e *fieldErrors) required(field string) { e.add(field, "required", "required") }
+
+func (e *fieldErrors) invalid(field, format string, args ...any) {
+	e.add(field, "invalid", fmt.Sprintf(format, args...))
+}
+
+// pathFieldErrors turns "path: message" problems (bundle and manifest
+// validation) into field errors.
+func pathFieldErrors(problems []string) fieldErrors {
+	out := make(fieldErrors, 0, len(problems))
+	for _, p := range problems {
+		field, msg, ok := strings.Cut(p, ": ")
+		if !ok {
+			field, msg = "", p
+		}
+		out.add(field, "invalid", msg)
+	}
+	return out
+}
+
+/* -------------------- writers -------------------- */
+
+func writeProblem(w http.ResponseWriter, r *http.Request, p problem) {
+	if p.Title == "" {
+		p.Title = problemTitles[p.Code]
+	}
+	p.Type = "urn:smelinx:problem:" + p.Code
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Del("Content-Disposition")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// writeError answers with a problem; detail is shown to the client as is,
+// so it must not carry internal errors.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblem(w, r, problem{Status: status, Code: code, Detail: detail})
+}
+
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, codeNotFound, "not found")
+}
+
+func writeValidation(w http.ResponseWriter, r *http.Request, errs fieldErrors) {
+	writeValidationStatus(w, r, http.StatusBadRequest, errs)
+}
+
+func writeValidationStatus(w http.ResponseWriter, r *http.Request, status int, errs fieldErrors) {
+	detail := "1 field is invalid"
+	if len(errs) != 1 {
+		detail = fmt.Sprintf("%d fields are invalid", len(errs))
+	}
+	writeProblem(w, r, problem{Status: status, Code: codeValidation, Detail: detail, Errors: errs})
+}
+
+// writeInternal logs err against the request ID and answers 500 with only
+// what failed ("create failed").
+func writeInternal(w http.ResponseWriter, r *http.Request, err error, what string) {
+	log.Printf("[error] %s %s %s: %s: %v", middleware.GetReqID(r.Context()), r.Method, r.URL.Path, what, err)
+	writeError(w, r, http.StatusInternalServerError, codeInternal, what)
+}
+
+// decodeJSON reads the request body into v. On malformed input it answers
+// 400 invalid_body (a field of the wrong type is reported as that field)
+// and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, codeTooLarge, fmt.Sprintf("body exceeds %d bytes", tooLarge.Limit))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		writeProblem(w, r, problem{
+			Status: http.StatusBadRequest, Code: codeInvalidBody, Detail: "a field has the wrong type",
+			Errors: []fieldError{{Field: typeErr.Field, Code: "invalid", Message: "must be " + jsonKind(typeErr.Type.String())}},
+		})
+	case errors.Is(err, io.EOF):
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "request body is empty")
+	default:
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, "request body is not valid JSON")
+	}
+	return false
+}
+
+// jsonKind names a Go type the way a JSON client would think of it.
+func jsonKind(goType string) string {
+	switch strings.TrimLeft(goType, "*") {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "int", "int64", "int32", "uint", "uint64":
+		return "an integer"
+	case "float64", "float32":
+		return "a number"
+	}
+	if strings.HasPrefix(goType, "[]") {
+		return "an array"
+	}
+	return "an object"
+}
+
+/* -------------------- middleware -------------------- */
+
+// requestIDHeader returns the request ID on every response as X-Request-ID.
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set("X-Request-ID", id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverer turns a panic into a logged 500 problem.
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil || rec == http.ErrAbortHandler {
+				if rec != nil {
+					panic(rec)
+				}
+				return
+			}
+			log.Printf("[panic] %s %s %s: %v\n%s", middleware.GetReqID(r.Context()), r.Method, r.URL.Path, rec, debug.Stack())
+			writeError(w, r, http.StatusInternalServerError, codeInternal, "unexpected error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) { writeNotFound(w, r) }
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, r.Method+" is not supported here")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Repository is the storage contract for the core entities. *Store
+// implements it for every backend; the SQL differences between SQLite and
+// PostgreSQL live in the dialect (db_sqlite.go, db_postgres.go).
+type Repository interface {
+	UserRepository
+	OrgRepository
+	APIRepository
+	VersionRepository
+	NotificationRepository
+}
+
+var _ Repository = (*Store)(nil)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, email, hash string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUserByID(ctx context.Context, id string) (*User, error)
+}
+
+type OrgRepository interface {
+	CreateOrgWithOwner(ctx context.Context, name, ownerID string) (*Org, error)
+	GetOrgByID(ctx context.Context, id string) (*Org, error)
+	GetUserPrimaryOrg(ctx context.Context, uid string) (*Org, error)
+	SetOrgTimezone(ctx context.Context, id, tz string) error
+	SetOrgLocale(ctx context.Context, id, locale string) error
+}
+
+type APIRepository interface {
+	ListAPIs(ctx context.Context, orgID string, q apiQuery) ([]API, error)
+	CreateAPI(ctx context.Context, orgID, name, desc string, meta *APIMeta) (*API, error)
+	GetAPIByID(ctx context.Context, id string) (*API, error)
+	UpdateAPI(ctx context.Context, id, name, desc string, meta *APIMeta, rev int64) (*API, error)
+	DeleteAPI(ctx context.Context, id string, rev int64) error
+	APIAutoNotify(ctx context.Context, apiID string) (bool, error)
+	SetAPIAutoNotify(ctx context.Context, apiID string, on bool) error
+}
+
+type VersionRepository interface {
+	ListVersions(ctx context.Context, apiID string, q versionQuery) ([]APIVersion, error)
+	CreateVersion(ctx context.Context, apiID, version, status string, sunset *time.Time) (*APIVersion, error)
+	GetVersionByID(ctx context.Context, id string) (*APIVersion, error)
+	UpdateVersionStatus(ctx context.Context, id, status string, sunset *time.Time, rev int64) (*APIVersion, error)
+	DeleteVersion(ctx context.Context, id string, rev int64) error
+	SuccessorVersion(ctx context.Context, apiID, versionID string) (string, error)
+}
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, apiID, versionID, typ string, when time.Time, tz string) (*APINotification, error)
+	CreateAutoNotification(ctx context.Context, apiID, versionID, typ, dedupeKey string) (*APINotification, error)
+	GetNotificationByID(ctx context.Context, id string) (*APINotification, error)
+	ListNotifications(ctx context.Context, apiID, orgID string, q notificationQuery) ([]APINotification, error)
+	UpdateNotificationStatus(ctx context.Context, id, status string, rev int64) (*APINotification, error)
+	ListDueNotifications(ctx context.Context, limit int) ([]dueNotification, error)
+	MarkNotificationSent(ctx context.Context, id string) error
+	DeferNotification(ctx context.Context, id string, until time.Time) error
+	ScheduleNotificationRetry(ctx context.Context, id string, next time.Time, attempts int, lastErr string) error
+	AutoCancelNotification(ctx context.Context, id string, reason string) error
+}
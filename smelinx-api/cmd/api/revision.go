@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+Optimistic concurrency.
+
+APIs, versions and notifications carry a revision that every change to
+the resource bumps. GET answers with ETag: "<revision>" (the revision is
+also in the body); PUT and DELETE must send it back as If-Match and are
+refused with 412 when the resource has changed since, i.e. someone else
+saved in between, or with 428 when If-Match is missing. "If-Match: *"
+skips the check explicitly. The store repeats the comparison in its UPDATE,
+so two writers that both pass the handler's check cannot both win.
+
+List pages carry a weak ETag over the body; repeating a list request with
+If-None-Match answers 304 Not Modified while nothing on the page changed.
+*/
+
+var errStaleRevision = errors.New("revision changed")
+
+func etag(rev int64) string { return `"` + strconv.FormatInt(rev, 10) + `"` }
+
+// etagMatches reports whether an If-Match / If-None-Match header lists tag.
+// Weak comparison ignores W/ prefixes; strong comparison never matches a
+// weak tag.
+func etagMatches(header, tag string, weak bool) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return true
+		}
+		if weak {
+			t, tag = strings.TrimPrefix(t, "W/"), strings.TrimPrefix(tag, "W/")
+		} else if strings.HasPrefix(t, "W/") {
+			continue
+		}
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces If-Match against the revision just loaded; writes
+// 428 / 412 and returns false otherwise.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, rev int64) bool {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" {
+		writeError(w, r, http.StatusPreconditionRequired, codePreconditionRequired,
+			"send If-Match with the ETag (or revision) you last read")
+		return false
+	}
+	if !etagMatches(h, etag(rev), false) {
+		writeStale(w, r, rev)
+		return false
+	}
+	return true
+}
+
+// writeStale answers 412, with the current revision when known (rev > 0).
+func writeStale(w http.ResponseWriter, r *http.Request, rev int64) {
+	p := problem{
+		Status: http.StatusPreconditionFailed, Code: codePreconditionFailed,
+		Detail: "modified since you read it; reload and retry",
+	}
+	if rev > 0 {
+		w.Header().Set("ETag", etag(rev))
+		p.Ext = map[string]any{"revision": rev}
+	}
+	writeProblem(w, r, p)
+}
+
+// writeUpdateError answers a failed conditional store write: 412 when the
+// revision moved after checkIfMatch, else 500 with what.
+func writeUpdateError(w http.ResponseWriter, r *http.Request, err error, what string) {
+	if errors.Is(err, errStaleRevision) {
+		writeStale(w, r, 0)
+		return
+	}
+	writeInternal(w, r, err, what)
+}
+
+// notModified sets the ETag and answers 304 when If-None-Match lists it.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	if h := r.Header.Get("If-None-Match"); h != "" && etagMatches(h, tag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeJSONTagged is writeJSON for cacheable GETs: a weak ETag over the
+// body, and 304 when the client already has it.
+func writeJSONTagged(w http.ResponseWriter, r *http.Request, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeInternal(w, r, err, "encode failed")
+		return
+	}
+	sum := sha256.Sum256(b)
+	if notModified(w, r, `W/"`+hex.EncodeToString(sum[:8])+`"`) {
+		return
+	}
+	writeJSON(w, http.StatusOK, json.RawMessage(b))
+}
+
+/* -------------------- store side -------------------- */
+
+// revisionCheck is the extra WHERE condition for an expected revision;
+// rev 0 means unconditional.
+func revisionCheck(rev int64) (string, []any) {
+	if rev == 0 {
+		return "", nil
+	}
+	return " AND revision = ?", []any{rev}
+}
+
+// staleRevision turns "no row updated" under a revision check into
+// errStaleRevision.
+func staleRevision(res sql.Result, rev int64) error {
+	if rev == 0 {
+		return nil
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errStaleRevision
+	}
+	return nil
+}
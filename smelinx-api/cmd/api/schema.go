@@ -1,92 +1,396 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"errors"
+	"regexp"
 )
 
-func runMigrations(db *sql.DB) {
-	stmts := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id TEXT PRIMARY KEY,
-			email TEXT UNIQUE NOT NULL,
-			password_hash TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL DEFAULT (datetime('now'))
-		);`,
-		`CREATE TABLE IF NOT EXISTS organizations (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL DEFAULT (datetime('now'))
-		);`,
-		`CREATE TABLE IF NOT EXISTS org_members (
-			org_id  TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			role    TEXT NOT NULL CHECK (role IN ('owner','admin','member')),
-			PRIMARY KEY (org_id, user_id),
-			FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-		);`,
-		`CREATE TABLE IF NOT EXISTS apis (
-			id          TEXT PRIMARY KEY,
-			org_id      TEXT NOT NULL,
-			name        TEXT NOT NULL,
-			description TEXT,
-			created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
-			deleted_at  TIMESTAMP,
-			FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
-		);`,
-		`CREATE TABLE IF NOT EXISTS api_versions (
-			id           TEXT PRIMARY KEY,
-			api_id       TEXT NOT NULL,
-			version      TEXT NOT NULL,
-			status       TEXT NOT NULL CHECK (status IN ('active','deprecated','sunset')) DEFAULT 'active',
-			sunset_date  DATE,
-			created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
-			deleted_at   TIMESTAMP,
-			UNIQUE (api_id, version),
-			FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE
-		);`,
-		// notifications table
-		`CREATE TABLE IF NOT EXISTS notifications (
-			id            TEXT PRIMARY KEY,
-			api_id        TEXT NOT NULL,
-			version_id    TEXT NOT NULL,
-			type          TEXT NOT NULL CHECK (type IN ('deprecate','sunset')),
-			scheduled_at  TIMESTAMP NOT NULL,
-			status        TEXT NOT NULL CHECK (status IN ('pending','sent','canceled')) DEFAULT 'pending',
-			created_at    TIMESTAMP NOT NULL DEFAULT (datetime('now')),
-			FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE,
-			FOREIGN KEY (version_id) REFERENCES api_versions(id) ON DELETE CASCADE
-		);`,
+// migrations is the schema history, oldest first (applied by migrate.go).
+// Never edit a released migration; change the schema with a new one.
+var migrations = []migration{
+	{Version: 1, Name: "baseline", Up: baselineUp, Down: baselineDown},
+	{Version: 2, Name: "api_tokens", Up: apiTokensUp, Down: apiTokensDown},
+	{Version: 3, Name: "list_indexes", Up: listIndexesUp, Down: listIndexesDown},
+	{Version: 4, Name: "revisions", Up: revisionsUp, Down: revisionsDown},
+}
+
+/* -------------------- 1: baseline -------------------- */
+
+// baselineUp is the schema as it stood before numbered migrations. It is
+// idempotent so databases created by earlier releases adopt it in place:
+// missing tables are created and columns added since then are added.
+func baselineUp(m *schemaTx) error {
+	if err := m.exec(baselineTables...); err != nil {
+		return err
 	}
-	for _, s := range stmts {
-		if _, err := db.Exec(s); err != nil {
-			log.Fatalf("migration failed: %v", err)
+	for _, c := range baselineColumns {
+		if err := m.addColumn(c.table, c.col, c.decl); err != nil {
+			return err
 		}
 	}
+	return m.exec(`CREATE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(dedupe_key);`)
+}
+
+// baselineDown refuses: the baseline adopts databases of earlier releases
+// in place, so reverting it would drop users, orgs and APIs that no
+// migration created.
+func baselineDown(m *schemaTx) error {
+	return errors.New("baseline cannot be reverted")
+}
 
+var baselineTables = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT (datetime('now'))
+	);`,
+	`CREATE TABLE IF NOT EXISTS organizations (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT (datetime('now'))
+	);`,
+	`CREATE TABLE IF NOT EXISTS org_members (
+		org_id  TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role    TEXT NOT NULL CHECK (role IN ('owner','admin','member')),
+		PRIMARY KEY (org_id, user_id),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS apis (
+		id          TEXT PRIMARY KEY,
+		org_id      TEXT NOT NULL,
+		name        TEXT NOT NULL,
+		description TEXT,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		deleted_at  TIMESTAMP,
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS api_versions (
+		id           TEXT PRIMARY KEY,
+		api_id       TEXT NOT NULL,
+		version      TEXT NOT NULL,
+		status       TEXT NOT NULL CHECK (status IN ('active','deprecated','sunset')) DEFAULT 'active',
+		sunset_date  DATE,
+		created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		deleted_at   TIMESTAMP,
+		UNIQUE (api_id, version),
+		FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE
+	);`,
+	// notifications table
+	`CREATE TABLE IF NOT EXISTS notifications (
+		id            TEXT PRIMARY KEY,
+		api_id        TEXT NOT NULL,
+		version_id    TEXT NOT NULL,
+		type          TEXT NOT NULL CHECK (type IN ('deprecate','sunset')),
+		scheduled_at  TIMESTAMP NOT NULL,
+		status        TEXT NOT NULL CHECK (status IN ('pending','sent','canceled')) DEFAULT 'pending',
+		created_at    TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE,
+		FOREIGN KEY (version_id) REFERENCES api_versions(id) ON DELETE CASCADE
+	);`,
+	// consumers of an org's APIs and their per-API subscriptions
+	`CREATE TABLE IF NOT EXISTS consumers (
+		id          TEXT PRIMARY KEY,
+		org_id      TEXT NOT NULL,
+		name        TEXT NOT NULL,
+		email       TEXT,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS api_consumers (
+		api_id      TEXT NOT NULL,
+		consumer_id TEXT NOT NULL,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (api_id, consumer_id),
+		FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE,
+		FOREIGN KEY (consumer_id) REFERENCES consumers(id) ON DELETE CASCADE
+	);`,
+	// chat channels (Slack/Teams webhooks), scoped to one API or one consumer
+	`CREATE TABLE IF NOT EXISTS notification_channels (
+		id           TEXT PRIMARY KEY,
+		org_id       TEXT NOT NULL,
+		api_id       TEXT,
+		consumer_id  TEXT,
+		kind         TEXT NOT NULL CHECK (kind IN ('slack','teams')),
+		name         TEXT,
+		webhook_url  TEXT NOT NULL,
+		created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		CHECK (api_id IS NOT NULL OR consumer_id IS NOT NULL),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+		FOREIGN KEY (api_id) REFERENCES apis(id) ON DELETE CASCADE,
+		FOREIGN KEY (consumer_id) REFERENCES consumers(id) ON DELETE CASCADE
+	);`,
+	// one row per send attempt to one destination; retries skip destinations already sent
+	`CREATE TABLE IF NOT EXISTS notification_deliveries (
+		id               TEXT PRIMARY KEY,
+		notification_id  TEXT NOT NULL,
+		channel          TEXT NOT NULL,
+		target           TEXT NOT NULL,
+		consumer_id      TEXT,
+		status           TEXT NOT NULL CHECK (status IN ('sent','failed')),
+		error            TEXT,
+		created_at       TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_deliveries_note ON notification_deliveries(notification_id);`,
+	// lifecycle events (api.created, version.deprecated, notification.sent, ...)
+	`CREATE TABLE IF NOT EXISTS events (
+		id          TEXT PRIMARY KEY,
+		org_id      TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		api_id      TEXT,
+		version_id  TEXT,
+		data        TEXT NOT NULL,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_events_org ON events(org_id, created_at);`,
+	// org-configured outbound webhook endpoints and their delivery log
+	`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+		id           TEXT PRIMARY KEY,
+		org_id       TEXT NOT NULL,
+		url          TEXT NOT NULL,
+		secret       TEXT NOT NULL,
+		description  TEXT,
+		events       TEXT NOT NULL DEFAULT '',
+		active       INTEGER NOT NULL DEFAULT 1,
+		created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id             TEXT PRIMARY KEY,
+		endpoint_id    TEXT NOT NULL,
+		event_id       TEXT NOT NULL,
+		event_type     TEXT NOT NULL,
+		payload        TEXT NOT NULL,
+		status         TEXT NOT NULL CHECK (status IN ('pending','delivered','failed')) DEFAULT 'pending',
+		attempts       INTEGER NOT NULL DEFAULT 0,
+		retry_after    TIMESTAMP,
+		response_code  INTEGER,
+		last_error     TEXT,
+		created_at     TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		delivered_at   TIMESTAMP,
+		FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at);`,
+	// per-org notification templates; every save is a new version, the highest one is active
+	`CREATE TABLE IF NOT EXISTS notification_templates (
+		id          TEXT PRIMARY KEY,
+		org_id      TEXT NOT NULL,
+		type        TEXT NOT NULL CHECK (type IN ('deprecate','sunset')),
+		version     INTEGER NOT NULL,
+		subject     TEXT NOT NULL,
+		html        TEXT NOT NULL,
+		text        TEXT,
+		created_by  TEXT,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		UNIQUE (org_id, type, version),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+	// consumer self-service notification preferences (no row = defaults)
+	`CREATE TABLE IF NOT EXISTS consumer_preferences (
+		consumer_id     TEXT PRIMARY KEY,
+		unsubscribed    INTEGER NOT NULL DEFAULT 0,
+		notice_types    TEXT NOT NULL DEFAULT '',
+		digest          TEXT NOT NULL CHECK (digest IN ('immediate','weekly')) DEFAULT 'immediate',
+		last_digest_at  TIMESTAMP,
+		updated_at      TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		FOREIGN KEY (consumer_id) REFERENCES consumers(id) ON DELETE CASCADE
+	);`,
+	// consumer acknowledgements of a deprecated version (acknowledged < migrated)
+	`CREATE TABLE IF NOT EXISTS version_acknowledgements (
+		consumer_id  TEXT NOT NULL,
+		version_id   TEXT NOT NULL,
+		status       TEXT NOT NULL CHECK (status IN ('acknowledged','migrated')),
+		source       TEXT NOT NULL CHECK (source IN ('link','manual')) DEFAULT 'link',
+		created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		updated_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (consumer_id, version_id),
+		FOREIGN KEY (consumer_id) REFERENCES consumers(id) ON DELETE CASCADE,
+		FOREIGN KEY (version_id) REFERENCES api_versions(id) ON DELETE CASCADE
+	);`,
+	// per-member summary email settings (no row = weekly, 30 days ahead)
+	`CREATE TABLE IF NOT EXISTS member_digest_settings (
+		org_id        TEXT NOT NULL,
+		user_id       TEXT NOT NULL,
+		frequency     TEXT NOT NULL CHECK (frequency IN ('off','daily','weekly')) DEFAULT 'weekly',
+		horizon_days  INTEGER NOT NULL DEFAULT 30,
+		last_sent_at  TIMESTAMP,
+		PRIMARY KEY (org_id, user_id),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`,
+	// notices held back for a consumer's weekly digest
+	`CREATE TABLE IF NOT EXISTS consumer_digest_items (
+		consumer_id      TEXT NOT NULL,
+		notification_id  TEXT NOT NULL,
+		created_at       TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		sent_at          TIMESTAMP,
+		PRIMARY KEY (consumer_id, notification_id),
+		FOREIGN KEY (consumer_id) REFERENCES consumers(id) ON DELETE CASCADE,
+		FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE
+	);`,
+	// mail provider events (bounces, complaints, opens); id is the provider's event id
+	`CREATE TABLE IF NOT EXISTS email_events (
+		id               TEXT PRIMARY KEY,
+		org_id           TEXT,
+		notification_id  TEXT,
+		email            TEXT NOT NULL,
+		event            TEXT NOT NULL,
+		reason           TEXT,
+		occurred_at      TEXT NOT NULL,
+		created_at       TIMESTAMP NOT NULL DEFAULT (datetime('now'))
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_email_events_note ON email_events(notification_id);`,
+	// addresses an org no longer mails (hard bounce, spam complaint)
+	`CREATE TABLE IF NOT EXISTS email_suppressions (
+		org_id      TEXT NOT NULL,
+		email       TEXT NOT NULL,
+		reason      TEXT NOT NULL CHECK (reason IN ('bounce','spamreport')),
+		detail      TEXT,
+		created_at  TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (org_id, email),
+		FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE
+	);`,
+}
+
+// baselineColumns were added to existing tables over time (safe add if missing).
+var baselineColumns = []struct{ table, col, decl string }{
 	// Optional nullable columns on apis (safe add if missing)
-	addColumnIfMissing(db, "apis", "base_url", "base_url TEXT")
-	addColumnIfMissing(db, "apis", "docs_url", "docs_url TEXT")
-	addColumnIfMissing(db, "apis", "contact_email", "contact_email TEXT")
-	addColumnIfMissing(db, "apis", "owner_team", "owner_team TEXT")
+	{"apis", "base_url", "base_url TEXT"},
+	{"apis", "docs_url", "docs_url TEXT"},
+	{"apis", "contact_email", "contact_email TEXT"},
+	{"apis", "owner_team", "owner_team TEXT"},
+
+	// Time zones: org default, per-notification zone, consumer zone + quiet hours
+	{"organizations", "timezone", "timezone TEXT NOT NULL DEFAULT 'UTC'"},
+	{"notifications", "timezone", "timezone TEXT"},
+	{"consumers", "timezone", "timezone TEXT"},
+	{"consumers", "quiet_hours_start", "quiet_hours_start TEXT"},
+	{"consumers", "quiet_hours_end", "quiet_hours_end TEXT"},
+
+	// Locales: org default, consumer preference, per-locale templates ('' = neutral)
+	{"organizations", "locale", "locale TEXT NOT NULL DEFAULT 'en'"},
+	{"consumers", "locale", "locale TEXT"},
+	{"notification_templates", "locale", "locale TEXT NOT NULL DEFAULT ''"},
+
+	// What the mail provider reported about each email delivery
+	{"notification_deliveries", "provider_status", "provider_status TEXT"},
+	{"notification_deliveries", "provider_reason", "provider_reason TEXT"},
+	{"notification_deliveries", "provider_updated_at", "provider_updated_at TEXT"},
+	{"notification_deliveries", "opened_at", "opened_at TEXT"},
+
+	// Consumer opted out of one API's notices via the preferences page
+	{"api_consumers", "muted", "muted INTEGER NOT NULL DEFAULT 0"},
+
+	// Opt-in automatic notices on version status / sunset date changes;
+	// dedupe_key keeps repeated edits from enqueuing the same notice twice
+	{"apis", "auto_notify", "auto_notify INTEGER NOT NULL DEFAULT 0"},
+	{"notifications", "dedupe_key", "dedupe_key TEXT"},
+
+	// Public changelog feed opt-in (per API and org-wide)
+	{"apis", "public_feed", "public_feed INTEGER NOT NULL DEFAULT 0"},
+	{"organizations", "public_feed", "public_feed INTEGER NOT NULL DEFAULT 0"},
 
 	// Reliability columns on notifications (safe add if missing)
-	addColumnIfMissing(db, "notifications", "attempts", "attempts INTEGER NOT NULL DEFAULT 0")
-	addColumnIfMissing(db, "notifications", "retry_after", "retry_after TIMESTAMP")
-	addColumnIfMissing(db, "notifications", "last_error", "last_error TEXT")
+	{"notifications", "attempts", "attempts INTEGER NOT NULL DEFAULT 0"},
+	{"notifications", "retry_after", "retry_after TIMESTAMP"},
+	{"notifications", "last_error", "last_error TEXT"},
+}
+
+/* -------------------- 2: personal access tokens -------------------- */
+
+// apiTokensUp adds personal access tokens for scripts and CI. Only a
+// SHA-256 of each token is stored; prefix is its first characters, shown
+// so users can tell their tokens apart.
+func apiTokensUp(m *schemaTx) error {
+	return m.exec(
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id           TEXT PRIMARY KEY,
+			org_id       TEXT NOT NULL,
+			user_id      TEXT NOT NULL,
+			role         TEXT NOT NULL,
+			name         TEXT NOT NULL,
+			prefix       TEXT NOT NULL,
+			token_hash   TEXT NOT NULL UNIQUE,
+			created_at   TIMESTAMP NOT NULL DEFAULT (datetime('now')),
+			expires_at   TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at   TIMESTAMP,
+			FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);`,
+	)
+}
+
+func apiTokensDown(m *schemaTx) error {
+	return m.exec(`DROP TABLE IF EXISTS api_tokens`)
+}
+
+/* -------------------- 3: list indexes -------------------- */
+
+// listIndexes back the filters and sorts of the paged lists (GET /apis,
+// /apis/{id}/versions, /apis/{id}/notifications): each leads with the
+// parent the list is scoped to and ends with id, the keyset tie-breaker.
+// A {column} is a timestamp, indexed as the dialect compares it (ts), so
+// that on SQLite the julianday() ORDER BY is served by the index.
+var listIndexes = []struct{ name, table, cols string }{
+	{"idx_apis_org_created", "apis", "org_id, {created_at}, id"},
+	{"idx_apis_org_name", "apis", "org_id, name, id"},
+	{"idx_apis_org_owner_team", "apis", "org_id, owner_team"},
+	{"idx_api_versions_api_created", "api_versions", "api_id, {created_at}, id"},
+	{"idx_api_versions_api_status", "api_versions", "api_id, status"},
+	{"idx_notifications_api_scheduled", "notifications", "api_id, {scheduled_at}, id"},
+	{"idx_notifications_api_status", "notifications", "api_id, status"},
+}
+
+var tsColumn = regexp.MustCompile(`\{(\w+)\}`)
+
+func listIndexesUp(m *schemaTx) error {
+	for _, ix := range listIndexes {
+		cols := tsColumn.ReplaceAllStringFunc(ix.cols, func(c string) string {
+			return m.tx.ts(c[1 : len(c)-1])
+		})
+		if err := m.exec(`CREATE INDEX IF NOT EXISTS ` + ix.name + ` ON ` + ix.table + `(` + cols + `)`); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// SQLite helper: add column if it does not exist
-func addColumnIfMissing(db *sql.DB, table, col, decl string) {
-	var name string
-	row := db.QueryRow(`SELECT name FROM pragma_table_info('`+table+`') WHERE name = ?`, col)
-	_ = row.Scan(&name)
-	if name == col {
-		return // already exists
+func listIndexesDown(m *schemaTx) error {
+	for _, ix := range listIndexes {
+		if err := m.exec(`DROP INDEX IF EXISTS ` + ix.name); err != nil {
+			return err
+		}
 	}
-	_, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + decl)
-	if err != nil {
-		log.Printf("[migrations] add column failed for %s.%s: %v", table, col, err)
+	return nil
+}
+
+/* -------------------- 4: revisions -------------------- */
+
+// revisionTables get a revision counter for optimistic concurrency
+// (revision.go): bumped by every change, compared against If-Match.
+var revisionTables = []string{"apis", "api_versions", "notifications"}
+
+func revisionsUp(m *schemaTx) error {
+	for _, t := range revisionTables {
+		if err := m.addColumn(t, "revision", "revision INTEGER NOT NULL DEFAULT 1"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func revisionsDown(m *schemaTx) error {
+	for _, t := range revisionTables {
+		if err := m.exec(`ALTER TABLE ` + t + ` DROP COLUMN revision`); err != nil {
+			return err
+		}
 	}
+	return nil
 }
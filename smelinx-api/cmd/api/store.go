@@ -2,24 +2,39 @@ package main
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/google/uuid"
 )
 
-type Store struct{ db *sql.DB }
+type Store struct{ db conn }
 
-func NewStore(db *sql.DB) *Store { return &Store{db: db} }
+func NewStore(db *dbConn) *Store { return &Store{db: db} }
 func newID() string              { return uuid.New().String() }
 
+// inTx calls fn with a Store bound to one transaction and commits it when
+// fn returns nil; any error rolls back everything fn did.
+func (s *Store) inTx(ctx context.Context, fn func(tx *Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&Store{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 type User struct {
 	ID           string
 	Email        string
 	PasswordHash string
 }
 type Org struct {
-	ID   string
-	Name string
+	ID       string
+	Name     string
+	Timezone string // IANA zone used for date-only schedules and rendering
+	Locale   string // default notice language for consumers without one
 }
 
 // Users/Orgs
@@ -40,6 +55,15 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error)
 	}
 	return &u, nil
 }
+func (s *Store) GetUserByID(ctx context.Context, id string) (*User, error) {
+	var u User
+	err := s.db.QueryRowContext(ctx, `SELECT id,email,password_hash FROM users WHERE id = ?`, id).
+		Scan(&u.ID, &u.Email, &u.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
 func (s *Store) CreateOrgWithOwner(ctx context.Context, name, ownerID string) (*Org, error) {
 	oid := newID()
 	if _, err := s.db.ExecContext(ctx, `INSERT INTO organizations (id,name) VALUES (?,?)`, oid, name); err != nil {
@@ -48,17 +72,34 @@ func (s *Store) CreateOrgWithOwner(ctx context.Context, name, ownerID string) (*
 	if _, err := s.db.ExecContext(ctx, `INSERT INTO org_members (org_id,user_id,role) VALUES (?,?,?)`, oid, ownerID, "owner"); err != nil {
 		return nil, err
 	}
-	return &Org{ID: oid, Name: name}, nil
+	return &Org{ID: oid, Name: name, Timezone: "UTC", Locale: defaultLocale}, nil
+}
+func (s *Store) GetOrgByID(ctx context.Context, id string) (*Org, error) {
+	var o Org
+	err := s.db.QueryRowContext(ctx, `SELECT id,name,timezone,locale FROM organizations WHERE id = ?`, id).
+		Scan(&o.ID, &o.Name, &o.Timezone, &o.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
 }
 func (s *Store) GetUserPrimaryOrg(ctx context.Context, uid string) (*Org, error) {
 	var o Org
 	err := s.db.QueryRowContext(ctx, `
-		SELECT o.id,o.name
+		SELECT o.id,o.name,o.timezone,o.locale
 		FROM organizations o
 		JOIN org_members m ON m.org_id=o.id
-		WHERE m.user_id=? LIMIT 1`, uid).Scan(&o.ID, &o.Name)
+		WHERE m.user_id=? LIMIT 1`, uid).Scan(&o.ID, &o.Name, &o.Timezone, &o.Locale)
 	if err != nil {
 		return nil, err
 	}
 	return &o, nil
 }
+func (s *Store) SetOrgTimezone(ctx context.Context, id, tz string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE organizations SET timezone = ? WHERE id = ?`, tz, id)
+	return err
+}
+func (s *Store) SetOrgLocale(ctx context.Context, id, locale string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE organizations SET locale = ? WHERE id = ?`, locale, id)
+	return err
+}
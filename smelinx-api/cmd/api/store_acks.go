@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Acknowledgement records that a consumer read a deprecation ("acknowledged")
+// or finished moving off the version ("migrated").
+type Acknowledgement struct {
+	ConsumerID string    `json:"consumer_id"`
+	VersionID  string    `json:"version_id"`
+	Status     string    `json:"status"` // acknowledged | migrated
+	Source     string    `json:"source"` // link | manual
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func validAckStatus(s string) bool { return s == "acknowledged" || s == "migrated" }
+
+func (s *Store) GetAcknowledgement(ctx context.Context, consumerID, versionID string) (*Acknowledgement, error) {
+	var ack Acknowledgement
+	err := s.db.QueryRowContext(ctx, `
+		SELECT consumer_id, version_id, status, source, created_at, updated_at
+		FROM version_acknowledgements WHERE consumer_id = ? AND version_id = ?`, consumerID, versionID).
+		Scan(&ack.ConsumerID, &ack.VersionID, &ack.Status, &ack.Source, &ack.CreatedAt, &ack.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ack, nil
+}
+
+// RecordAcknowledgement upserts an acknowledgement. A consumer-clicked link
+// never downgrades "migrated" back to "acknowledged"; a manual edit may.
+func (s *Store) RecordAcknowledgement(ctx context.Context, consumerID, versionID, status, source string) (*Acknowledgement, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO version_acknowledgements (consumer_id, version_id, status, source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (consumer_id, version_id) DO UPDATE SET
+			status = CASE
+				WHEN excluded.source = 'link' AND version_acknowledgements.status = 'migrated' THEN 'migrated'
+				ELSE excluded.status END,
+			source     = excluded.source,
+			updated_at = excluded.updated_at`,
+		consumerID, versionID, status, source, now, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAcknowledgement(ctx, consumerID, versionID)
+}
+
+func (s *Store) DeleteAcknowledgement(ctx context.Context, consumerID, versionID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM version_acknowledgements WHERE consumer_id = ? AND version_id = ?`, consumerID, versionID)
+	return err
+}
+
+// ackReportRow is one consumer's line in a version acknowledgement report.
+type ackReportRow struct {
+	ConsumerID     string     `json:"consumer_id"`
+	Name           string     `json:"name"`
+	Email          *string    `json:"email,omitempty"`
+	Subscribed     bool       `json:"subscribed"`
+	Status         string     `json:"status"` // acknowledged | migrated | outstanding
+	Source         *string    `json:"source,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// ListVersionAcknowledgements reports every consumer subscribed to the
+// version's API, plus any who acknowledged before unsubscribing.
+func (s *Store) ListVersionAcknowledgements(ctx context.Context, apiID, versionID string) ([]ackReportRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.name, c.email,
+		       EXISTS (SELECT 1 FROM api_consumers ac WHERE ac.api_id = ? AND ac.consumer_id = c.id),
+		       COALESCE(k.status, 'outstanding'), k.source, k.updated_at
+		FROM consumers c
+		LEFT JOIN version_acknowledgements k ON k.consumer_id = c.id AND k.version_id = ?
+		WHERE c.id IN (SELECT consumer_id FROM api_consumers WHERE api_id = ?)
+		   OR k.consumer_id IS NOT NULL
+		ORDER BY c.name ASC`, apiID, versionID, apiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ackReportRow{}
+	for rows.Next() {
+		var row ackReportRow
+		var email, source sql.NullString
+		var at sql.NullTime
+		if err := rows.Scan(&row.ConsumerID, &row.Name, &email, &row.Subscribed, &row.Status, &source, &at); err != nil {
+			return nil, err
+		}
+		if email.Valid {
+			row.Email = &email.String
+		}
+		if source.Valid {
+			row.Source = &source.String
+		}
+		if at.Valid {
+			row.AcknowledgedAt = &at.Time
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
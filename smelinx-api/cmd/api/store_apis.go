@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -17,15 +18,68 @@ type API struct {
 	ContactEmail *string    `json:"contact_email,omitempty"`
 	OwnerTeam    *string    `json:"owner_team,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
+	Revision     int64      `json:"revision"` // bumped on every change; the ETag
 	DeletedAt    *time.Time `json:"-"`
 }
 
-func (s *Store) ListAPIs(ctx context.Context, orgID string) ([]API, error) {
+// APIAutoNotify reports whether version changes of the API enqueue notices.
+func (s *Store) APIAutoNotify(ctx context.Context, apiID string) (bool, error) {
+	var on bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT auto_notify FROM apis WHERE id = ? AND deleted_at IS NULL`, apiID).Scan(&on)
+	return on, err
+}
+
+func (s *Store) SetAPIAutoNotify(ctx context.Context, apiID string, on bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE apis SET auto_notify = ? WHERE id = ?`, on, apiID)
+	return err
+}
+
+// apiQuery filters and pages ListAPIs; the zero value lists every API,
+// newest first.
+type apiQuery struct {
+	pageQuery
+	Search    string // case-insensitive substring of the name
+	OwnerTeam string
+}
+
+var apiSorts = map[string]sortField{
+	"created_at": {col: "created_at", time: true},
+	"name":       {col: "name"},
+}
+
+func (a API) cursorID() string { return a.ID }
+
+func (a API) cursorKey(field string) string {
+	if field == "name" {
+		return a.Name
+	}
+	return cursorTime(a.CreatedAt)
+}
+
+func (s *Store) ListAPIs(ctx context.Context, orgID string, q apiQuery) ([]API, error) {
+	if q.Field == "" {
+		q.Field, q.Desc = "created_at", true
+	}
+	where, args := []string{"org_id = ?", "deleted_at IS NULL"}, []any{orgID}
+	if q.Search != "" {
+		where = append(where, `LOWER(name) LIKE ? ESCAPE '\'`)
+		args = append(args, likeContains(q.Search))
+	}
+	if q.OwnerTeam != "" {
+		where = append(where, "owner_team = ?")
+		args = append(args, q.OwnerTeam)
+	}
+	after, afterArgs, order := s.keyset("", apiSorts, q.pageQuery)
+	if after != "" {
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, org_id, name, COALESCE(description,''), base_url, docs_url, contact_email, owner_team, created_at, deleted_at
+		SELECT id, org_id, name, COALESCE(description,''), base_url, docs_url, contact_email, owner_team, created_at, revision, deleted_at
 		FROM apis
-		WHERE org_id = ? AND deleted_at IS NULL
-		ORDER BY datetime(created_at) DESC`, orgID)
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+q.limitClause(), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +93,7 @@ func (s *Store) ListAPIs(ctx context.Context, orgID string) ([]API, error) {
 		if err := rows.Scan(
 			&a.ID, &a.OrgID, &a.Name, &a.Description,
 			&baseURL, &docsURL, &contactEmail, &ownerTeam,
-			&a.CreatedAt, &deletedAt,
+			&a.CreatedAt, &a.Revision, &deletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -100,12 +154,12 @@ func (s *Store) GetAPIByID(ctx context.Context, id string) (*API, error) {
 	var deletedAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, org_id, name, COALESCE(description,''), base_url, docs_url, contact_email, owner_team, created_at, deleted_at
+		SELECT id, org_id, name, COALESCE(description,''), base_url, docs_url, contact_email, owner_team, created_at, revision, deleted_at
 		FROM apis
 		WHERE id = ?`, id).
 		Scan(&a.ID, &a.OrgID, &a.Name, &a.Description,
 			&baseURL, &docsURL, &contactEmail, &ownerTeam,
-			&a.CreatedAt, &deletedAt)
+			&a.CreatedAt, &a.Revision, &deletedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -127,27 +181,40 @@ func (s *Store) GetAPIByID(ctx context.Context, id string) (*API, error) {
 	return &a, nil
 }
 
-func (s *Store) UpdateAPI(ctx context.Context, id, name, desc string, meta *APIMeta) (*API, error) {
-	_, err := s.db.ExecContext(ctx, `
+// UpdateAPI saves the API if it is still at revision rev (0: whatever it
+// is at), else returns errStaleRevision.
+func (s *Store) UpdateAPI(ctx context.Context, id, name, desc string, meta *APIMeta, rev int64) (*API, error) {
+	check, checkArgs := revisionCheck(rev)
+	res, err := s.db.ExecContext(ctx, `
 		UPDATE apis
-		SET name = ?, description = ?, base_url = ?, docs_url = ?, contact_email = ?, owner_team = ?
-		WHERE id = ? AND deleted_at IS NULL`,
-		name, desc,
-		nullable(meta, func(m *APIMeta) any { return m.BaseURL }),
-		nullable(meta, func(m *APIMeta) any { return m.DocsURL }),
-		nullable(meta, func(m *APIMeta) any { return m.ContactEmail }),
-		nullable(meta, func(m *APIMeta) any { return m.OwnerTeam }),
-		id,
+		SET name = ?, description = ?, base_url = ?, docs_url = ?, contact_email = ?, owner_team = ?,
+		    revision = revision + 1
+		WHERE id = ? AND deleted_at IS NULL`+check,
+		append([]any{name, desc,
+			nullable(meta, func(m *APIMeta) any { return m.BaseURL }),
+			nullable(meta, func(m *APIMeta) any { return m.DocsURL }),
+			nullable(meta, func(m *APIMeta) any { return m.ContactEmail }),
+			nullable(meta, func(m *APIMeta) any { return m.OwnerTeam }),
+			id,
+		}, checkArgs...)...,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := staleRevision(res, rev); err != nil {
+		return nil, err
+	}
 	return s.GetAPIByID(ctx, id)
 }
 
 // Soft delete (keeps history; versions cascade via FK only if hard delete — so we keep soft here)
-func (s *Store) DeleteAPI(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE apis SET deleted_at = datetime('now') WHERE id = ? AND deleted_at IS NULL`, id)
-	return err
+func (s *Store) DeleteAPI(ctx context.Context, id string, rev int64) error {
+	check, checkArgs := revisionCheck(rev)
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE apis SET deleted_at = ?, revision = revision + 1 WHERE id = ? AND deleted_at IS NULL`+check,
+		append([]any{time.Now().UTC().Format(time.RFC3339), id}, checkArgs...)...)
+	if err != nil {
+		return err
+	}
+	return staleRevision(res, rev)
 }
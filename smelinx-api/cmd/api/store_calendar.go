@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// calendarEntry is one dated lifecycle milestone for the calendar feed.
+type calendarEntry struct {
+	Kind      string // sunset | deprecation
+	RefID     string // version id (sunset) or notification id (deprecation)
+	APIID     string
+	APIName   string
+	VersionID string
+	Version   string
+	Date      time.Time
+	DocsURL   string
+}
+
+// ListCalendarEntries returns upcoming sunsets (from api_versions.sunset_date)
+// and scheduled deprecation notices for an org. When consumerID is set, only
+// APIs that consumer is subscribed to are included.
+func (s *Store) ListCalendarEntries(ctx context.Context, orgID, consumerID string) ([]calendarEntry, error) {
+	scope := ``
+	args := []any{orgID}
+	if consumerID != "" {
+		scope = ` AND a.id IN (SELECT api_id FROM api_consumers WHERE consumer_id = ?)`
+		args = append(args, consumerID)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var out []calendarEntry
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT v.id, a.id, a.name, v.version, v.sunset_date, a.docs_url
+		FROM api_versions v
+		JOIN apis a ON a.id = v.api_id
+		WHERE a.org_id = ? AND a.deleted_at IS NULL AND v.deleted_at IS NULL
+		  AND v.sunset_date IS NOT NULL`+scope, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e calendarEntry
+		var docs sql.NullString
+		if err := rows.Scan(&e.VersionID, &e.APIID, &e.APIName, &e.Version, &e.Date, &docs); err != nil {
+			return nil, err
+		}
+		if e.Date.Before(today) {
+			continue
+		}
+		e.Kind, e.RefID, e.DocsURL = "sunset", e.VersionID, docs.String
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	nrows, err := s.db.QueryContext(ctx, `
+		SELECT n.id, a.id, a.name, v.id, v.version, n.scheduled_at, a.docs_url
+		FROM notifications n
+		JOIN apis a ON a.id = n.api_id
+		JOIN api_versions v ON v.id = n.version_id
+		WHERE a.org_id = ? AND a.deleted_at IS NULL AND v.deleted_at IS NULL
+		  AND n.type = 'deprecate' AND n.status IN ('pending','sent')`+scope, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer nrows.Close()
+	for nrows.Next() {
+		var e calendarEntry
+		var docs sql.NullString
+		if err := nrows.Scan(&e.RefID, &e.APIID, &e.APIName, &e.VersionID, &e.Version, &e.Date, &docs); err != nil {
+			return nil, err
+		}
+		if e.Date.Before(today) {
+			continue
+		}
+		e.Kind, e.DocsURL = "deprecation", docs.String
+		out = append(out, e)
+	}
+	return out, nrows.Err()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NotificationChannel is a chat destination (Slack or Teams incoming webhook)
+// attached either to one API or to one consumer.
+type NotificationChannel struct {
+	ID         string    `json:"id"`
+	OrgID      string    `json:"org_id"`
+	APIID      *string   `json:"api_id,omitempty"`
+	ConsumerID *string   `json:"consumer_id,omitempty"`
+	Kind       string    `json:"kind"` // slack | teams
+	Name       string    `json:"name"`
+	WebhookURL string    `json:"webhook_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const channelCols = `id, org_id, api_id, consumer_id, kind, COALESCE(name,''), webhook_url, created_at`
+
+func scanChannel(sc interface{ Scan(...any) error }) (*NotificationChannel, error) {
+	var c NotificationChannel
+	var apiID, consumerID sql.NullString
+	if err := sc.Scan(&c.ID, &c.OrgID, &apiID, &consumerID, &c.Kind, &c.Name, &c.WebhookURL, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	if apiID.Valid {
+		c.APIID = &apiID.String
+	}
+	if consumerID.Valid {
+		c.ConsumerID = &consumerID.String
+	}
+	return &c, nil
+}
+
+func (s *Store) queryChannels(ctx context.Context, where string, args ...any) ([]NotificationChannel, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+channelCols+`
+		FROM notification_channels
+		WHERE `+where+`
+		ORDER BY created_at ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []NotificationChannel
+	for rows.Next() {
+		c, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListAPIChannels(ctx context.Context, apiID string) ([]NotificationChannel, error) {
+	return s.queryChannels(ctx, `api_id = ?`, apiID)
+}
+
+func (s *Store) ListConsumerChannels(ctx context.Context, consumerID string) ([]NotificationChannel, error) {
+	return s.queryChannels(ctx, `consumer_id = ?`, consumerID)
+}
+
+// CreateChannel stores a channel; exactly one of apiID/consumerID is expected.
+func (s *Store) CreateChannel(ctx context.Context, orgID string, apiID, consumerID *string, kind, name, webhookURL string) (*NotificationChannel, error) {
+	id := newID()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_channels (id, org_id, api_id, consumer_id, kind, name, webhook_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		id, orgID, apiID, consumerID, kind, name, webhookURL); err != nil {
+		return nil, err
+	}
+	return s.GetChannelByID(ctx, id)
+}
+
+func (s *Store) GetChannelByID(ctx context.Context, id string) (*NotificationChannel, error) {
+	return scanChannel(s.db.QueryRowContext(ctx, `
+		SELECT `+channelCols+` FROM notification_channels WHERE id = ?`, id))
+}
+
+func (s *Store) DeleteChannel(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM notification_channels WHERE id = ?`, id)
+	return err
+}
+
+/* -------------------- dispatcher support -------------------- */
+
+// recipient is one destination a due notification fans out to.
+type recipient struct {
+	Channel    string // email | slack | teams
+	Target     string // email address or webhook URL
+	ConsumerID string // empty for the API contact / API-level channels
+	Name       string
+	Timezone   string // consumer's zone, if set
+	QuietStart string // consumer's quiet hours (HH:MM), if set
+	QuietEnd   string
+	Locale     string // consumer's locale, if set
+}
+
+// consumerRecipient fills the consumer-level fields of a recipient.
+func consumerRecipient(r recipient, c *Consumer) recipient {
+	r.ConsumerID, r.Name = c.ID, firstNonEmpty(r.Name, c.Name)
+	if c.Timezone != nil {
+		r.Timezone = *c.Timezone
+	}
+	if c.QuietHoursStart != nil && c.QuietHoursEnd != nil {
+		r.QuietStart, r.QuietEnd = *c.QuietHoursStart, *c.QuietHoursEnd
+	}
+	if c.Locale != nil {
+		r.Locale = *c.Locale
+	}
+	return r
+}
+
+// ListNotificationRecipients resolves every destination for a due notification:
+// the API contact email, subscribed consumers with an email, API-level chat
+// channels and the chat channels of subscribed consumers.
+func (s *Store) ListNotificationRecipients(ctx context.Context, d dueNotification, fallbackTo string) ([]recipient, error) {
+	var out []recipient
+	if to := firstNonEmpty(d.ContactEmail.String, fallbackTo); to != "" {
+		out = append(out, recipient{Channel: "email", Target: to})
+	}
+
+	consumers, err := s.ListAPIConsumers(ctx, d.APIID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Consumer, len(consumers))
+	for i := range consumers {
+		c := &consumers[i]
+		byID[c.ID] = c
+		if c.Email != nil {
+			out = append(out, consumerRecipient(recipient{Channel: "email", Target: *c.Email}, c))
+		}
+	}
+
+	chans, err := s.queryChannels(ctx, `
+		api_id = ?
+		OR consumer_id IN (SELECT consumer_id FROM api_consumers WHERE api_id = ?)`, d.APIID, d.APIID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chans {
+		r := recipient{Channel: c.Kind, Target: c.WebhookURL, Name: c.Name}
+		if c.ConsumerID != nil {
+			if cons, ok := byID[*c.ConsumerID]; ok {
+				r = consumerRecipient(r, cons)
+			} else {
+				r.ConsumerID = *c.ConsumerID
+			}
+		}
+		out = append(out, r)
+	}
+	return dedupeRecipients(out), nil
+}
+
+func dedupeRecipients(in []recipient) []recipient {
+	seen := make(map[string]bool, len(in))
+	out := in[:0]
+	for _, r := range in {
+		k := r.Channel + "|" + r.Target
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, r)
+	}
+	return out
+}
+
+// SentDeliveryTargets returns the "channel|target" keys already delivered for a
+// notification, so a retry only re-sends to destinations that failed.
+func (s *Store) SentDeliveryTargets(ctx context.Context, noteID string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT channel, target FROM notification_deliveries
+		WHERE notification_id = ? AND status = 'sent'`, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]bool{}
+	for rows.Next() {
+		var ch, target string
+		if err := rows.Scan(&ch, &target); err != nil {
+			return nil, err
+		}
+		out[ch+"|"+target] = true
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordDelivery(ctx context.Context, noteID string, to recipient, status, errMsg string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (id, notification_id, channel, target, consumer_id, status, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		newID(), noteID, to.Channel, to.Target, nullIfEmpty(to.ConsumerID), status, nullIfEmpty(errMsg))
+	return err
+}
+
+// RecordDeliveryOnce is RecordDelivery for outcomes that repeat on every
+// retry (a suppressed address): it adds nothing if the notification already
+// has the same outcome for the target.
+func (s *Store) RecordDeliveryOnce(ctx context.Context, noteID string, to recipient, status, errMsg string) error {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notification_deliveries
+		WHERE notification_id = ? AND channel = ? AND target = ? AND status = ? AND COALESCE(error, '') = ?`,
+		noteID, to.Channel, to.Target, status, errMsg).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return s.RecordDelivery(ctx, noteID, to, status, errMsg)
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Consumer is someone (a team, a customer) who depends on one or more of an
+// org's APIs and should hear about their deprecations.
+type Consumer struct {
+	ID       string  `json:"id"`
+	OrgID    string  `json:"org_id"`
+	Name     string  `json:"name"`
+	Email    *string `json:"email,omitempty"`
+	Timezone *string `json:"timezone,omitempty"`
+	// Quiet hours are a daily HH:MM window in Timezone during which
+	// deliveries to this consumer are held back.
+	QuietHoursStart *string   `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string   `json:"quiet_hours_end,omitempty"`
+	Locale          *string   `json:"locale,omitempty"` // notice language; org default when unset
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// consumerFields are the user-editable consumer columns.
+type consumerFields struct {
+	Name            string
+	Email           *string
+	Timezone        *string
+	QuietHoursStart *string
+	QuietHoursEnd   *string
+	Locale          *string
+}
+
+const consumerCols = `id, org_id, name, email, timezone, quiet_hours_start, quiet_hours_end, locale, created_at`
+
+func scanConsumer(sc interface{ Scan(...any) error }) (*Consumer, error) {
+	var c Consumer
+	var email, tz, qs, qe, locale sql.NullString
+	if err := sc.Scan(&c.ID, &c.OrgID, &c.Name, &email, &tz, &qs, &qe, &locale, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.Email = nonEmptyPtr(email)
+	c.Timezone = nonEmptyPtr(tz)
+	c.QuietHoursStart = nonEmptyPtr(qs)
+	c.QuietHoursEnd = nonEmptyPtr(qe)
+	c.Locale = nonEmptyPtr(locale)
+	return &c, nil
+}
+
+func nonEmptyPtr(ns sql.NullString) *string {
+	if !ns.Valid || ns.String == "" {
+		return nil
+	}
+	return &ns.String
+}
+
+func (s *Store) ListConsumers(ctx context.Context, orgID string) ([]Consumer, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+consumerCols+`
+		FROM consumers
+		WHERE org_id = ?
+		ORDER BY name ASC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Consumer
+	for rows.Next() {
+		c, err := scanConsumer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) CreateConsumer(ctx context.Context, orgID string, f consumerFields) (*Consumer, error) {
+	id := newID()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO consumers (id, org_id, name, email, timezone, quiet_hours_start, quiet_hours_end, locale)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, orgID, f.Name, f.Email, f.Timezone, f.QuietHoursStart, f.QuietHoursEnd, f.Locale); err != nil {
+		return nil, err
+	}
+	return s.GetConsumerByID(ctx, id)
+}
+
+func (s *Store) GetConsumerByID(ctx context.Context, id string) (*Consumer, error) {
+	return scanConsumer(s.db.QueryRowContext(ctx, `
+		SELECT `+consumerCols+` FROM consumers WHERE id = ?`, id))
+}
+
+func (s *Store) UpdateConsumer(ctx context.Context, id string, f consumerFields) (*Consumer, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE consumers
+		SET name = ?, email = ?, timezone = ?, quiet_hours_start = ?, quiet_hours_end = ?, locale = ?
+		WHERE id = ?`,
+		f.Name, f.Email, f.Timezone, f.QuietHoursStart, f.QuietHoursEnd, f.Locale, id); err != nil {
+		return nil, err
+	}
+	return s.GetConsumerByID(ctx, id)
+}
+
+// DeleteConsumer removes a consumer with its subscriptions and chat channels.
+func (s *Store) DeleteConsumer(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM api_consumers WHERE consumer_id = ?`,
+		`DELETE FROM notification_channels WHERE consumer_id = ?`,
+		`DELETE FROM consumer_preferences WHERE consumer_id = ?`,
+		`DELETE FROM consumer_digest_items WHERE consumer_id = ?`,
+		`DELETE FROM version_acknowledgements WHERE consumer_id = ?`,
+		`DELETE FROM consumers WHERE id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+/* -------------------- API subscriptions -------------------- */
+
+// ListAPIConsumers returns the consumers subscribed to one API.
+func (s *Store) ListAPIConsumers(ctx context.Context, apiID string) ([]Consumer, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.org_id, c.name, c.email, c.timezone, c.quiet_hours_start, c.quiet_hours_end, c.locale, c.created_at
+		FROM consumers c
+		JOIN api_consumers ac ON ac.consumer_id = c.id
+		WHERE ac.api_id = ?
+		ORDER BY c.name ASC`, apiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Consumer
+	for rows.Next() {
+		c, err := scanConsumer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) SubscribeConsumer(ctx context.Context, apiID, consumerID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_consumers (api_id, consumer_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING`, apiID, consumerID)
+	return err
+}
+
+func (s *Store) UnsubscribeConsumer(ctx context.Context, apiID, consumerID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM api_consumers WHERE api_id = ? AND consumer_id = ?`, apiID, consumerID)
+	return err
+}
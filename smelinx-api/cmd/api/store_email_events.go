@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// emailEvent is one delivery event reported by the mail provider.
+type emailEvent struct {
+	ID             string // provider event id, used for de-duplication
+	OrgID          string
+	NotificationID string
+	Email          string
+	Event          string // delivered | bounce | dropped | spamreport | open | ...
+	Reason         string
+	OccurredAt     time.Time
+}
+
+// RecordEmailEvent stores e once; it reports false for an event already seen.
+func (s *Store) RecordEmailEvent(ctx context.Context, e emailEvent) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_events (id, org_id, notification_id, email, event, reason, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		e.ID, nullIfEmpty(e.OrgID), nullIfEmpty(e.NotificationID), strings.ToLower(e.Email), e.Event,
+		nullIfEmpty(e.Reason), e.OccurredAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// providerStatusRank orders provider statuses so a late "delivered" never
+// overwrites an earlier bounce.
+var providerStatusRank = map[string]int{
+	"processed": 1, "deferred": 2, "delivered": 3, "bounce": 4, "dropped": 4, "spamreport": 5,
+}
+
+// ApplyDeliveryEvent updates the latest email delivery record of a
+// notification for one address. Bounces and drops mark it failed.
+func (s *Store) ApplyDeliveryEvent(ctx context.Context, noteID, email, status, reason string, at time.Time) error {
+	var id string
+	var current sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, provider_status FROM notification_deliveries
+		WHERE notification_id = ? AND channel = 'email' AND lower(target) = lower(?)
+		ORDER BY created_at DESC, `+s.db.insertOrder("")+` DESC
+		LIMIT 1`, noteID, email).Scan(&id, &current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // not one of ours (or already pruned)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == "open" {
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE notification_deliveries SET opened_at = COALESCE(opened_at, ?) WHERE id = ?`,
+			at.UTC().Format(time.RFC3339), id)
+		return err
+	}
+	if providerStatusRank[status] < providerStatusRank[current.String] {
+		return nil
+	}
+	q := `UPDATE notification_deliveries
+		SET provider_status = ?, provider_reason = ?, provider_updated_at = ?`
+	args := []any{status, nullIfEmpty(reason), at.UTC().Format(time.RFC3339)}
+	if status == "bounce" || status == "dropped" {
+		q += `, status = 'failed', error = ?`
+		args = append(args, truncate(strings.TrimSpace(status+": "+reason), 500))
+	}
+	_, err = s.db.ExecContext(ctx, q+` WHERE id = ?`, append(args, id)...)
+	return err
+}
+
+/* -------------------- suppressions -------------------- */
+
+// EmailSuppression is an address the org no longer mails because it
+// hard-bounced or reported a notice as spam.
+type EmailSuppression struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"` // bounce | spamreport
+	Detail    *string   `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuppressEmail adds (or refreshes) a suppression for the org.
+func (s *Store) SuppressEmail(ctx context.Context, orgID, email, reason, detail string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_suppressions (org_id, email, reason, detail)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (org_id, email) DO UPDATE SET reason = excluded.reason, detail = excluded.detail`,
+		orgID, strings.ToLower(strings.TrimSpace(email)), reason, nullIfEmpty(detail))
+	return err
+}
+
+// GetEmailSuppression returns the org's suppression for email, or sql.ErrNoRows.
+func (s *Store) GetEmailSuppression(ctx context.Context, orgID, email string) (*EmailSuppression, error) {
+	var e EmailSuppression
+	var detail sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT email, reason, detail, created_at FROM email_suppressions
+		WHERE org_id = ? AND email = ?`, orgID, strings.ToLower(strings.TrimSpace(email))).
+		Scan(&e.Email, &e.Reason, &detail, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if detail.Valid {
+		e.Detail = &detail.String
+	}
+	return &e, nil
+}
+
+func (s *Store) ListEmailSuppressions(ctx context.Context, orgID string) ([]EmailSuppression, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT email, reason, detail, created_at FROM email_suppressions
+		WHERE org_id = ?
+		ORDER BY created_at DESC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []EmailSuppression{}
+	for rows.Next() {
+		var e EmailSuppression
+		var detail sql.NullString
+		if err := rows.Scan(&e.Email, &e.Reason, &detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if detail.Valid {
+			e.Detail = &detail.String
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// DeleteEmailSuppression lifts a suppression; it reports whether one existed.
+func (s *Store) DeleteEmailSuppression(ctx context.Context, orgID, email string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM email_suppressions WHERE org_id = ? AND email = ?`,
+		orgID, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+/* -------------------- delivery log -------------------- */
+
+// NotificationDelivery is one attempt to deliver a notification to one
+// destination, with what the provider later reported about it.
+type NotificationDelivery struct {
+	ID                string     `json:"id"`
+	Channel           string     `json:"channel"`
+	Target            string     `json:"target"`
+	ConsumerID        *string    `json:"consumer_id,omitempty"`
+	Status            string     `json:"status"` // sent | failed
+	Error             *string    `json:"error,omitempty"`
+	ProviderStatus    *string    `json:"provider_status,omitempty"`
+	ProviderReason    *string    `json:"provider_reason,omitempty"`
+	ProviderUpdatedAt *time.Time `json:"provider_updated_at,omitempty"`
+	OpenedAt          *time.Time `json:"opened_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func (s *Store) ListNotificationDeliveries(ctx context.Context, noteID string) ([]NotificationDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, channel, target, consumer_id, status, error,
+		       provider_status, provider_reason, provider_updated_at, opened_at, created_at
+		FROM notification_deliveries
+		WHERE notification_id = ?
+		ORDER BY created_at DESC, `+s.db.insertOrder("")+` DESC`, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []NotificationDelivery{}
+	for rows.Next() {
+		var d NotificationDelivery
+		var consumerID, errMsg, pStatus, pReason, pAt, opened sql.NullString
+		if err := rows.Scan(&d.ID, &d.Channel, &d.Target, &consumerID, &d.Status, &errMsg,
+			&pStatus, &pReason, &pAt, &opened, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.ConsumerID = nonEmptyPtr(consumerID)
+		d.Error = nonEmptyPtr(errMsg)
+		d.ProviderStatus = nonEmptyPtr(pStatus)
+		d.ProviderReason = nonEmptyPtr(pReason)
+		d.ProviderUpdatedAt = parseTimePtr(pAt)
+		d.OpenedAt = parseTimePtr(opened)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func parseTimePtr(ns sql.NullString) *time.Time {
+	if !ns.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, ns.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Event is an immutable record of something that happened in an org
+// (api.created, version.deprecated, notification.sent, ...). Events are fanned
+// out to the org's webhook endpoints as they are recorded.
+type Event struct {
+	ID        string          `json:"id"`
+	OrgID     string          `json:"org_id"`
+	Type      string          `json:"type"`
+	APIID     *string         `json:"api_id,omitempty"`
+	VersionID *string         `json:"version_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// eventTypes lists every event type an endpoint can subscribe to.
+var eventTypes = []string{
+	"api.created", "api.updated", "api.deleted",
+	"version.created", "version.updated", "version.deprecated", "version.sunset", "version.deleted",
+	"version.acknowledged",
+	"notification.scheduled", "notification.canceled", "notification.sent", "notification.failed",
+	"email.bounced", "email.complained",
+}
+
+// RecordEvent stores an event and queues a delivery for every active endpoint
+// of the org subscribed to its type.
+func (s *Store) RecordEvent(ctx context.Context, orgID, typ, apiID, versionID string, data any) (*Event, error) {
+	ev, err := newEvent(orgID, typ, apiID, versionID, data)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := s.ListWebhookEndpoints(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertEvent(ctx, tx, ev); err != nil {
+		return nil, err
+	}
+	for _, ep := range endpoints {
+		if !ep.Active || !ep.Subscribed(typ) {
+			continue
+		}
+		if err := insertWebhookDelivery(ctx, tx, ep.ID, ev); err != nil {
+			return nil, err
+		}
+	}
+	return ev, tx.Commit()
+}
+
+// RecordTestEvent stores a "webhook.test" event without fanning it out; the
+// caller queues it for the one endpoint being tested.
+func (s *Store) RecordTestEvent(ctx context.Context, orgID string, data any) (*Event, error) {
+	ev, err := newEvent(orgID, "webhook.test", "", "", data)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := insertEvent(ctx, tx, ev); err != nil {
+		return nil, err
+	}
+	return ev, tx.Commit()
+}
+
+func newEvent(orgID, typ, apiID, versionID string, data any) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	ev := &Event{
+		ID:        newID(),
+		OrgID:     orgID,
+		Type:      typ,
+		Data:      raw,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if apiID != "" {
+		ev.APIID = &apiID
+	}
+	if versionID != "" {
+		ev.VersionID = &versionID
+	}
+	return ev, nil
+}
+
+func insertEvent(ctx context.Context, tx *txConn, ev *Event) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO events (id, org_id, type, api_id, version_id, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		ev.ID, ev.OrgID, ev.Type, ev.APIID, ev.VersionID, string(ev.Data), ev.CreatedAt.Format(time.RFC3339))
+	return err
+}
+
+// emitEvent records an event and only logs on failure: losing a webhook must
+// never fail the request that caused it.
+func (s *Store) emitEvent(ctx context.Context, orgID, typ, apiID, versionID string, data any) {
+	if _, err := s.RecordEvent(ctx, orgID, typ, apiID, versionID, data); err != nil {
+		log.Printf("[events] record %s failed (org=%s): %v", typ, orgID, err)
+	}
+}
+
+func (s *Store) GetEventByID(ctx context.Context, id string) (*Event, error) {
+	var ev Event
+	var apiID, versionID sql.NullString
+	var data string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, org_id, type, api_id, version_id, data, created_at
+		FROM events WHERE id = ?`, id).
+		Scan(&ev.ID, &ev.OrgID, &ev.Type, &apiID, &versionID, &data, &ev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if apiID.Valid {
+		ev.APIID = &apiID.String
+	}
+	if versionID.Valid {
+		ev.VersionID = &versionID.String
+	}
+	ev.Data = json.RawMessage(data)
+	return &ev, nil
+}
+
+// eventEnvelope is the JSON body POSTed to webhook endpoints.
+func eventEnvelope(ev *Event) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":         ev.ID,
+		"type":       ev.Type,
+		"org_id":     ev.OrgID,
+		"created_at": ev.CreatedAt.UTC().Format(time.RFC3339),
+		"data":       ev.Data,
+	})
+}
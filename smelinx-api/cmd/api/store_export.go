@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Org export / import.
+
+An export is a self-contained bundle of an org's APIs (with their versions),
+consumers (with subscriptions and preferences), scheduled notifications and
+notification templates. IDs in a bundle are only references between its own
+items: an import creates everything under new IDs and returns the mapping.
+
+An import runs in one transaction. Items that already exist in the target
+org (same API name, same consumer email, ...) are conflicts: by default the
+import is refused and the conflicts are reported; with skipConflicts the
+existing item is kept and the bundle's references point at it. A dry run
+performs the whole import and rolls it back, so its report is exact.
+*/
+
+const (
+	bundleFormat = "smelinx.org-export"
+	bundleSchema = 1
+)
+
+type OrgBundle struct {
+	Format        string               `json:"format" yaml:"format"`
+	Version       int                  `json:"version" yaml:"version"`
+	ExportedAt    time.Time            `json:"exported_at" yaml:"exported_at"`
+	Org           bundleOrg            `json:"org" yaml:"org"`
+	APIs          []bundleAPI          `json:"apis" yaml:"apis"`
+	Consumers     []bundleConsumer     `json:"consumers" yaml:"consumers"`
+	Notifications []bundleNotification `json:"notifications" yaml:"notifications"`
+	Templates     []bundleTemplate     `json:"templates" yaml:"templates"`
+}
+
+type bundleOrg struct {
+	Name       string `json:"name" yaml:"name"`
+	Timezone   string `json:"timezone" yaml:"timezone"`
+	Locale     string `json:"locale" yaml:"locale"`
+	PublicFeed bool   `json:"public_feed" yaml:"public_feed"`
+}
+
+type bundleAPI struct {
+	ID           string          `json:"id" yaml:"id"`
+	Name         string          `json:"name" yaml:"name"`
+	Description  string          `json:"description,omitempty" yaml:"description,omitempty"`
+	BaseURL      *string         `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	DocsURL      *string         `json:"docs_url,omitempty" yaml:"docs_url,omitempty"`
+	ContactEmail *string         `json:"contact_email,omitempty" yaml:"contact_email,omitempty"`
+	OwnerTeam    *string         `json:"owner_team,omitempty" yaml:"owner_team,omitempty"`
+	AutoNotify   bool            `json:"auto_notify" yaml:"auto_notify"`
+	PublicFeed   bool            `json:"public_feed" yaml:"public_feed"`
+	Versions     []bundleVersion `json:"versions" yaml:"versions"`
+}
+
+type bundleVersion struct {
+	ID         string `json:"id" yaml:"id"`
+	Version    string `json:"version" yaml:"version"`
+	Status     string `json:"status" yaml:"status"`
+	SunsetDate string `json:"sunset_date,omitempty" yaml:"sunset_date,omitempty"` // YYYY-MM-DD
+}
+
+type bundleConsumer struct {
+	ID              string               `json:"id" yaml:"id"`
+	Name            string               `json:"name" yaml:"name"`
+	Email           *string              `json:"email,omitempty" yaml:"email,omitempty"`
+	Timezone        *string              `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	QuietHoursStart *string              `json:"quiet_hours_start,omitempty" yaml:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string              `json:"quiet_hours_end,omitempty" yaml:"quiet_hours_end,omitempty"`
+	Locale          *string              `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Subscriptions   []bundleSubscription `json:"subscriptions" yaml:"subscriptions"`
+	Preferences     *bundlePreferences   `json:"preferences,omitempty" yaml:"preferences,omitempty"`
+}
+
+type bundleSubscription struct {
+	APIID string `json:"api_id" yaml:"api_id"`
+	Muted bool   `json:"muted,omitempty" yaml:"muted,omitempty"`
+}
+
+type bundlePreferences struct {
+	Unsubscribed bool     `json:"unsubscribed" yaml:"unsubscribed"`
+	NoticeTypes  []string `json:"notice_types,omitempty" yaml:"notice_types,omitempty"`
+	Digest       string   `json:"digest" yaml:"digest"`
+}
+
+type bundleNotification struct {
+	ID          string    `json:"id" yaml:"id"`
+	APIID       string    `json:"api_id" yaml:"api_id"`
+	VersionID   string    `json:"version_id" yaml:"version_id"`
+	Type        string    `json:"type" yaml:"type"`
+	ScheduledAt time.Time `json:"scheduled_at" yaml:"scheduled_at"`
+	Timezone    string    `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Status      string    `json:"status" yaml:"status"`
+}
+
+// bundleTemplate is one stored template version; a bundle lists them oldest
+// first so the last one per (type, locale) is the active one.
+type bundleTemplate struct {
+	Type    string `json:"type" yaml:"type"`
+	Locale  string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Version int    `json:"version" yaml:"version"`
+	Subject string `json:"subject" yaml:"subject"`
+	HTML    string `json:"html" yaml:"html"`
+	Text    string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+/* -------------------- export -------------------- */
+
+func (s *Store) ExportOrg(ctx context.Context, orgID string) (*OrgBundle, error) {
+	org, err := s.GetOrgByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := s.OrgFeedEnabled(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	b := &OrgBundle{
+		Format:        bundleFormat,
+		Version:       bundleSchema,
+		ExportedAt:    time.Now().UTC().Truncate(time.Second),
+		Org:           bundleOrg{Name: org.Name, Timezone: org.Timezone, Locale: org.Locale, PublicFeed: feed},
+		APIs:          []bundleAPI{},
+		Consumers:     []bundleConsumer{},
+		Notifications: []bundleNotification{},
+		Templates:     []bundleTemplate{},
+	}
+
+	apis, err := s.ListAPIs(ctx, orgID, apiQuery{})
+	if err != nil {
+		return nil, err
+	}
+	// ListAPIs is newest first; export in creation order
+	for i := len(apis) - 1; i >= 0; i-- {
+		a := apis[i]
+		ba := bundleAPI{
+			ID: a.ID, Name: a.Name, Description: a.Description,
+			BaseURL: a.BaseURL, DocsURL: a.DocsURL, ContactEmail: a.ContactEmail, OwnerTeam: a.OwnerTeam,
+			Versions: []bundleVersion{},
+		}
+		if err := s.db.QueryRowContext(ctx, `SELECT auto_notify, public_feed FROM apis WHERE id = ?`, a.ID).
+			Scan(&ba.AutoNotify, &ba.PublicFeed); err != nil {
+			return nil, err
+		}
+		if ba.Versions, err = s.exportVersions(ctx, a.ID); err != nil {
+			return nil, err
+		}
+		b.APIs = append(b.APIs, ba)
+
+		notes, err := s.ListNotifications(ctx, a.ID, orgID, notificationQuery{})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			b.Notifications = append(b.Notifications, bundleNotification{
+				ID: n.ID, APIID: n.APIID, VersionID: n.VersionID, Type: n.Type,
+				ScheduledAt: n.ScheduledAt.UTC(), Timezone: n.Timezone, Status: n.Status,
+			})
+		}
+	}
+
+	consumers, err := s.ListConsumers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.orgSubscriptions(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.orgPreferences(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range consumers {
+		bc := bundleConsumer{
+			ID: c.ID, Name: c.Name, Email: c.Email, Timezone: c.Timezone,
+			QuietHoursStart: c.QuietHoursStart, QuietHoursEnd: c.QuietHoursEnd, Locale: c.Locale,
+			Subscriptions: subs[c.ID], Preferences: prefs[c.ID],
+		}
+		if bc.Subscriptions == nil {
+			bc.Subscriptions = []bundleSubscription{}
+		}
+		b.Consumers = append(b.Consumers, bc)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT type, locale, version, subject, html, COALESCE(text,'')
+		FROM notification_templates
+		WHERE org_id = ?
+		ORDER BY type, version`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t bundleTemplate
+		if err := rows.Scan(&t.Type, &t.Locale, &t.Version, &t.Subject, &t.HTML, &t.Text); err != nil {
+			return nil, err
+		}
+		b.Templates = append(b.Templates, t)
+	}
+	return b, rows.Err()
+}
+
+// exportVersions lists an API's versions in creation order.
+func (s *Store) exportVersions(ctx context.Context, apiID string) ([]bundleVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, version, status, sunset_date
+		FROM api_versions
+		WHERE api_id = ?
+		ORDER BY `+s.db.ts("created_at")+`, `+s.db.insertOrder(""), apiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []bundleVersion{}
+	for rows.Next() {
+		var v bundleVersion
+		var sd sql.NullTime
+		if err := rows.Scan(&v.ID, &v.Version, &v.Status, &sd); err != nil {
+			return nil, err
+		}
+		if sd.Valid {
+			v.SunsetDate = sd.Time.UTC().Format("2006-01-02")
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// orgSubscriptions maps consumer ID to its live API subscriptions.
+func (s *Store) orgSubscriptions(ctx context.Context, orgID string) (map[string][]bundleSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ac.consumer_id, ac.api_id, ac.muted
+		FROM api_consumers ac
+		JOIN apis a ON a.id = ac.api_id
+		WHERE a.org_id = ? AND a.deleted_at IS NULL
+		ORDER BY ac.consumer_id, `+s.db.ts("ac.created_at"), orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string][]bundleSubscription{}
+	for rows.Next() {
+		var consumerID string
+		var sub bundleSubscription
+		if err := rows.Scan(&consumerID, &sub.APIID, &sub.Muted); err != nil {
+			return nil, err
+		}
+		out[consumerID] = append(out[consumerID], sub)
+	}
+	return out, rows.Err()
+}
+
+// orgPreferences maps consumer ID to its saved preferences (no entry = defaults).
+func (s *Store) orgPreferences(ctx context.Context, orgID string) (map[string]*bundlePreferences, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.consumer_id, p.unsubscribed, p.notice_types, p.digest
+		FROM consumer_preferences p
+		JOIN consumers c ON c.id = p.consumer_id
+		WHERE c.org_id = ?`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]*bundlePreferences{}
+	for rows.Next() {
+		var consumerID, types string
+		var p bundlePreferences
+		if err := rows.Scan(&consumerID, &p.Unsubscribed, &types, &p.Digest); err != nil {
+			return nil, err
+		}
+		if types != "" {
+			p.NoticeTypes = splitList(types)
+		}
+		out[consumerID] = &p
+	}
+	return out, rows.Err()
+}
+
+/* -------------------- import -------------------- */
+
+var errImportConflicts = errors.New("bundle conflicts with existing data")
+
+type importConflict struct {
+	Kind       string `json:"kind"` // api | version | consumer | notification | template
+	BundleID   string `json:"bundle_id,omitempty"`
+	Name       string `json:"name"`
+	ExistingID string `json:"existing_id,omitempty"`
+}
+
+type importReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   map[string]int    `json:"created"`
+	Skipped   map[string]int    `json:"skipped"`
+	Conflicts []importConflict  `json:"conflicts"`
+	IDs       map[string]string `json:"ids"` // bundle ID -> ID in the target org
+}
+
+// ImportOrg applies a validated bundle to orgID. It returns
+// errImportConflicts (with the report) when the bundle collides with existing
+// data and skipConflicts is false; nothing is written then.
+func (s *Store) ImportOrg(ctx context.Context, orgID string, b *OrgBundle, skipConflicts, dryRun bool) (*importReport, error) {
+	rep := &importReport{
+		DryRun:    dryRun,
+		Created:   map[string]int{},
+		Skipped:   map[string]int{},
+		Conflicts: []importConflict{},
+		IDs:       map[string]string{},
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	im := &importer{ctx: ctx, tx: tx, orgID: orgID, rep: rep}
+	if err := im.loadExisting(); err != nil {
+		return nil, err
+	}
+	im.findConflicts(b)
+	if len(rep.Conflicts) > 0 && !skipConflicts {
+		return rep, errImportConflicts
+	}
+	if err := im.apply(b); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return rep, nil
+	}
+	return rep, tx.Commit()
+}
+
+// importer holds one import's transaction and what the target org already has.
+type importer struct {
+	ctx   context.Context
+	tx    *txConn
+	orgID string
+	rep   *importReport
+
+	apisByName      map[string]string            // lower(name) -> id
+	versionsByAPI   map[string]map[string]string // api id -> version -> id
+	consumersByKey  map[string]string            // consumerKey -> id
+	notificationSet map[string]string            // notificationKey -> id
+	templateSet     map[string]bool              // type/locale with stored versions
+}
+
+func consumerKey(name string, email *string) string {
+	if email != nil && *email != "" {
+		return "email:" + strings.ToLower(*email)
+	}
+	return "name:" + strings.ToLower(name)
+}
+
+func notificationKey(versionID, typ string, at time.Time) string {
+	return versionID + "/" + typ + "/" + at.UTC().Format(time.RFC3339)
+}
+
+func (im *importer) query(q string, args []any, scan func(*sql.Rows) error) error {
+	rows, err := im.tx.QueryContext(im.ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (im *importer) loadExisting() error {
+	im.apisByName = map[string]string{}
+	im.versionsByAPI = map[string]map[string]string{}
+	im.consumersByKey = map[string]string{}
+	im.notificationSet = map[string]string{}
+	im.templateSet = map[string]bool{}
+	org := []any{im.orgID}
+
+	if err := im.query(`SELECT id, name FROM apis WHERE org_id = ? AND deleted_at IS NULL`, org, func(r *sql.Rows) error {
+		var id, name string
+		if err := r.Scan(&id, &name); err != nil {
+			return err
+		}
+		im.apisByName[strings.ToLower(name)] = id
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := im.query(`
+		SELECT v.id, v.api_id, v.version FROM api_versions v JOIN apis a ON a.id = v.api_id
+		WHERE a.org_id = ? AND a.deleted_at IS NULL`, org, func(r *sql.Rows) error {
+		var id, apiID, version string
+		if err := r.Scan(&id, &apiID, &version); err != nil {
+			return err
+		}
+		if im.versionsByAPI[apiID] == nil {
+			im.versionsByAPI[apiID] = map[string]string{}
+		}
+		im.versionsByAPI[apiID][version] = id
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := im.query(`SELECT id, name, email FROM consumers WHERE org_id = ?`, org, func(r *sql.Rows) error {
+		var id, name string
+		var email sql.NullString
+		if err := r.Scan(&id, &name, &email); err != nil {
+			return err
+		}
+		im.consumersByKey[consumerKey(name, nonEmptyPtr(email))] = id
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := im.query(`
+		SELECT n.id, n.version_id, n.type, n.scheduled_at FROM notifications n JOIN apis a ON a.id = n.api_id
+		WHERE a.org_id = ?`, org, func(r *sql.Rows) error {
+		var id, versionID, typ string
+		var at time.Time
+		if err := r.Scan(&id, &versionID, &typ, &at); err != nil {
+			return err
+		}
+		im.notificationSet[notificationKey(versionID, typ, at)] = id
+		return nil
+	}); err != nil {
+		return err
+	}
+	return im.query(`SELECT DISTINCT type, locale FROM notification_templates WHERE org_id = ?`, org, func(r *sql.Rows) error {
+		var typ, locale string
+		if err := r.Scan(&typ, &locale); err != nil {
+			return err
+		}
+		im.templateSet[typ+"/"+locale] = true
+		return nil
+	})
+}
+
+func (im *importer) conflict(kind, bundleID, name, existingID string) {
+	im.rep.Conflicts = append(im.rep.Conflicts, importConflict{Kind: kind, BundleID: bundleID, Name: name, ExistingID: existingID})
+}
+
+// findConflicts records every bundle item that matches existing data. Only
+// items under an existing API can collide with its versions and notices.
+func (im *importer) findConflicts(b *OrgBundle) {
+	versionOwner := map[string]string{} // bundle version id -> existing api id
+	for _, a := range b.APIs {
+		existing, ok := im.apisByName[strings.ToLower(a.Name)]
+		if !ok {
+			continue
+		}
+		im.conflict("api", a.ID, a.Name, existing)
+		for _, v := range a.Versions {
+			if id, ok := im.versionsByAPI[existing][v.Version]; ok {
+				im.conflict("version", v.ID, a.Name+" "+v.Version, id)
+				versionOwner[v.ID] = id
+			}
+		}
+	}
+	for _, c := range b.Consumers {
+		if id, ok := im.consumersByKey[consumerKey(c.Name, c.Email)]; ok {
+			im.conflict("consumer", c.ID, c.Name, id)
+		}
+	}
+	for _, n := range b.Notifications {
+		if vid, ok := versionOwner[n.VersionID]; ok {
+			if id, ok := im.notificationSet[notificationKey(vid, n.Type, n.ScheduledAt)]; ok {
+				im.conflict("notification", n.ID, n.Type+" "+n.ScheduledAt.UTC().Format(time.RFC3339), id)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, t := range b.Templates {
+		key := t.Type + "/" + t.Locale
+		if im.templateSet[key] && !seen[key] {
+			seen[key] = true
+			im.conflict("template", "", key, "")
+		}
+	}
+}
+
+func (im *importer) exec(q string, args ...any) error {
+	_, err := im.tx.ExecContext(im.ctx, q, args...)
+	return err
+}
+
+// apply writes the bundle, reusing existing items where they conflict.
+func (im *importer) apply(b *OrgBundle) error {
+	ids := im.rep.IDs
+	if err := im.exec(`UPDATE organizations SET timezone = ?, locale = ?, public_feed = ? WHERE id = ?`,
+		b.Org.Timezone, b.Org.Locale, b.Org.PublicFeed, im.orgID); err != nil {
+		return err
+	}
+
+	for _, a := range b.APIs {
+		apiID, exists := im.apisByName[strings.ToLower(a.Name)]
+		if exists {
+			im.rep.Skipped["apis"]++
+		} else {
+			apiID = newID()
+			if err := im.exec(`
+				INSERT INTO apis (id, org_id, name, description, base_url, docs_url, contact_email, owner_team, auto_notify, public_feed)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				apiID, im.orgID, a.Name, a.Description, a.BaseURL, a.DocsURL, a.ContactEmail, a.OwnerTeam,
+				a.AutoNotify, a.PublicFeed); err != nil {
+				return fmt.Errorf("api %q: %w", a.Name, err)
+			}
+			im.rep.Created["apis"]++
+		}
+		ids[a.ID] = apiID
+
+		for _, v := range a.Versions {
+			if id, ok := im.versionsByAPI[apiID][v.Version]; exists && ok {
+				ids[v.ID] = id
+				im.rep.Skipped["versions"]++
+				continue
+			}
+			sunset, _ := parseDatePtrYYYYMMDD(trimOptional(&v.SunsetDate))
+			id := newID()
+			if err := im.exec(`
+				INSERT INTO api_versions (id, api_id, version, status, sunset_date) VALUES (?, ?, ?, ?, ?)`,
+				id, apiID, v.Version, v.Status, sunset); err != nil {
+				return fmt.Errorf("api %q version %q: %w", a.Name, v.Version, err)
+			}
+			ids[v.ID] = id
+			im.rep.Created["versions"]++
+		}
+	}
+
+	for _, c := range b.Consumers {
+		consumerID, exists := im.consumersByKey[consumerKey(c.Name, c.Email)]
+		if exists {
+			im.rep.Skipped["consumers"]++
+		} else {
+			consumerID = newID()
+			if err := im.exec(`
+				INSERT INTO consumers (id, org_id, name, email, timezone, quiet_hours_start, quiet_hours_end, locale)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				consumerID, im.orgID, c.Name, c.Email, c.Timezone, c.QuietHoursStart, c.QuietHoursEnd, c.Locale); err != nil {
+				return fmt.Errorf("consumer %q: %w", c.Name, err)
+			}
+			im.rep.Created["consumers"]++
+			if p := c.Preferences; p != nil {
+				now := time.Now().UTC().Format(time.RFC3339)
+				if err := im.exec(`
+					INSERT INTO consumer_preferences (consumer_id, unsubscribed, notice_types, digest, last_digest_at, updated_at)
+					VALUES (?, ?, ?, ?, ?, ?)`,
+					consumerID, p.Unsubscribed, strings.Join(p.NoticeTypes, ","), p.Digest, now, now); err != nil {
+					return fmt.Errorf("consumer %q preferences: %w", c.Name, err)
+				}
+			}
+		}
+		ids[c.ID] = consumerID
+
+		for _, sub := range c.Subscriptions {
+			res, err := im.tx.ExecContext(im.ctx, `
+				INSERT INTO api_consumers (api_id, consumer_id, muted) VALUES (?, ?, ?)
+				ON CONFLICT DO NOTHING`, ids[sub.APIID], consumerID, sub.Muted)
+			if err != nil {
+				return fmt.Errorf("consumer %q subscription: %w", c.Name, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				im.rep.Created["subscriptions"]++
+			} else {
+				im.rep.Skipped["subscriptions"]++
+			}
+		}
+	}
+
+	for _, n := range b.Notifications {
+		versionID := ids[n.VersionID]
+		if id, ok := im.notificationSet[notificationKey(versionID, n.Type, n.ScheduledAt)]; ok {
+			ids[n.ID] = id
+			im.rep.Skipped["notifications"]++
+			continue
+		}
+		id := newID()
+		if err := im.exec(`
+			INSERT INTO notifications (id, api_id, version_id, type, scheduled_at, timezone, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, ids[n.APIID], versionID, n.Type, n.ScheduledAt.UTC().Format(time.RFC3339), nullIfEmpty(n.Timezone), n.Status); err != nil {
+			return fmt.Errorf("notification %s: %w", n.ID, err)
+		}
+		ids[n.ID] = id
+		im.rep.Created["notifications"]++
+	}
+
+	// template versions are renumbered after whatever the org already has
+	templates := append([]bundleTemplate(nil), b.Templates...)
+	sort.SliceStable(templates, func(i, j int) bool {
+		if templates[i].Type != templates[j].Type {
+			return templates[i].Type < templates[j].Type
+		}
+		return templates[i].Version < templates[j].Version
+	})
+	for _, t := range templates {
+		if im.templateSet[t.Type+"/"+t.Locale] {
+			im.rep.Skipped["templates"]++
+			continue
+		}
+		if err := im.exec(`
+			INSERT INTO notification_templates (id, org_id, type, locale, version, subject, html, text)
+			SELECT ?, ?, ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?
+			FROM notification_templates WHERE org_id = ? AND type = ?`,
+			newID(), im.orgID, t.Type, t.Locale, t.Subject, t.HTML, nullIfEmpty(t.Text), im.orgID, t.Type); err != nil {
+			return fmt.Errorf("template %s/%s: %w", t.Type, t.Locale, err)
+		}
+		im.rep.Created["templates"]++
+	}
+	return nil
+}
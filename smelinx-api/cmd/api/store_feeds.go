@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// feedEntry is one version lifecycle event as shown on a changelog feed.
+type feedEntry struct {
+	EventID    string
+	Type       string
+	APIID      string
+	APIName    string
+	DocsURL    string
+	Version    string
+	SunsetDate *time.Time
+	CreatedAt  time.Time
+}
+
+func (s *Store) APIFeedEnabled(ctx context.Context, apiID string) (bool, error) {
+	var on bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT public_feed FROM apis WHERE id = ? AND deleted_at IS NULL`, apiID).Scan(&on)
+	return on, err
+}
+
+func (s *Store) SetAPIFeed(ctx context.Context, apiID string, on bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE apis SET public_feed = ? WHERE id = ?`, on, apiID)
+	return err
+}
+
+func (s *Store) OrgFeedEnabled(ctx context.Context, orgID string) (bool, error) {
+	var on bool
+	err := s.db.QueryRowContext(ctx, `SELECT public_feed FROM organizations WHERE id = ?`, orgID).Scan(&on)
+	return on, err
+}
+
+func (s *Store) SetOrgFeed(ctx context.Context, orgID string, on bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE organizations SET public_feed = ? WHERE id = ?`, on, orgID)
+	return err
+}
+
+// ListFeedEntries returns the newest version lifecycle events of an org's
+// feed-enabled APIs, optionally restricted to one API. Events of deleted
+// APIs or versions are left out.
+func (s *Store) ListFeedEntries(ctx context.Context, orgID, apiID string, limit int) ([]feedEntry, error) {
+	q := `
+		SELECT e.id, e.type, a.id, a.name, a.docs_url, e.data, e.created_at
+		FROM events e
+		JOIN apis a ON a.id = e.api_id
+		LEFT JOIN api_versions v ON v.id = e.version_id
+		WHERE e.org_id = ? AND a.public_feed = 1 AND a.deleted_at IS NULL
+		  AND v.deleted_at IS NULL
+		  AND e.type IN ('version.created','version.deprecated','version.sunset')`
+	args := []any{orgID}
+	if apiID != "" {
+		q += ` AND a.id = ?`
+		args = append(args, apiID)
+	}
+	q += ` ORDER BY e.created_at DESC, ` + s.db.insertOrder("e") + ` DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []feedEntry
+	for rows.Next() {
+		var e feedEntry
+		var docs sql.NullString
+		var data string
+		if err := rows.Scan(&e.EventID, &e.Type, &e.APIID, &e.APIName, &docs, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.DocsURL = docs.String
+
+		// version number and sunset date as of the event
+		var payload struct {
+			Version APIVersion `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err == nil {
+			e.Version = payload.Version.Version
+			e.SunsetDate = payload.Version.SunsetDate
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
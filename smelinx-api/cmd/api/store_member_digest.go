@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// MemberDigestSettings controls the summary email one org member receives.
+type MemberDigestSettings struct {
+	OrgID       string     `json:"org_id"`
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email"`
+	OrgName     string     `json:"-"`
+	Frequency   string     `json:"frequency"`    // off | daily | weekly
+	HorizonDays int        `json:"horizon_days"` // how far ahead to look for sunsets
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+func validDigestFrequency(f string) bool { return f == "off" || f == "daily" || f == "weekly" }
+
+const memberDigestQuery = `
+	SELECT m.org_id, m.user_id, u.email, o.name,
+	       COALESCE(s.frequency, 'weekly'), COALESCE(s.horizon_days, 30), s.last_sent_at
+	FROM org_members m
+	JOIN users u ON u.id = m.user_id
+	JOIN organizations o ON o.id = m.org_id
+	LEFT JOIN member_digest_settings s ON s.org_id = m.org_id AND s.user_id = m.user_id`
+
+func scanMemberDigestSettings(rows interface{ Scan(...any) error }) (*MemberDigestSettings, error) {
+	var m MemberDigestSettings
+	var last sql.NullTime
+	if err := rows.Scan(&m.OrgID, &m.UserID, &m.Email, &m.OrgName, &m.Frequency, &m.HorizonDays, &last); err != nil {
+		return nil, err
+	}
+	if last.Valid {
+		m.LastSentAt = &last.Time
+	}
+	return &m, nil
+}
+
+func (s *Store) GetMemberDigestSettings(ctx context.Context, orgID, userID string) (*MemberDigestSettings, error) {
+	return scanMemberDigestSettings(s.db.QueryRowContext(ctx, memberDigestQuery+`
+		WHERE m.org_id = ? AND m.user_id = ?`, orgID, userID))
+}
+
+// ListMemberDigestSettings returns every org member whose digest is not off.
+func (s *Store) ListMemberDigestSettings(ctx context.Context) ([]MemberDigestSettings, error) {
+	rows, err := s.db.QueryContext(ctx, memberDigestQuery+`
+		WHERE COALESCE(s.frequency, 'weekly') <> 'off'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MemberDigestSettings
+	for rows.Next() {
+		m, err := scanMemberDigestSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) SaveMemberDigestSettings(ctx context.Context, orgID, userID, frequency string, horizonDays int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO member_digest_settings (org_id, user_id, frequency, horizon_days)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET
+			frequency = excluded.frequency, horizon_days = excluded.horizon_days`,
+		orgID, userID, frequency, horizonDays)
+	return err
+}
+
+func (s *Store) MarkMemberDigestSent(ctx context.Context, orgID, userID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO member_digest_settings (org_id, user_id, last_sent_at) VALUES (?, ?, ?)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET last_sent_at = excluded.last_sent_at`,
+		orgID, userID, at.UTC().Format(time.RFC3339))
+	return err
+}
+
+/* -------------------- digest content -------------------- */
+
+// ListOrgEvents returns an org's events of the given types recorded since t, oldest first.
+func (s *Store) ListOrgEvents(ctx context.Context, orgID string, since time.Time, types ...string) ([]Event, error) {
+	q := `SELECT id, org_id, type, api_id, version_id, data, created_at
+		FROM events WHERE org_id = ? AND ` + s.db.ts("created_at") + ` >= ` + s.db.ts("?")
+	args := []any{orgID, since.UTC().Format(time.RFC3339)}
+	if len(types) > 0 {
+		q += ` AND type IN (?` + strings.Repeat(",?", len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY created_at ASC, `+s.db.insertOrder("")+` ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Event
+	for rows.Next() {
+		var ev Event
+		var apiID, versionID sql.NullString
+		var data string
+		if err := rows.Scan(&ev.ID, &ev.OrgID, &ev.Type, &apiID, &versionID, &data, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if apiID.Valid {
+			ev.APIID = &apiID.String
+		}
+		if versionID.Valid {
+			ev.VersionID = &versionID.String
+		}
+		ev.Data = json.RawMessage(data)
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+// retryingNotification is a pending notice whose last attempt failed.
+type retryingNotification struct {
+	NoteID     string
+	APIName    string
+	Version    string
+	Type       string
+	Attempts   int
+	LastError  string
+	RetryAfter sql.NullTime
+}
+
+func (s *Store) ListRetryingNotifications(ctx context.Context, orgID string) ([]retryingNotification, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT n.id, a.name, v.version, n.type, n.attempts, COALESCE(n.last_error, ''), n.retry_after
+		FROM notifications n
+		JOIN apis a ON a.id = n.api_id
+		JOIN api_versions v ON v.id = n.version_id
+		WHERE a.org_id = ? AND n.status = 'pending' AND n.attempts > 0
+		  AND a.deleted_at IS NULL AND v.deleted_at IS NULL
+		ORDER BY a.name, v.version`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []retryingNotification
+	for rows.Next() {
+		var n retryingNotification
+		if err := rows.Scan(&n.NoteID, &n.APIName, &n.Version, &n.Type, &n.Attempts, &n.LastError, &n.RetryAfter); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
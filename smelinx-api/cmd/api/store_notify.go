@@ -13,47 +13,135 @@ type APINotification struct {
 	VersionID   string    `json:"version_id"`
 	Type        string    `json:"type"` // deprecate | sunset
 	ScheduledAt time.Time `json:"scheduled_at"`
-	Status      string    `json:"status"` // pending | sent | canceled
+	Timezone    string    `json:"timezone,omitempty"` // IANA zone the schedule was entered in
+	Status      string    `json:"status"`             // pending | sent | canceled
 	CreatedAt   time.Time `json:"created_at"`
+	Revision    int64     `json:"revision"` // bumped on every change; the ETag
 }
 
-func (s *Store) CreateNotification(ctx context.Context, apiID, versionID, typ string, when time.Time) (*APINotification, error) {
+func (s *Store) CreateNotification(ctx context.Context, apiID, versionID, typ string, when time.Time, tz string) (*APINotification, error) {
 	id := newID()
-	// store RFC3339 UTC so SQLite julianday() can parse
+	// store RFC3339 UTC so SQLite julianday() can parse (and Postgres casts)
 	whenRFC3339 := when.UTC().Format(time.RFC3339)
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO notifications (id, api_id, version_id, type, scheduled_at, status)
-		VALUES (?, ?, ?, ?, ?, 'pending')
-	`, id, apiID, versionID, strings.ToLower(typ), whenRFC3339)
+		INSERT INTO notifications (id, api_id, version_id, type, scheduled_at, timezone, status)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending')
+	`, id, apiID, versionID, strings.ToLower(typ), whenRFC3339, nullIfEmpty(tz))
 	if err != nil {
 		return nil, err
 	}
 	return s.GetNotificationByID(ctx, id)
 }
 
+// CreateAutoNotification enqueues an immediate notice unless a pending or
+// sent one with the same dedupe key exists; it returns nil, nil then.
+func (s *Store) CreateAutoNotification(ctx context.Context, apiID, versionID, typ, dedupeKey string) (*APINotification, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE dedupe_key = ? AND status IN ('pending','sent')`, dedupeKey).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, nil
+	}
+	id := newID()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO notifications (id, api_id, version_id, type, scheduled_at, status, dedupe_key)
+		VALUES (?, ?, ?, ?, ?, 'pending', ?)`,
+		id, apiID, versionID, typ, time.Now().UTC().Format(time.RFC3339), dedupeKey); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetNotificationByID(ctx, id)
+}
+
 func (s *Store) GetNotificationByID(ctx context.Context, id string) (*APINotification, error) {
 	var n APINotification
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, api_id, version_id, type, scheduled_at, status, created_at
+		SELECT id, api_id, version_id, type, scheduled_at, COALESCE(timezone, ''), status, created_at, revision
 		FROM notifications
 		WHERE id = ?
 	`, id).
-		Scan(&n.ID, &n.APIID, &n.VersionID, &n.Type, &n.ScheduledAt, &n.Status, &n.CreatedAt)
+		Scan(&n.ID, &n.APIID, &n.VersionID, &n.Type, &n.ScheduledAt, &n.Timezone, &n.Status, &n.CreatedAt, &n.Revision)
 	if err != nil {
 		return nil, err
 	}
 	return &n, nil
 }
 
+var (
+	notificationStatuses = []string{"pending", "sent", "canceled"}
+	notificationTypes    = []string{"deprecate", "sunset"}
+)
+
+// notificationQuery filters and pages ListNotifications; the zero value
+// lists every notice, soonest first.
+type notificationQuery struct {
+	pageQuery
+	Status []string
+	Type   []string
+	From   *time.Time // scheduled at or after
+	To     *time.Time // scheduled before
+}
+
+var notificationSorts = map[string]sortField{
+	"scheduled_at": {col: "scheduled_at", time: true},
+	"created_at":   {col: "created_at", time: true},
+}
+
+func (n APINotification) cursorID() string { return n.ID }
+
+func (n APINotification) cursorKey(field string) string {
+	if field == "created_at" {
+		return cursorTime(n.CreatedAt)
+	}
+	return cursorTime(n.ScheduledAt)
+}
+
 // Org-scoped list for one API.
-func (s *Store) ListNotifications(ctx context.Context, apiID, orgID string) ([]APINotification, error) {
+func (s *Store) ListNotifications(ctx context.Context, apiID, orgID string, q notificationQuery) ([]APINotification, error) {
+	if q.Field == "" {
+		q.Field = "scheduled_at"
+	}
+	where, args := []string{"n.api_id = ?", "a.org_id = ?"}, []any{apiID, orgID}
+	if len(q.Status) > 0 {
+		in, inArgs := inClause("n.status", q.Status)
+		where = append(where, in)
+		args = append(args, inArgs...)
+	}
+	if len(q.Type) > 0 {
+		in, inArgs := inClause("n.type", q.Type)
+		where = append(where, in)
+		args = append(args, inArgs...)
+	}
+	if q.From != nil {
+		where = append(where, s.db.ts("n.scheduled_at")+" >= "+s.db.ts("?"))
+		args = append(args, cursorTime(*q.From))
+	}
+	if q.To != nil {
+		where = append(where, s.db.ts("n.scheduled_at")+" < "+s.db.ts("?"))
+		args = append(args, cursorTime(*q.To))
+	}
+	after, afterArgs, order := s.keyset("n", notificationSorts, q.pageQuery)
+	if after != "" {
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT n.id, n.api_id, n.version_id, n.type, n.scheduled_at, n.status, n.created_at
+		SELECT n.id, n.api_id, n.version_id, n.type, n.scheduled_at, COALESCE(n.timezone, ''), n.status, n.created_at, n.revision
 		FROM notifications n
 		JOIN apis a ON a.id = n.api_id
-		WHERE n.api_id = ? AND a.org_id = ?
-		ORDER BY datetime(n.scheduled_at) ASC, datetime(n.created_at) DESC
-	`, apiID, orgID)
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+q.limitClause(), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +150,7 @@ func (s *Store) ListNotifications(ctx context.Context, apiID, orgID string) ([]A
 	var out []APINotification
 	for rows.Next() {
 		var n APINotification
-		if err := rows.Scan(&n.ID, &n.APIID, &n.VersionID, &n.Type, &n.ScheduledAt, &n.Status, &n.CreatedAt); err != nil {
+		if err := rows.Scan(&n.ID, &n.APIID, &n.VersionID, &n.Type, &n.ScheduledAt, &n.Timezone, &n.Status, &n.CreatedAt, &n.Revision); err != nil {
 			return nil, err
 		}
 		out = append(out, n)
@@ -70,13 +158,19 @@ func (s *Store) ListNotifications(ctx context.Context, apiID, orgID string) ([]A
 	return out, rows.Err()
 }
 
-func (s *Store) UpdateNotificationStatus(ctx context.Context, id, status string) (*APINotification, error) {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE notifications SET status = ? WHERE id = ?
-	`, status, id)
+// UpdateNotificationStatus sets the status if the notice is still at
+// revision rev (0: whatever it is at), else returns errStaleRevision.
+func (s *Store) UpdateNotificationStatus(ctx context.Context, id, status string, rev int64) (*APINotification, error) {
+	check, checkArgs := revisionCheck(rev)
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET status = ?, revision = revision + 1 WHERE id = ?`+check,
+		append([]any{status, id}, checkArgs...)...)
 	if err != nil {
 		return nil, err
 	}
+	if err := staleRevision(res, rev); err != nil {
+		return nil, err
+	}
 	return s.GetNotificationByID(ctx, id)
 }
 
@@ -85,43 +179,87 @@ type dueNotification struct {
 	NoteID       string
 	APIID        string
 	OrgID        string
+	OrgName      string
 	APIName      string
 	VersionID    string
 	Version      string
+	SunsetDate   sql.NullTime
 	Type         string
 	ContactEmail sql.NullString
 	DocsURL      sql.NullString
 	BaseURL      sql.NullString
 	ScheduledAt  time.Time
+	Timezone     string // notification zone, else the org's
+	Locale       string // org default locale
 	Attempts     int
 }
 
-// ListDueNotifications returns "pending" notes whose scheduled_at <= now
-// and (retry_after is null or <= now). limit guards each batch.
-func (s *Store) ListDueNotifications(ctx context.Context, limit int) ([]dueNotification, error) {
-	if limit <= 0 {
-		limit = 50
+// noticeFor assembles the dispatcher payload for a (possibly hypothetical)
+// notification, so previews and test sends render exactly like real sends.
+func noticeFor(org *Org, api *API, v *APIVersion, noteID, typ string, when time.Time) dueNotification {
+	d := dueNotification{
+		NoteID:      noteID,
+		APIID:       api.ID,
+		OrgID:       api.OrgID,
+		OrgName:     org.Name,
+		APIName:     api.Name,
+		VersionID:   v.ID,
+		Version:     v.Version,
+		Type:        typ,
+		ScheduledAt: when,
+		Timezone:    org.Timezone,
+		Locale:      org.Locale,
 	}
-	rows, err := s.db.QueryContext(ctx, `
+	if v.SunsetDate != nil {
+		d.SunsetDate = sql.NullTime{Time: *v.SunsetDate, Valid: true}
+	}
+	if api.ContactEmail != nil {
+		d.ContactEmail = sql.NullString{String: *api.ContactEmail, Valid: true}
+	}
+	if api.DocsURL != nil {
+		d.DocsURL = sql.NullString{String: *api.DocsURL, Valid: true}
+	}
+	if api.BaseURL != nil {
+		d.BaseURL = sql.NullString{String: *api.BaseURL, Valid: true}
+	}
+	return d
+}
+
+// dueNotificationQuery selects the columns scanned by scanDueNotifications;
+// callers append JOINs/WHERE clauses.
+const dueNotificationQuery = `
 		SELECT
 			n.id,
 			a.id,
 			a.org_id,
+			o.name,
 			a.name,
 			v.id,
 			v.version,
+			v.sunset_date,
 			n.type,
 			a.contact_email,
 			a.docs_url,
 			a.base_url,
 			n.scheduled_at,
+			COALESCE(n.timezone, o.timezone, 'UTC'),
+			COALESCE(o.locale, 'en'),
 			COALESCE(n.attempts, 0)
 		FROM notifications n
 		JOIN apis a ON a.id = n.api_id
 		JOIN api_versions v ON v.id = n.version_id
+		JOIN organizations o ON o.id = a.org_id`
+
+// ListDueNotifications returns "pending" notes whose scheduled_at <= now
+// and (retry_after is null or <= now). limit guards each batch.
+func (s *Store) ListDueNotifications(ctx context.Context, limit int) ([]dueNotification, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, dueNotificationQuery+`
 		WHERE n.status = 'pending'
-		  AND julianday(n.scheduled_at) <= julianday('now')
-		  AND (n.retry_after IS NULL OR julianday(n.retry_after) <= julianday('now'))
+		  AND `+s.db.ts("n.scheduled_at")+` <= `+s.db.now()+`
+		  AND (n.retry_after IS NULL OR `+s.db.ts("n.retry_after")+` <= `+s.db.now()+`)
 		  AND a.deleted_at IS NULL
 		  AND v.deleted_at IS NULL
 		ORDER BY n.scheduled_at ASC
@@ -130,6 +268,10 @@ func (s *Store) ListDueNotifications(ctx context.Context, limit int) ([]dueNotif
 	if err != nil {
 		return nil, err
 	}
+	return scanDueNotifications(rows)
+}
+
+func scanDueNotifications(rows *sql.Rows) ([]dueNotification, error) {
 	defer rows.Close()
 
 	var out []dueNotification
@@ -139,14 +281,18 @@ func (s *Store) ListDueNotifications(ctx context.Context, limit int) ([]dueNotif
 			&d.NoteID,
 			&d.APIID,
 			&d.OrgID,
+			&d.OrgName,
 			&d.APIName,
 			&d.VersionID,
 			&d.Version,
+			&d.SunsetDate,
 			&d.Type,
 			&d.ContactEmail,
 			&d.DocsURL,
 			&d.BaseURL,
 			&d.ScheduledAt,
+			&d.Timezone,
+			&d.Locale,
 			&d.Attempts,
 		); err != nil {
 			return nil, err
@@ -157,7 +303,16 @@ func (s *Store) ListDueNotifications(ctx context.Context, limit int) ([]dueNotif
 }
 
 func (s *Store) MarkNotificationSent(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `UPDATE notifications SET status = 'sent' WHERE id = ?`, id)
+	_, err := s.db.ExecContext(ctx, `UPDATE notifications SET status = 'sent', revision = revision + 1 WHERE id = ?`, id)
+	return err
+}
+
+// DeferNotification pushes a pending notification past a recipient's quiet
+// hours without counting it as a failed attempt.
+func (s *Store) DeferNotification(ctx context.Context, id string, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET retry_after = ? WHERE id = ? AND status = 'pending'`,
+		until.UTC().Format(time.RFC3339), id)
 	return err
 }
 
@@ -173,8 +328,49 @@ func (s *Store) ScheduleNotificationRetry(ctx context.Context, id string, next t
 func (s *Store) AutoCancelNotification(ctx context.Context, id string, reason string) error {
 	_, err := s.db.ExecContext(ctx, `
 		UPDATE notifications
-		SET status = 'canceled', last_error = ?
+		SET status = 'canceled', last_error = ?, revision = revision + 1
 		WHERE id = ?
 	`, reason, id)
 	return err
 }
+
+/* -------------------- manifest-managed notices -------------------- */
+
+// createManifestNotification schedules a notice owned by manifest sync; key
+// is its "manifest:" dedupe key.
+func (s *Store) createManifestNotification(ctx context.Context, apiID, versionID, typ string, when time.Time, tz, key string) (*APINotification, error) {
+	id := newID()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO notifications (id, api_id, version_id, type, scheduled_at, timezone, status, dedupe_key)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending', ?)`,
+		id, apiID, versionID, typ, when.UTC().Format(time.RFC3339), nullIfEmpty(tz), key); err != nil {
+		return nil, err
+	}
+	return s.GetNotificationByID(ctx, id)
+}
+
+// listManifestNotifications maps the dedupe key of every manifest-owned
+// notice in the org to the most recent notice with it.
+func (s *Store) listManifestNotifications(ctx context.Context, orgID string) (map[string]*APINotification, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT n.id, n.api_id, n.version_id, n.type, n.scheduled_at, COALESCE(n.timezone, ''), n.status, n.created_at, n.revision, n.dedupe_key
+		FROM notifications n
+		JOIN apis a ON a.id = n.api_id
+		WHERE a.org_id = ? AND n.dedupe_key LIKE 'manifest:%'
+		ORDER BY `+s.db.ts("n.created_at")+`, `+s.db.insertOrder("n"), orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]*APINotification{}
+	for rows.Next() {
+		var n APINotification
+		var key string
+		if err := rows.Scan(&n.ID, &n.APIID, &n.VersionID, &n.Type, &n.ScheduledAt, &n.Timezone, &n.Status, &n.CreatedAt, &n.Revision, &key); err != nil {
+			return nil, err
+		}
+		out[key] = &n
+	}
+	return out, rows.Err()
+}
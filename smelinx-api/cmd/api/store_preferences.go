@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConsumerPreferences is what a consumer chose on the self-service
+// preferences page. Sunset notices ignore all of it: they are always sent.
+type ConsumerPreferences struct {
+	ConsumerID   string   `json:"consumer_id"`
+	Unsubscribed bool     `json:"unsubscribed"`
+	NoticeTypes  []string `json:"notice_types"`  // empty = every type
+	Digest       string   `json:"digest"`        // immediate | weekly
+	MutedAPIIDs  []string `json:"muted_api_ids"` // subscribed APIs the consumer opted out of
+}
+
+// Wants reports whether an optional notice of typ for apiID should reach the consumer.
+func (p *ConsumerPreferences) Wants(apiID, typ string) bool {
+	if p.Unsubscribed || slices.Contains(p.MutedAPIIDs, apiID) {
+		return false
+	}
+	return len(p.NoticeTypes) == 0 || slices.Contains(p.NoticeTypes, typ)
+}
+
+// GetConsumerPreferences returns the stored preferences, or the defaults
+// (everything, immediately) when the consumer never saved any.
+func (s *Store) GetConsumerPreferences(ctx context.Context, consumerID string) (*ConsumerPreferences, error) {
+	p := ConsumerPreferences{ConsumerID: consumerID, Digest: "immediate", NoticeTypes: []string{}, MutedAPIIDs: []string{}}
+	var types string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT unsubscribed, notice_types, digest FROM consumer_preferences WHERE consumer_id = ?`, consumerID).
+		Scan(&p.Unsubscribed, &types, &p.Digest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if types != "" {
+		p.NoticeTypes = splitList(types)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT api_id FROM api_consumers WHERE consumer_id = ? AND muted = 1`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		p.MutedAPIIDs = append(p.MutedAPIIDs, id)
+	}
+	return &p, rows.Err()
+}
+
+// SaveConsumerPreferences upserts p. Switching to the weekly digest starts the
+// weekly cycle now, so the first digest goes out a week later.
+func (s *Store) SaveConsumerPreferences(ctx context.Context, p *ConsumerPreferences) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO consumer_preferences (consumer_id, unsubscribed, notice_types, digest, last_digest_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (consumer_id) DO UPDATE SET
+			unsubscribed   = excluded.unsubscribed,
+			notice_types   = excluded.notice_types,
+			digest         = excluded.digest,
+			last_digest_at = CASE WHEN consumer_preferences.digest = 'weekly'
+				THEN consumer_preferences.last_digest_at ELSE excluded.last_digest_at END,
+			updated_at     = excluded.updated_at`,
+		p.ConsumerID, p.Unsubscribed, strings.Join(p.NoticeTypes, ","), p.Digest, now, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE api_consumers SET muted = 0 WHERE consumer_id = ?`, p.ConsumerID); err != nil {
+		return err
+	}
+	for _, apiID := range p.MutedAPIIDs {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE api_consumers SET muted = 1 WHERE consumer_id = ? AND api_id = ?`, p.ConsumerID, apiID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Unsubscribe opts a consumer out of every optional notice (one-click unsubscribe).
+func (s *Store) Unsubscribe(ctx context.Context, consumerID string) error {
+	p, err := s.GetConsumerPreferences(ctx, consumerID)
+	if err != nil {
+		return err
+	}
+	p.Unsubscribed = true
+	return s.SaveConsumerPreferences(ctx, p)
+}
+
+/* -------------------- weekly digest -------------------- */
+
+// QueueDigestItem holds a notice back for the consumer's next weekly digest.
+func (s *Store) QueueDigestItem(ctx context.Context, consumerID, noteID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO consumer_digest_items (consumer_id, notification_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING`,
+		consumerID, noteID)
+	return err
+}
+
+// ListDigestConsumersDue returns weekly-digest consumers with queued notices
+// whose last digest went out at least a week ago.
+func (s *Store) ListDigestConsumersDue(ctx context.Context) ([]Consumer, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.org_id, c.name, c.email, c.timezone, c.quiet_hours_start, c.quiet_hours_end, c.locale, c.created_at
+		FROM consumers c
+		JOIN consumer_preferences p ON p.consumer_id = c.id
+		WHERE p.digest = 'weekly' AND c.email IS NOT NULL
+		  AND (p.last_digest_at IS NULL OR `+s.db.ts("p.last_digest_at")+` <= `+s.db.ts("?")+`)
+		  AND EXISTS (SELECT 1 FROM consumer_digest_items di WHERE di.consumer_id = c.id AND di.sent_at IS NULL)`,
+		time.Now().UTC().AddDate(0, 0, -7).Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Consumer
+	for rows.Next() {
+		c, err := scanConsumer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+// ListDigestItems returns the notices queued for a consumer's next digest.
+func (s *Store) ListDigestItems(ctx context.Context, consumerID string) ([]dueNotification, error) {
+	rows, err := s.db.QueryContext(ctx, dueNotificationQuery+`
+		JOIN consumer_digest_items di ON di.notification_id = n.id
+		WHERE di.consumer_id = ? AND di.sent_at IS NULL
+		  AND a.deleted_at IS NULL AND v.deleted_at IS NULL
+		ORDER BY a.name, v.version, n.scheduled_at`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDueNotifications(rows)
+}
+
+// MarkDigestSent clears the consumer's queue and restarts the weekly cycle.
+func (s *Store) MarkDigestSent(ctx context.Context, consumerID string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE consumer_digest_items SET sent_at = ? WHERE consumer_id = ? AND sent_at IS NULL`, now, consumerID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE consumer_preferences SET last_digest_at = ? WHERE consumer_id = ?`, now, consumerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// consumerAPI is one API a consumer is subscribed to, as listed on the
+// preferences page.
+type consumerAPI struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Muted bool   `json:"muted"`
+}
+
+func (s *Store) ListConsumerAPIs(ctx context.Context, consumerID string) ([]consumerAPI, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.name, ac.muted
+		FROM api_consumers ac
+		JOIN apis a ON a.id = ac.api_id
+		WHERE ac.consumer_id = ? AND a.deleted_at IS NULL
+		ORDER BY a.name ASC`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []consumerAPI{}
+	for rows.Next() {
+		var c consumerAPI
+		if err := rows.Scan(&c.ID, &c.Name, &c.Muted); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NotificationTemplate is one stored version of an org's template for a
+// notification type and locale ("" = locale-neutral). Versions are numbered
+// per (org, type); the highest one per locale is active for that locale.
+type NotificationTemplate struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	Type      string    `json:"type"` // deprecate | sunset
+	Locale    string    `json:"locale,omitempty"`
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"`
+	HTML      string    `json:"html"`
+	Text      string    `json:"text,omitempty"`
+	CreatedBy *string   `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Template returns the renderable source of t.
+func (t NotificationTemplate) Template() noticeTemplate {
+	return noticeTemplate{Subject: t.Subject, HTML: t.HTML, Text: t.Text}
+}
+
+const templateCols = `id, org_id, type, locale, version, subject, html, COALESCE(text,''), created_by, created_at`
+
+func scanTemplate(sc interface{ Scan(...any) error }) (*NotificationTemplate, error) {
+	var t NotificationTemplate
+	var createdBy sql.NullString
+	if err := sc.Scan(&t.ID, &t.OrgID, &t.Type, &t.Locale, &t.Version, &t.Subject, &t.HTML, &t.Text, &createdBy, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		t.CreatedBy = &createdBy.String
+	}
+	return &t, nil
+}
+
+// GetActiveTemplate returns the latest stored version for locale, else the
+// latest locale-neutral one, or sql.ErrNoRows if the org uses the built-in
+// default for typ.
+func (s *Store) GetActiveTemplate(ctx context.Context, orgID, typ, locale string) (*NotificationTemplate, error) {
+	return scanTemplate(s.db.QueryRowContext(ctx, `
+		SELECT `+templateCols+`
+		FROM notification_templates
+		WHERE org_id = ? AND type = ? AND locale IN (?, '')
+		ORDER BY locale = ? DESC, version DESC
+		LIMIT 1`, orgID, typ, locale, locale))
+}
+
+func (s *Store) GetTemplateVersion(ctx context.Context, orgID, typ string, version int) (*NotificationTemplate, error) {
+	return scanTemplate(s.db.QueryRowContext(ctx, `
+		SELECT `+templateCols+`
+		FROM notification_templates
+		WHERE org_id = ? AND type = ? AND version = ?`, orgID, typ, version))
+}
+
+func (s *Store) ListTemplateVersions(ctx context.Context, orgID, typ, locale string) ([]NotificationTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+templateCols+`
+		FROM notification_templates
+		WHERE org_id = ? AND type = ? AND locale = ?
+		ORDER BY version DESC`, orgID, typ, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []NotificationTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	return out, rows.Err()
+}
+
+// CreateTemplateVersion stores tpl as the next version for (org, type),
+// making it the active template for locale.
+func (s *Store) CreateTemplateVersion(ctx context.Context, orgID, typ, locale string, tpl noticeTemplate, createdBy string) (*NotificationTemplate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var next int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0) + 1 FROM notification_templates WHERE org_id = ? AND type = ?`,
+		orgID, typ).Scan(&next); err != nil {
+		return nil, err
+	}
+	id := newID()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO notification_templates (id, org_id, type, locale, version, subject, html, text, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, orgID, typ, locale, next, tpl.Subject, tpl.HTML, nullIfEmpty(tpl.Text), nullIfEmpty(createdBy)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetTemplateVersion(ctx, orgID, typ, next)
+}
+
+// DeleteTemplates drops every stored version for locale so the org falls back
+// to its locale-neutral template or the default.
+func (s *Store) DeleteTemplates(ctx context.Context, orgID, typ, locale string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM notification_templates WHERE org_id = ? AND type = ? AND locale = ?`, orgID, typ, locale)
+	return err
+}
@@ -0,0 +1,535 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+Store behavior suite.
+
+Every test runs once per backend: SQLite in a temp file, and PostgreSQL
+when SMELINX_TEST_POSTGRES holds a DSN, e.g.
+
+	docker run --rm -e POSTGRES_PASSWORD=pw -p 5432:5432 postgres:16
+	SMELINX_TEST_POSTGRES=postgres://postgres:pw@localhost:5432/postgres?sslmode=disable go test ./cmd/api
+
+Each PostgreSQL run gets a throwaway schema, dropped afterwards.
+*/
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard) // migrations and workers log every step
+	os.Exit(m.Run())
+}
+
+// forEachBackend runs fn against a freshly migrated database per backend.
+func forEachBackend(t *testing.T, fn func(t *testing.T, db *dbConn)) {
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, migratedDB(t, openTestSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("SMELINX_TEST_POSTGRES")
+		if dsn == "" {
+			t.Skip("SMELINX_TEST_POSTGRES not set")
+		}
+		fn(t, migratedDB(t, openTestPostgres(t, dsn)))
+	})
+}
+
+// forEachStore is forEachBackend for tests that only need the Store.
+func forEachStore(t *testing.T, fn func(t *testing.T, s *Store)) {
+	forEachBackend(t, func(t *testing.T, db *dbConn) { fn(t, NewStore(db)) })
+}
+
+func openTestSQLite(t *testing.T) *dbConn {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &dbConn{DB: db, dialect: sqliteDialect{}}
+}
+
+func openTestPostgres(t *testing.T, dsn string) *dbConn {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	b := make([]byte, 6)
+	rand.Read(b)
+	schema := "smelinx_test_" + hex.EncodeToString(b)
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	dsn = postgresDSN(dsn)
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping postgres: %v", err)
+	}
+	return &dbConn{DB: db, dialect: postgresDialect{}}
+}
+
+func migratedDB(t *testing.T, db *dbConn) *dbConn {
+	t.Helper()
+	if _, err := migrateUp(context.Background(), db, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
+
+/* -------------------- fixtures -------------------- */
+
+// seedOrg creates a user and the org they own.
+func seedOrg(t *testing.T, s *Store, email string) (*User, *Org) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := s.CreateUser(ctx, email, "hash")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	org, err := s.CreateOrgWithOwner(ctx, "Org of "+email, u.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	return u, org
+}
+
+func seedAPI(t *testing.T, s *Store, orgID, name string, meta *APIMeta) *API {
+	t.Helper()
+	api, err := s.CreateAPI(context.Background(), orgID, name, "", meta)
+	if err != nil {
+		t.Fatalf("create API %s: %v", name, err)
+	}
+	return api
+}
+
+func seedVersion(t *testing.T, s *Store, apiID, version, status string, sunset *time.Time) *APIVersion {
+	t.Helper()
+	v, err := s.CreateVersion(context.Background(), apiID, version, status, sunset)
+	if err != nil {
+		t.Fatalf("create version %s: %v", version, err)
+	}
+	return v
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// allPages follows the keyset cursors of a list from the first page.
+func allPages[T pageItem](t *testing.T, p pageQuery, list func(pageQuery) ([]T, error)) []T {
+	t.Helper()
+	var out []T
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("cursor does not advance")
+		}
+		items, err := list(p)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		items, next := trimPage(items, p)
+		out = append(out, items...)
+		if next == "" {
+			return out
+		}
+		c, ok := decodeCursor(next)
+		if !ok {
+			t.Fatalf("undecodable cursor %q", next)
+		}
+		p.After = c
+	}
+}
+
+/* -------------------- users and orgs -------------------- */
+
+func TestStoreUsersAndOrgs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		u, org := seedOrg(t, s, "a@b.co")
+
+		got, err := s.GetUserByEmail(ctx, "a@b.co")
+		if err != nil || got.ID != u.ID {
+			t.Fatalf("GetUserByEmail = %+v, %v; want %s", got, err, u.ID)
+		}
+		if _, err := s.CreateUser(ctx, "a@b.co", "other"); err == nil {
+			t.Error("duplicate email: want an error")
+		}
+		if _, err := s.GetUserByEmail(ctx, "nobody@b.co"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("unknown email: err = %v, want sql.ErrNoRows", err)
+		}
+
+		primary, err := s.GetUserPrimaryOrg(ctx, u.ID)
+		if err != nil || primary.ID != org.ID {
+			t.Fatalf("GetUserPrimaryOrg = %+v, %v; want %s", primary, err, org.ID)
+		}
+		if err := s.SetOrgTimezone(ctx, org.ID, "Europe/Berlin"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetOrgLocale(ctx, org.ID, "de"); err != nil {
+			t.Fatal(err)
+		}
+		org, err = s.GetOrgByID(ctx, org.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if org.Timezone != "Europe/Berlin" || org.Locale != "de" {
+			t.Errorf("org zone/locale = %q/%q, want Europe/Berlin/de", org.Timezone, org.Locale)
+		}
+	})
+}
+
+/* -------------------- APIs -------------------- */
+
+func TestStoreAPIRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		api := seedAPI(t, s, org.ID, "Payments", &APIMeta{OwnerTeam: ptr("core")})
+		if api.Revision != 1 || api.OwnerTeam == nil || *api.OwnerTeam != "core" {
+			t.Fatalf("created API = %+v, want revision 1 and owner team core", api)
+		}
+		if api.CreatedAt.IsZero() || api.CreatedAt.Location() != time.UTC {
+			t.Errorf("created_at = %v, want a UTC time", api.CreatedAt)
+		}
+
+		updated, err := s.UpdateAPI(ctx, api.ID, "Payments v2", "desc", &APIMeta{}, api.Revision)
+		if err != nil {
+			t.Fatalf("UpdateAPI: %v", err)
+		}
+		if updated.Name != "Payments v2" || updated.Revision != 2 {
+			t.Errorf("updated = %q rev %d, want Payments v2 rev 2", updated.Name, updated.Revision)
+		}
+		if _, err := s.UpdateAPI(ctx, api.ID, "Lost update", "", nil, api.Revision); !errors.Is(err, errStaleRevision) {
+			t.Errorf("update at stale revision: err = %v, want errStaleRevision", err)
+		}
+		if err := s.DeleteAPI(ctx, api.ID, api.Revision); !errors.Is(err, errStaleRevision) {
+			t.Errorf("delete at stale revision: err = %v, want errStaleRevision", err)
+		}
+
+		// flags are INTEGER on both backends and scan into bool
+		if on, err := s.APIAutoNotify(ctx, api.ID); err != nil || on {
+			t.Errorf("auto notify = %v, %v; want false", on, err)
+		}
+		if err := s.SetAPIAutoNotify(ctx, api.ID, true); err != nil {
+			t.Fatal(err)
+		}
+		if on, err := s.APIAutoNotify(ctx, api.ID); err != nil || !on {
+			t.Errorf("auto notify = %v, %v; want true", on, err)
+		}
+
+		if err := s.DeleteAPI(ctx, api.ID, updated.Revision); err != nil {
+			t.Fatalf("DeleteAPI: %v", err)
+		}
+		list, err := s.ListAPIs(ctx, org.ID, apiQuery{})
+		if err != nil || len(list) != 0 {
+			t.Errorf("after delete ListAPIs = %d APIs, %v; want none", len(list), err)
+		}
+	})
+}
+
+func TestStoreListAPIsPages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		_, other := seedOrg(t, s, "c@d.co")
+		seedAPI(t, s, other.ID, "Elsewhere", nil)
+		// created within the same second: the id breaks the created_at ties
+		names := []string{"delta", "alpha", "echo", "charlie", "bravo", "100% uptime"}
+		for i, n := range names {
+			team := "core"
+			if i%2 == 1 {
+				team = "edge"
+			}
+			seedAPI(t, s, org.ID, n, &APIMeta{OwnerTeam: &team})
+		}
+
+		list := func(q apiQuery) func(pageQuery) ([]API, error) {
+			return func(p pageQuery) ([]API, error) {
+				q.pageQuery = p
+				return s.ListAPIs(ctx, org.ID, q)
+			}
+		}
+		names2 := func(apis []API) string {
+			var out []string
+			for _, a := range apis {
+				out = append(out, a.Name)
+			}
+			return strings.Join(out, ",")
+		}
+
+		byName := allPages(t, pageQuery{Limit: 2, Field: "name"}, list(apiQuery{}))
+		if got, want := names2(byName), "100% uptime,alpha,bravo,charlie,delta,echo"; got != want {
+			t.Errorf("sort=name pages = %s, want %s", got, want)
+		}
+
+		newest := allPages(t, pageQuery{Limit: 4, Field: "created_at", Desc: true}, list(apiQuery{}))
+		if len(newest) != len(names) {
+			t.Fatalf("sort=-created_at pages = %d APIs, want %d", len(newest), len(names))
+		}
+		seen := map[string]bool{}
+		for i, a := range newest {
+			if seen[a.ID] {
+				t.Errorf("%s served twice", a.Name)
+			}
+			seen[a.ID] = true
+			if i > 0 && a.CreatedAt.After(newest[i-1].CreatedAt) {
+				t.Errorf("%s is newer than %s, which came before it", a.Name, newest[i-1].Name)
+			}
+		}
+
+		if got := names2(allPages(t, pageQuery{Field: "name"}, list(apiQuery{Search: "%"}))); got != "100% uptime" {
+			t.Errorf("q=%% matched %q, want only the literal %%", got)
+		}
+		if got := names2(allPages(t, pageQuery{Field: "name"}, list(apiQuery{Search: "HAR"}))); got != "charlie" {
+			t.Errorf("q=HAR matched %q, want charlie", got)
+		}
+		if got := names2(allPages(t, pageQuery{Field: "name"}, list(apiQuery{OwnerTeam: "edge"}))); got != "100% uptime,alpha,charlie" {
+			t.Errorf("owner_team=edge matched %q, want 100%% uptime,alpha,charlie", got)
+		}
+	})
+}
+
+/* -------------------- versions -------------------- */
+
+func TestStoreVersions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		api := seedAPI(t, s, org.ID, "Payments", nil)
+		sunset := time.Date(2031, 6, 30, 0, 0, 0, 0, time.UTC)
+		v1 := seedVersion(t, s, api.ID, "v1", "deprecated", &sunset)
+		v2 := seedVersion(t, s, api.ID, "v2", "active", nil)
+
+		if v1.SunsetDate == nil || !v1.SunsetDate.Equal(sunset) {
+			t.Errorf("v1 sunset = %v, want %v", v1.SunsetDate, sunset)
+		}
+		if next, err := s.SuccessorVersion(ctx, api.ID, v1.ID); err != nil || next != "v2" {
+			t.Errorf("SuccessorVersion(v1) = %q, %v; want v2", next, err)
+		}
+
+		later := sunset.AddDate(0, 3, 0)
+		updated, err := s.UpdateVersionStatus(ctx, v2.ID, "deprecated", &later, v2.Revision)
+		if err != nil {
+			t.Fatalf("UpdateVersionStatus: %v", err)
+		}
+		if updated.Status != "deprecated" || updated.Revision != 2 || updated.SunsetDate == nil || !updated.SunsetDate.Equal(later) {
+			t.Errorf("updated v2 = %+v, want deprecated at rev 2 with sunset %v", updated, later)
+		}
+		if _, err := s.UpdateVersionStatus(ctx, v2.ID, "sunset", nil, v2.Revision); !errors.Is(err, errStaleRevision) {
+			t.Errorf("stale version update: err = %v, want errStaleRevision", err)
+		}
+
+		deprecated, err := s.ListVersions(ctx, api.ID, versionQuery{Status: []string{"deprecated"}})
+		if err != nil || len(deprecated) != 2 {
+			t.Errorf("status=deprecated: %d versions, %v; want 2", len(deprecated), err)
+		}
+		active, err := s.ListVersions(ctx, api.ID, versionQuery{Status: []string{"active"}})
+		if err != nil || len(active) != 0 {
+			t.Errorf("status=active: %d versions, %v; want 0", len(active), err)
+		}
+
+		if err := s.DeleteVersion(ctx, v1.ID, v1.Revision); err != nil {
+			t.Fatalf("DeleteVersion: %v", err)
+		}
+		if _, err := s.GetVersionByID(ctx, v1.ID); err == nil {
+			t.Error("deleted version is still found")
+		}
+	})
+}
+
+/* -------------------- notifications -------------------- */
+
+func TestStoreNotifications(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		api := seedAPI(t, s, org.ID, "Payments", nil)
+		v := seedVersion(t, s, api.ID, "v1", "active", nil)
+
+		past := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		jan := time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC)
+		feb := time.Date(2030, 2, 15, 9, 0, 0, 0, time.UTC)
+		due, err := s.CreateNotification(ctx, api.ID, v.ID, "deprecate", past, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n1, err := s.CreateNotification(ctx, api.ID, v.ID, "sunset", jan, "Europe/Berlin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.CreateNotification(ctx, api.ID, v.ID, "sunset", feb, ""); err != nil {
+			t.Fatal(err)
+		}
+		if !n1.ScheduledAt.Equal(jan) || n1.Timezone != "Europe/Berlin" || n1.Status != "pending" {
+			t.Errorf("created notice = %+v, want pending at %v in Europe/Berlin", n1, jan)
+		}
+
+		// date range compares timestamps, not strings
+		from, to := jan.Add(-time.Minute), feb
+		inJan, err := s.ListNotifications(ctx, api.ID, org.ID, notificationQuery{From: &from, To: &to})
+		if err != nil || len(inJan) != 1 || inJan[0].ID != n1.ID {
+			t.Errorf("January range = %v, %v; want only %s", inJan, err, n1.ID)
+		}
+		all := allPages(t, pageQuery{Limit: 1, Field: "scheduled_at"}, func(p pageQuery) ([]APINotification, error) {
+			return s.ListNotifications(ctx, api.ID, org.ID, notificationQuery{pageQuery: p})
+		})
+		if len(all) != 3 || all[0].ID != due.ID || !all[2].ScheduledAt.Equal(feb) {
+			t.Errorf("soonest first = %v, want the past notice first and February last", all)
+		}
+		if other, err := s.ListNotifications(ctx, api.ID, "another-org", notificationQuery{}); err != nil || len(other) != 0 {
+			t.Errorf("another org sees %d notices, %v; want none", len(other), err)
+		}
+
+		dueList, err := s.ListDueNotifications(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dueList) != 1 || dueList[0].NoteID != due.ID || dueList[0].Timezone != "UTC" || dueList[0].Locale != "en" {
+			t.Fatalf("due = %+v, want only %s in UTC/en", dueList, due.ID)
+		}
+		if err := s.DeferNotification(ctx, due.ID, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if dueList, _ := s.ListDueNotifications(ctx, 10); len(dueList) != 0 {
+			t.Errorf("deferred notice is still due")
+		}
+
+		if err := s.MarkNotificationSent(ctx, due.ID); err != nil {
+			t.Fatal(err)
+		}
+		sent, err := s.GetNotificationByID(ctx, due.ID)
+		if err != nil || sent.Status != "sent" || sent.Revision != 2 {
+			t.Errorf("after send = %+v, %v; want sent at revision 2", sent, err)
+		}
+
+		if _, err := s.UpdateNotificationStatus(ctx, n1.ID, "canceled", n1.Revision+1); !errors.Is(err, errStaleRevision) {
+			t.Errorf("stale notice update: err = %v, want errStaleRevision", err)
+		}
+		canceled, err := s.UpdateNotificationStatus(ctx, n1.ID, "canceled", n1.Revision)
+		if err != nil || canceled.Status != "canceled" {
+			t.Errorf("cancel = %+v, %v", canceled, err)
+		}
+	})
+}
+
+/* -------------------- sync -------------------- */
+
+func TestApplySyncIsAtomic(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		api := seedAPI(t, s, org.ID, "Payments", nil)
+		m, err := parseManifest(strings.NewReader(`
+version: 1
+apis:
+  - name: Payments
+    description: Card payments
+    versions:
+      - version: v1
+      - version: v2
+`))
+		if err != nil || len(m.validate()) > 0 {
+			t.Fatalf("manifest: %v %v", err, m.validate())
+		}
+		events := func() (n int) {
+			if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM events WHERE org_id = ?`, org.ID).Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+
+		plan, err := s.PlanSync(ctx, org.ID, m, false)
+		if err != nil || len(plan.Changes) != 3 {
+			t.Fatalf("plan = %v, %v; want the update and two creates", plan, err)
+		}
+		// the last change now collides with a version created meanwhile
+		seedVersion(t, s, api.ID, "v2", "active", nil)
+		if err := s.ApplySync(ctx, plan); err == nil || !strings.Contains(err.Error(), "create version Payments v2") {
+			t.Fatalf("apply over a conflicting version: err = %v", err)
+		}
+		got, err := s.GetAPIByID(ctx, api.ID)
+		if err != nil || got.Description != "" {
+			t.Errorf("description after the failed apply = %q, %v; want it unchanged", got.Description, err)
+		}
+		if vs, _ := s.ListVersions(ctx, api.ID, versionQuery{}); len(vs) != 1 {
+			t.Errorf("%d versions after the failed apply, want only the conflicting one", len(vs))
+		}
+		if n := events(); n != 0 {
+			t.Errorf("%d events kept from the failed apply, want none", n)
+		}
+
+		plan, err = s.PlanSync(ctx, org.ID, m, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ApplySync(ctx, plan); err != nil {
+			t.Fatalf("second apply: %v", err)
+		}
+		if vs, _ := s.ListVersions(ctx, api.ID, versionQuery{}); len(vs) != 2 {
+			t.Errorf("%d versions after the second apply, want 2", len(vs))
+		}
+		if n := events(); n != 2 {
+			t.Errorf("%d events after the second apply, want api.updated and version.created", n)
+		}
+	})
+}
+
+/* -------------------- migrations -------------------- */
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *dbConn) {
+		ctx := context.Background()
+		n, err := migrateDown(ctx, db, latestMigration()-1)
+		if err != nil || n != latestMigration()-1 {
+			t.Fatalf("down to the baseline: %d reverted, %v", n, err)
+		}
+		if _, err := migrateDown(ctx, db, 1); err == nil || !strings.Contains(err.Error(), "baseline cannot be reverted") {
+			t.Errorf("reverting the baseline: err = %v, want a refusal", err)
+		}
+		if pending, err := checkSchema(ctx, db); err != nil || len(pending) != latestMigration()-1 {
+			t.Errorf("after refused down: %d pending, %v; want the baseline kept and not dirty", len(pending), err)
+		}
+		if n, err := migrateUp(ctx, db, 0); err != nil || n != latestMigration()-1 {
+			t.Fatalf("up again: %d applied, %v", n, err)
+		}
+
+		// the re-applied schema works
+		s := NewStore(db)
+		_, org := seedOrg(t, s, "a@b.co")
+		if api := seedAPI(t, s, org.ID, "Payments", nil); api.Revision != 1 {
+			t.Errorf("revision after round trip = %d, want 1", api.Revision)
+		}
+	})
+}
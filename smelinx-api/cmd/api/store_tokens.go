@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// tokenPrefix marks personal access tokens, so the middleware can tell them
+// from session JWTs and secret scanners can find leaked ones.
+const tokenPrefix = "smx_pat_"
+
+// APIToken is a personal access token. The secret itself is only returned
+// once, on creation.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Token      string     `json:"token,omitempty"`
+}
+
+func hashToken(tok string) string {
+	sum := sha256.Sum256([]byte(tok))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAccessToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+const tokenCols = `id, name, prefix, role, created_at, expires_at, last_used_at`
+
+func scanAPIToken(sc interface{ Scan(...any) error }) (*APIToken, error) {
+	var t APIToken
+	var exp, used sql.NullTime
+	if err := sc.Scan(&t.ID, &t.Name, &t.Prefix, &t.Role, &t.CreatedAt, &exp, &used); err != nil {
+		return nil, err
+	}
+	if exp.Valid {
+		t.ExpiresAt = &exp.Time
+	}
+	if used.Valid {
+		t.LastUsedAt = &used.Time
+	}
+	return &t, nil
+}
+
+// CreateAPIToken issues a token acting as userID in orgID with role.
+// expiresAt nil means it does not expire.
+func (s *Store) CreateAPIToken(ctx context.Context, orgID, userID, role, name string, expiresAt *time.Time) (*APIToken, error) {
+	tok, err := newAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	var exp any
+	if expiresAt != nil {
+		exp = expiresAt.UTC().Format(time.RFC3339)
+	}
+	id := newID()
+	prefix := tok[:len(tokenPrefix)+6]
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_tokens (id, org_id, user_id, role, name, prefix, token_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, orgID, userID, role, name, prefix, hashToken(tok), time.Now().UTC().Format(time.RFC3339), exp); err != nil {
+		return nil, err
+	}
+	t, err := scanAPIToken(s.db.QueryRowContext(ctx, `SELECT `+tokenCols+` FROM api_tokens WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+	t.Token = tok
+	return t, nil
+}
+
+// ListAPITokens returns the user's unrevoked tokens in the org, newest first.
+func (s *Store) ListAPITokens(ctx context.Context, orgID, userID string) ([]APIToken, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+tokenCols+`
+		FROM api_tokens
+		WHERE org_id = ? AND user_id = ? AND revoked_at IS NULL
+		ORDER BY `+s.db.ts("created_at")+` DESC, `+s.db.insertOrder("")+` DESC`, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	return out, rows.Err()
+}
+
+// RevokeAPIToken revokes one of the user's tokens; sql.ErrNoRows if there
+// is no such live token.
+func (s *Store) RevokeAPIToken(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_tokens SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+var errTokenInvalid = errors.New("invalid token")
+
+// AuthenticateToken resolves a presented token to the claims it acts with
+// and records its use. Revoked, expired and unknown tokens are all
+// errTokenInvalid, as is a token whose user has left the org.
+func (s *Store) AuthenticateToken(ctx context.Context, tok string) (jwtClaims, error) {
+	if !strings.HasPrefix(tok, tokenPrefix) {
+		return jwtClaims{}, errTokenInvalid
+	}
+	var id string
+	var c jwtClaims
+	err := s.db.QueryRowContext(ctx, `
+		SELECT t.id, t.user_id, t.org_id, t.role
+		FROM api_tokens t
+		JOIN org_members m ON m.org_id = t.org_id AND m.user_id = t.user_id
+		WHERE t.token_hash = ? AND t.revoked_at IS NULL
+		  AND (t.expires_at IS NULL OR `+s.db.ts("t.expires_at")+` > `+s.db.now()+`)`,
+		hashToken(tok)).Scan(&id, &c.Sub, &c.OrgID, &c.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return jwtClaims{}, errTokenInvalid
+	}
+	if err != nil {
+		return jwtClaims{}, err
+	}
+	c.TokenID = id
+	_, _ = s.db.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339), id)
+	return c, nil
+}
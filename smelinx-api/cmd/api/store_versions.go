@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -13,14 +14,52 @@ type APIVersion struct {
 	Status     string     `json:"status"`                // active | deprecated | sunset
 	SunsetDate *time.Time `json:"sunset_date,omitempty"` // nullable
 	CreatedAt  time.Time  `json:"created_at"`
+	Revision   int64      `json:"revision"` // bumped on every change; the ETag
 }
 
-func (s *Store) ListVersions(ctx context.Context, apiID string) ([]APIVersion, error) {
+var versionStatuses = []string{"active", "deprecated", "sunset"}
+
+// versionQuery filters and pages ListVersions; the zero value lists every
+// version, newest first.
+type versionQuery struct {
+	pageQuery
+	Status []string
+}
+
+var versionSorts = map[string]sortField{
+	"created_at": {col: "created_at", time: true},
+	"version":    {col: "version"},
+}
+
+func (v APIVersion) cursorID() string { return v.ID }
+
+func (v APIVersion) cursorKey(field string) string {
+	if field == "version" {
+		return v.Version
+	}
+	return cursorTime(v.CreatedAt)
+}
+
+func (s *Store) ListVersions(ctx context.Context, apiID string, q versionQuery) ([]APIVersion, error) {
+	if q.Field == "" {
+		q.Field, q.Desc = "created_at", true
+	}
+	where, args := []string{"api_id = ?"}, []any{apiID}
+	if len(q.Status) > 0 {
+		in, inArgs := inClause("status", q.Status)
+		where = append(where, in)
+		args = append(args, inArgs...)
+	}
+	after, afterArgs, order := s.keyset("", versionSorts, q.pageQuery)
+	if after != "" {
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, api_id, version, status, sunset_date, created_at
+		SELECT id, api_id, version, status, sunset_date, created_at, revision
 		FROM api_versions
-		WHERE api_id = ?
-		ORDER BY created_at DESC`, apiID)
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+q.limitClause(), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +69,7 @@ func (s *Store) ListVersions(ctx context.Context, apiID string) ([]APIVersion, e
 	for rows.Next() {
 		var v APIVersion
 		var sd sql.NullTime
-		if err := rows.Scan(&v.ID, &v.APIID, &v.Version, &v.Status, &sd, &v.CreatedAt); err != nil {
+		if err := rows.Scan(&v.ID, &v.APIID, &v.Version, &v.Status, &sd, &v.CreatedAt, &v.Revision); err != nil {
 			return nil, err
 		}
 		if sd.Valid {
@@ -59,9 +98,9 @@ func (s *Store) GetVersionByID(ctx context.Context, id string) (*APIVersion, err
 	var v APIVersion
 	var sd sql.NullTime
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, api_id, version, status, sunset_date, created_at
+		SELECT id, api_id, version, status, sunset_date, created_at, revision
 		FROM api_versions WHERE id = ?`, id).
-		Scan(&v.ID, &v.APIID, &v.Version, &v.Status, &sd, &v.CreatedAt)
+		Scan(&v.ID, &v.APIID, &v.Version, &v.Status, &sd, &v.CreatedAt, &v.Revision)
 	if err != nil {
 		return nil, err
 	}
@@ -71,17 +110,45 @@ func (s *Store) GetVersionByID(ctx context.Context, id string) (*APIVersion, err
 	return &v, nil
 }
 
-func (s *Store) UpdateVersionStatus(ctx context.Context, id, status string, sunset *time.Time) (*APIVersion, error) {
-	if _, err := s.db.ExecContext(ctx, `
+// UpdateVersionStatus saves the version if it is still at revision rev (0:
+// whatever it is at), else returns errStaleRevision.
+func (s *Store) UpdateVersionStatus(ctx context.Context, id, status string, sunset *time.Time, rev int64) (*APIVersion, error) {
+	check, checkArgs := revisionCheck(rev)
+	res, err := s.db.ExecContext(ctx, `
 		UPDATE api_versions
-		SET status = ?, sunset_date = ?
-		WHERE id = ?`, status, sunset, id); err != nil {
+		SET status = ?, sunset_date = ?, revision = revision + 1
+		WHERE id = ?`+check, append([]any{status, sunset, id}, checkArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := staleRevision(res, rev); err != nil {
 		return nil, err
 	}
 	return s.GetVersionByID(ctx, id)
 }
 
-func (s *Store) DeleteVersion(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM api_versions WHERE id = ?`, id)
-	return err
+func (s *Store) DeleteVersion(ctx context.Context, id string, rev int64) error {
+	check, checkArgs := revisionCheck(rev)
+	res, err := s.db.ExecContext(ctx, `DELETE FROM api_versions WHERE id = ?`+check, append([]any{id}, checkArgs...)...)
+	if err != nil {
+		return err
+	}
+	return staleRevision(res, rev)
+}
+
+// SuccessorVersion returns the newest active version of the API created after
+// versionID, or "" if there is none.
+func (s *Store) SuccessorVersion(ctx context.Context, apiID, versionID string) (string, error) {
+	var v string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT version
+		FROM api_versions
+		WHERE api_id = ? AND id <> ? AND status = 'active' AND deleted_at IS NULL
+		  AND `+s.db.ts("created_at")+` >= (SELECT `+s.db.ts("created_at")+` FROM api_versions WHERE id = ?)
+		ORDER BY `+s.db.ts("created_at")+` DESC
+		LIMIT 1`, apiID, versionID, versionID).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return v, err
 }
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// WebhookEndpoint is an org-configured URL receiving signed event payloads.
+// Events is the subscribed type list; empty means every event.
+type WebhookEndpoint struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Subscribed reports whether the endpoint wants events of type typ.
+// Entries may be exact ("version.sunset") or a prefix wildcard ("version.*").
+func (e WebhookEndpoint) Subscribed(typ string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, want := range e.Events {
+		if want == "*" || want == typ {
+			return true
+		}
+		if strings.HasSuffix(want, ".*") && strings.HasPrefix(typ, strings.TrimSuffix(want, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one attempt log entry for sending an event to an endpoint.
+type WebhookDelivery struct {
+	ID           string     `json:"id"`
+	EndpointID   string     `json:"endpoint_id"`
+	EventID      string     `json:"event_id"`
+	EventType    string     `json:"event_type"`
+	Status       string     `json:"status"` // pending | delivered | failed
+	Attempts     int        `json:"attempts"`
+	ResponseCode *int       `json:"response_code,omitempty"`
+	LastError    *string    `json:"last_error,omitempty"`
+	RetryAfter   *time.Time `json:"retry_after,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+/* -------------------- endpoints -------------------- */
+
+const webhookCols = `id, org_id, url, secret, COALESCE(description,''), events, active, created_at`
+
+func scanWebhookEndpoint(sc interface{ Scan(...any) error }) (*WebhookEndpoint, error) {
+	var e WebhookEndpoint
+	var events string
+	if err := sc.Scan(&e.ID, &e.OrgID, &e.URL, &e.Secret, &e.Description, &events, &e.Active, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	e.Events = splitList(events)
+	return &e, nil
+}
+
+func (s *Store) ListWebhookEndpoints(ctx context.Context, orgID string) ([]WebhookEndpoint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+webhookCols+`
+		FROM webhook_endpoints
+		WHERE org_id = ?
+		ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) CreateWebhookEndpoint(ctx context.Context, orgID, url, secret, desc string, events []string) (*WebhookEndpoint, error) {
+	id := newID()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_endpoints (id, org_id, url, secret, description, events)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		id, orgID, url, secret, desc, strings.Join(events, ",")); err != nil {
+		return nil, err
+	}
+	return s.GetWebhookEndpointByID(ctx, id)
+}
+
+func (s *Store) GetWebhookEndpointByID(ctx context.Context, id string) (*WebhookEndpoint, error) {
+	return scanWebhookEndpoint(s.db.QueryRowContext(ctx, `
+		SELECT `+webhookCols+` FROM webhook_endpoints WHERE id = ?`, id))
+}
+
+func (s *Store) UpdateWebhookEndpoint(ctx context.Context, id, url, desc string, events []string, active bool) (*WebhookEndpoint, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_endpoints
+		SET url = ?, description = ?, events = ?, active = ?
+		WHERE id = ?`,
+		url, desc, strings.Join(events, ","), active, id); err != nil {
+		return nil, err
+	}
+	return s.GetWebhookEndpointByID(ctx, id)
+}
+
+func (s *Store) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE endpoint_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/* -------------------- deliveries -------------------- */
+
+func insertWebhookDelivery(ctx context.Context, tx *txConn, endpointID string, ev *Event) error {
+	payload, err := eventEnvelope(ev)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status)
+		VALUES (?, ?, ?, ?, ?, 'pending')`,
+		newID(), endpointID, ev.ID, ev.Type, string(payload))
+	return err
+}
+
+// QueueWebhookDelivery queues an already recorded event for one endpoint and
+// returns the delivery id (used by "send test event"). The caller attempts it
+// inline, so the row starts leased: retry_after keeps the dispatcher away
+// until the lease runs out, and only then picks it up if the caller never
+// recorded an outcome.
+func (s *Store) QueueWebhookDelivery(ctx context.Context, endpointID string, ev *Event, lease time.Duration) (string, error) {
+	payload, err := eventEnvelope(ev)
+	if err != nil {
+		return "", err
+	}
+	id := newID()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status, retry_after)
+		VALUES (?, ?, ?, ?, ?, 'pending', ?)`,
+		id, endpointID, ev.ID, ev.Type, string(payload), time.Now().UTC().Add(lease).Format(time.RFC3339))
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+const webhookDeliveryCols = `id, endpoint_id, event_id, event_type, status, attempts, response_code, last_error, retry_after, created_at, delivered_at`
+
+func scanWebhookDelivery(sc interface{ Scan(...any) error }) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	var code sql.NullInt64
+	var lastErr sql.NullString
+	var retryAfter, deliveredAt sql.NullTime
+	if err := sc.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+		&code, &lastErr, &retryAfter, &d.CreatedAt, &deliveredAt); err != nil {
+		return nil, err
+	}
+	if code.Valid {
+		c := int(code.Int64)
+		d.ResponseCode = &c
+	}
+	if lastErr.Valid {
+		d.LastError = &lastErr.String
+	}
+	if retryAfter.Valid {
+		d.RetryAfter = &retryAfter.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, endpointID string, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+webhookDeliveryCols+`
+		FROM webhook_deliveries
+		WHERE endpoint_id = ?
+		ORDER BY `+s.db.ts("created_at")+` DESC
+		LIMIT ?`, endpointID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetWebhookDeliveryByID(ctx context.Context, id string) (*WebhookDelivery, error) {
+	return scanWebhookDelivery(s.db.QueryRowContext(ctx, `
+		SELECT `+webhookDeliveryCols+` FROM webhook_deliveries WHERE id = ?`, id))
+}
+
+// dueWebhookDelivery is what the webhook worker needs to send one delivery.
+type dueWebhookDelivery struct {
+	ID       string
+	URL      string
+	Secret   string
+	EventID  string
+	Payload  []byte
+	Attempts int
+}
+
+// ListDueWebhookDeliveries returns pending deliveries whose retry time has
+// passed, for active endpoints only.
+func (s *Store) ListDueWebhookDeliveries(ctx context.Context, limit int) ([]dueWebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	return s.queryDueWebhookDeliveries(ctx, `
+		AND (d.retry_after IS NULL OR `+s.db.ts("d.retry_after")+` <= `+s.db.now()+`)
+		ORDER BY d.created_at ASC
+		LIMIT ?`, limit)
+}
+
+// GetDueWebhookDelivery loads a single pending delivery regardless of its retry time.
+func (s *Store) GetDueWebhookDelivery(ctx context.Context, id string) (*dueWebhookDelivery, error) {
+	out, err := s.queryDueWebhookDeliveries(ctx, `AND d.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &out[0], nil
+}
+
+func (s *Store) queryDueWebhookDeliveries(ctx context.Context, tail string, args ...any) ([]dueWebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, e.url, e.secret, d.event_id, d.payload, d.attempts
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE d.status = 'pending' AND e.active = 1
+		`+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []dueWebhookDelivery
+	for rows.Next() {
+		var d dueWebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.URL, &d.Secret, &d.EventID, &payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) MarkWebhookDelivered(ctx context.Context, id string, attempts, code int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = ?, response_code = ?, last_error = NULL, retry_after = NULL, delivered_at = ?
+		WHERE id = ?`, attempts, code, time.Now().UTC().Format(time.RFC3339), id)
+	return err
+}
+
+func (s *Store) ScheduleWebhookRetry(ctx context.Context, id string, next time.Time, attempts, code int, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = ?, response_code = ?, last_error = ?, retry_after = ?
+		WHERE id = ?`, attempts, nullIfZero(code), lastErr, next.UTC().Format(time.RFC3339), id)
+	return err
+}
+
+func (s *Store) FailWebhookDelivery(ctx context.Context, id string, attempts, code int, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'failed', attempts = ?, response_code = ?, last_error = ?, retry_after = NULL
+		WHERE id = ?`, attempts, nullIfZero(code), lastErr, id)
+	return err
+}
+
+/* -------------------- small helpers -------------------- */
+
+// splitList splits a comma-separated column into trimmed, non-empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func nullIfZero(n int) any {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Declarative sync from a smelinx.yaml manifest.
+
+	version: 1
+	notify:                       # default cadence for every version with a sunset date
+	  before_sunset: [90d, 30d, 7d]
+	  on_sunset: true
+	  at: "09:00"                 # in the org's time zone
+	apis:
+	  - name: Payments
+	    docs_url: https://docs.example.com/payments
+	    auto_notify: true
+	    versions:
+	      - version: v1
+	        status: deprecated
+	        sunset_date: 2027-01-31
+	        notify: { before_sunset: [30d] }   # overrides the API / manifest cadence
+	      - version: v2
+
+APIs are matched by name and versions by version string. Optional API
+fields left out of the manifest are not managed; status defaults to active.
+
+A cadence expands into deprecate notices before the sunset date (and a
+sunset notice on the day). Those notices carry a "manifest:" dedupe key:
+sync creates the ones that are missing and cancels pending ones the
+manifest no longer asks for. Notices scheduled by hand are never touched.
+With prune, APIs and versions missing from the manifest are deleted.
+
+Applying a plan makes the same calls as the dashboard (and emits the same
+events) inside one transaction: a failure leaves the org as it was, and the
+same manifest can simply be applied again.
+*/
+
+const manifestFormat = 1
+
+type Manifest struct {
+	Version int              `yaml:"version"`
+	Notify  *manifestCadence `yaml:"notify"`
+	APIs    []manifestAPI    `yaml:"apis"`
+}
+
+type manifestAPI struct {
+	Name         string            `yaml:"name"`
+	Description  *string           `yaml:"description"`
+	BaseURL      *string           `yaml:"base_url"`
+	DocsURL      *string           `yaml:"docs_url"`
+	ContactEmail *string           `yaml:"contact_email"`
+	OwnerTeam    *string           `yaml:"owner_team"`
+	AutoNotify   *bool             `yaml:"auto_notify"`
+	PublicFeed   *bool             `yaml:"public_feed"`
+	Notify       *manifestCadence  `yaml:"notify"`
+	Versions     []manifestVersion `yaml:"versions"`
+}
+
+type manifestVersion struct {
+	Version    string           `yaml:"version"`
+	Status     string           `yaml:"status"`
+	SunsetDate string           `yaml:"sunset_date"`
+	Notify     *manifestCadence `yaml:"notify"`
+}
+
+type manifestCadence struct {
+	BeforeSunset []string `yaml:"before_sunset"` // "90d", "4w"
+	OnSunset     bool     `yaml:"on_sunset"`
+	At           string   `yaml:"at"` // HH:MM, default 09:00
+}
+
+// parseManifest reads YAML (or JSON, which is YAML) and rejects unknown
+// fields so a typo does not silently unmanage something.
+func parseManifest(r io.Reader) (*Manifest, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty manifest")
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// validate normalizes m and returns one message per problem.
+func (m *Manifest) validate() []string {
+	var problems []string
+	bad := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if m.Version != manifestFormat {
+		bad("version: want %d", manifestFormat)
+	}
+	checkCadence := func(path string, c *manifestCadence) {
+		if c == nil {
+			return
+		}
+		for _, d := range c.BeforeSunset {
+			if _, err := parseDays(d); err != nil {
+				bad("%s.notify.before_sunset: %v", path, err)
+			}
+		}
+		if c.At != "" {
+			if _, _, err := parseClock(c.At); err != nil {
+				bad("%s.notify.at: %v", path, err)
+			}
+		}
+	}
+	checkCadence("manifest", m.Notify)
+
+	names := map[string]bool{}
+	for i := range m.APIs {
+		a := &m.APIs[i]
+		path := fmt.Sprintf("apis[%d]", i)
+		a.Name = strings.TrimSpace(a.Name)
+		if a.Name == "" {
+			bad("%s.name: required", path)
+		} else if names[strings.ToLower(a.Name)] {
+			bad("%s.name: duplicate API %q", path, a.Name)
+		}
+		names[strings.ToLower(a.Name)] = true
+		if a.ContactEmail != nil && *a.ContactEmail != "" && !validEmail(*a.ContactEmail) {
+			bad("%s.contact_email: invalid", path)
+		}
+		checkCadence(path, a.Notify)
+
+		versions := map[string]bool{}
+		for j := range a.Versions {
+			v := &a.Versions[j]
+			vpath := fmt.Sprintf("%s.versions[%d]", path, j)
+			v.Version = strings.TrimSpace(v.Version)
+			if v.Version == "" {
+				bad("%s.version: required", vpath)
+			} else if versions[v.Version] {
+				bad("%s.version: duplicate version %q", vpath, v.Version)
+			}
+			versions[v.Version] = true
+			v.Status = strings.ToLower(strings.TrimSpace(v.Status))
+			if v.Status == "" {
+				v.Status = "active"
+			}
+			if !slices.Contains([]string{"active", "deprecated", "sunset"}, v.Status) {
+				bad("%s.status: must be active, deprecated or sunset", vpath)
+			}
+			if _, err := parseDatePtrYYYYMMDD(trimOptional(&v.SunsetDate)); err != nil {
+				bad("%s.sunset_date: want YYYY-MM-DD", vpath)
+			}
+			checkCadence(vpath, v.Notify)
+		}
+	}
+	return problems
+}
+
+// parseDays reads a cadence offset: "30d", "4w" or "0d".
+func parseDays(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	mult := 0
+	switch {
+	case strings.HasSuffix(s, "d"):
+		mult = 1
+	case strings.HasSuffix(s, "w"):
+		mult = 7
+	default:
+		return 0, fmt.Errorf("invalid offset %q (use e.g. 30d or 4w)", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid offset %q (use e.g. 30d or 4w)", s)
+	}
+	return n * mult, nil
+}
+
+/* -------------------- plan -------------------- */
+
+type syncChange struct {
+	Action string      `json:"action"` // create | update | delete | cancel
+	Kind   string      `json:"kind"`   // api | version | notification
+	Target string      `json:"target"`
+	Diff   []fieldDiff `json:"diff,omitempty"`
+
+	// apply runs on the store bound to ApplySync's transaction.
+	apply func(ctx context.Context, st *Store) error
+}
+
+type fieldDiff struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type syncPlan struct {
+	Changes []syncChange `json:"changes"`
+}
+
+// String renders the plan as a diff, one line per change.
+func (p *syncPlan) String() string {
+	if len(p.Changes) == 0 {
+		return "no changes\n"
+	}
+	var b bytes.Buffer
+	for _, c := range p.Changes {
+		sign := map[string]string{"create": "+", "update": "~", "delete": "-", "cancel": "-"}[c.Action]
+		fmt.Fprintf(&b, "%s %s %s", sign, c.Kind, c.Target)
+		if c.Action == "cancel" {
+			b.WriteString(" (cancel)")
+		}
+		for i, d := range c.Diff {
+			sep := ", "
+			if i == 0 {
+				sep = ": "
+			}
+			fmt.Fprintf(&b, "%s%s %s -> %s", sep, d.Field, showValue(d.From), showValue(d.To))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func showValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "(none)"
+	case string:
+		if x == "" {
+			return "(none)"
+		}
+		return strconv.Quote(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// syncer computes and applies the plan for one org.
+type syncer struct {
+	store *Store
+	org   *Org
+	loc   *time.Location
+	now   time.Time
+	plan  syncPlan
+}
+
+func (s *syncer) add(c syncChange) { s.plan.Changes = append(s.plan.Changes, c) }
+
+// PlanSync compares m with the org's current state. The plan's changes are
+// applied in order by ApplySync.
+func (st *Store) PlanSync(ctx context.Context, orgID string, m *Manifest, prune bool) (*syncPlan, error) {
+	org, err := st.GetOrgByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	s := &syncer{store: st, org: org, loc: zoneOrUTC(org.Timezone), now: time.Now().UTC()}
+	s.plan.Changes = []syncChange{}
+
+	current, err := st.ListAPIs(ctx, orgID, apiQuery{})
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]*API{}
+	for i := range current {
+		byName[strings.ToLower(current[i].Name)] = &current[i]
+	}
+	managed, err := st.listManifestNotifications(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]bool{}
+
+	var deletes []syncChange
+	for i := range m.APIs {
+		ma := &m.APIs[i]
+		api := byName[strings.ToLower(ma.Name)]
+		delete(byName, strings.ToLower(ma.Name))
+		apiRef, err := s.planAPI(ctx, ma, api)
+		if err != nil {
+			return nil, err
+		}
+
+		var versions []APIVersion
+		if api != nil {
+			if versions, err = st.ListVersions(ctx, api.ID, versionQuery{}); err != nil {
+				return nil, err
+			}
+		}
+		byVersion := map[string]*APIVersion{}
+		for j := range versions {
+			byVersion[versions[j].Version] = &versions[j]
+		}
+		for j := range ma.Versions {
+			mv := &ma.Versions[j]
+			v := byVersion[mv.Version]
+			delete(byVersion, mv.Version)
+			versionRef := s.planVersion(apiRef, ma, mv, v)
+			s.planNotices(apiRef, versionRef, ma, mv, m.Notify, managed, wanted)
+		}
+		if prune {
+			for _, v := range versions {
+				if _, gone := byVersion[v.Version]; gone {
+					deletes = append(deletes, s.deleteVersion(api, v))
+				}
+			}
+		}
+	}
+
+	// pending manifest notices nothing asks for any more
+	for key, n := range managed {
+		if !wanted[key] && n.Status == "pending" {
+			s.cancelNotice(ctx, n)
+		}
+	}
+	if prune {
+		for _, api := range current {
+			if _, gone := byName[strings.ToLower(api.Name)]; gone {
+				deletes = append(deletes, s.deleteAPI(api))
+			}
+		}
+	}
+	s.plan.Changes = append(s.plan.Changes, deletes...)
+	return &s.plan, nil
+}
+
+// ApplySync applies plan's changes in order in one transaction; if any
+// fails, none of them (nor their events) are kept.
+func (st *Store) ApplySync(ctx context.Context, plan *syncPlan) error {
+	return st.inTx(ctx, func(tx *Store) error {
+		for _, c := range plan.Changes {
+			if err := c.apply(ctx, tx); err != nil {
+				return fmt.Errorf("%s %s %s: %w", c.Action, c.Kind, c.Target, err)
+			}
+		}
+		return nil
+	})
+}
+
+// apiRef / versionRef resolve to the ID once the create has been applied.
+type apiRef struct{ api *API }
+type versionRef struct {
+	id     string
+	sunset *time.Time
+}
+
+func (s *syncer) planAPI(ctx context.Context, ma *manifestAPI, api *API) (*apiRef, error) {
+	ref := &apiRef{api: api}
+	st := s.store
+	if api == nil {
+		s.add(syncChange{Action: "create", Kind: "api", Target: ma.Name, apply: func(ctx context.Context, st *Store) error {
+			created, err := st.CreateAPI(ctx, s.org.ID, ma.Name, deref(ma.Description), &APIMeta{
+				BaseURL: ma.BaseURL, DocsURL: ma.DocsURL, ContactEmail: ma.ContactEmail, OwnerTeam: ma.OwnerTeam,
+			})
+			if err != nil {
+				return err
+			}
+			st.emitEvent(ctx, s.org.ID, "api.created", created.ID, "", created)
+			ref.api = created
+			return setAPIFlags(ctx, st, created.ID, ma.AutoNotify, ma.PublicFeed)
+		}})
+		return ref, nil
+	}
+
+	var diff []fieldDiff
+	cmp := func(field string, cur, want *string) {
+		if want != nil && deref(cur) != *want {
+			diff = append(diff, fieldDiff{Field: field, From: deref(cur), To: *want})
+		}
+	}
+	cmp("description", &api.Description, ma.Description)
+	cmp("base_url", api.BaseURL, ma.BaseURL)
+	cmp("docs_url", api.DocsURL, ma.DocsURL)
+	cmp("contact_email", api.ContactEmail, ma.ContactEmail)
+	cmp("owner_team", api.OwnerTeam, ma.OwnerTeam)
+	metaChanged := len(diff) > 0
+
+	autoNotify, err := st.APIAutoNotify(ctx, api.ID)
+	if err != nil {
+		return nil, err
+	}
+	publicFeed, err := st.APIFeedEnabled(ctx, api.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ma.AutoNotify != nil && *ma.AutoNotify != autoNotify {
+		diff = append(diff, fieldDiff{Field: "auto_notify", From: autoNotify, To: *ma.AutoNotify})
+	}
+	if ma.PublicFeed != nil && *ma.PublicFeed != publicFeed {
+		diff = append(diff, fieldDiff{Field: "public_feed", From: publicFeed, To: *ma.PublicFeed})
+	}
+	if len(diff) == 0 {
+		return ref, nil
+	}
+
+	s.add(syncChange{Action: "update", Kind: "api", Target: api.Name, Diff: diff, apply: func(ctx context.Context, st *Store) error {
+		if metaChanged {
+			orNil := func(cur, want *string) *string {
+				if want != nil {
+					return nilIfBlank(*want)
+				}
+				return cur
+			}
+			desc := api.Description
+			if ma.Description != nil {
+				desc = *ma.Description
+			}
+			updated, err := st.UpdateAPI(ctx, api.ID, api.Name, desc, &APIMeta{
+				BaseURL:      orNil(api.BaseURL, ma.BaseURL),
+				DocsURL:      orNil(api.DocsURL, ma.DocsURL),
+				ContactEmail: orNil(api.ContactEmail, ma.ContactEmail),
+				OwnerTeam:    orNil(api.OwnerTeam, ma.OwnerTeam),
+			}, 0)
+			if err != nil {
+				return err
+			}
+			st.emitEvent(ctx, s.org.ID, "api.updated", updated.ID, "", updated)
+		}
+		return setAPIFlags(ctx, st, api.ID, ma.AutoNotify, ma.PublicFeed)
+	}})
+	return ref, nil
+}
+
+func nilIfBlank(s string) *string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return &s
+}
+
+func setAPIFlags(ctx context.Context, st *Store, apiID string, autoNotify, publicFeed *bool) error {
+	if autoNotify != nil {
+		if err := st.SetAPIAutoNotify(ctx, apiID, *autoNotify); err != nil {
+			return err
+		}
+	}
+	if publicFeed != nil {
+		if err := st.SetAPIFeed(ctx, apiID, *publicFeed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncer) planVersion(ref *apiRef, ma *manifestAPI, mv *manifestVersion, v *APIVersion) *versionRef {
+	sunset, _ := parseDatePtrYYYYMMDD(trimOptional(&mv.SunsetDate))
+	vref := &versionRef{sunset: sunset}
+	target := ma.Name + " " + mv.Version
+	if v == nil {
+		var diff []fieldDiff
+		if mv.Status != "active" {
+			diff = append(diff, fieldDiff{Field: "status", From: nil, To: mv.Status})
+		}
+		if sunset != nil {
+			diff = append(diff, fieldDiff{Field: "sunset_date", From: nil, To: mv.SunsetDate})
+		}
+		s.add(syncChange{Action: "create", Kind: "version", Target: target, Diff: diff, apply: func(ctx context.Context, st *Store) error {
+			created, err := st.CreateVersion(ctx, ref.api.ID, mv.Version, mv.Status, sunset)
+			if err != nil {
+				return err
+			}
+			st.emitEvent(ctx, s.org.ID, "version.created", ref.api.ID, created.ID, versionEventData(ref.api, created))
+			vref.id = created.ID
+			return nil
+		}})
+		return vref
+	}
+
+	vref.id = v.ID
+	var diff []fieldDiff
+	if v.Status != mv.Status {
+		diff = append(diff, fieldDiff{Field: "status", From: v.Status, To: mv.Status})
+	}
+	if !sameDate(v.SunsetDate, sunset) {
+		var from any
+		if v.SunsetDate != nil {
+			from = v.SunsetDate.UTC().Format("2006-01-02")
+		}
+		diff = append(diff, fieldDiff{Field: "sunset_date", From: from, To: mv.SunsetDate})
+	}
+	if len(diff) == 0 {
+		return vref
+	}
+	s.add(syncChange{Action: "update", Kind: "version", Target: target, Diff: diff, apply: func(ctx context.Context, st *Store) error {
+		updated, err := st.UpdateVersionStatus(ctx, v.ID, mv.Status, sunset, 0)
+		if err != nil {
+			return err
+		}
+		st.emitEvent(ctx, s.org.ID, versionUpdateEvent(v.Status, updated.Status), ref.api.ID, updated.ID, versionEventData(ref.api, updated))
+		enqueueAutoNotice(ctx, st, ref.api, v, updated)
+		return nil
+	}})
+	return vref
+}
+
+// planNotices expands the version's cadence and adds the future notices that
+// do not exist yet; wanted collects every key the manifest asks for.
+func (s *syncer) planNotices(ref *apiRef, vref *versionRef, ma *manifestAPI, mv *manifestVersion, def *manifestCadence,
+	managed map[string]*APINotification, wanted map[string]bool) {
+	c := mv.Notify
+	if c == nil {
+		c = ma.Notify
+	}
+	if c == nil {
+		c = def
+	}
+	if c == nil || vref.sunset == nil {
+		return
+	}
+	at := c.At
+	if at == "" {
+		at = "09:00"
+	}
+	type notice struct {
+		typ  string
+		days int
+	}
+	var notices []notice
+	for _, d := range c.BeforeSunset {
+		days, _ := parseDays(d)
+		notices = append(notices, notice{"deprecate", days})
+	}
+	if c.OnSunset {
+		notices = append(notices, notice{"sunset", 0})
+	}
+
+	date := vref.sunset.UTC().Format("2006-01-02")
+	for _, n := range notices {
+		day := vref.sunset.UTC().AddDate(0, 0, -n.days).Format("2006-01-02")
+		when, err := parseWhen(day, s.loc, at)
+		if err != nil || !when.After(s.now) {
+			continue
+		}
+		// the key names the version by API and version string so it is
+		// known before a new version has an ID
+		key := manifestNoticeKey(ma.Name, mv.Version, n.typ, when)
+		wanted[key] = true
+		if existing, ok := managed[key]; ok && existing.Status != "canceled" {
+			continue
+		}
+		target := fmt.Sprintf("%s %s %s %s (sunset %s)", ma.Name, mv.Version, n.typ, when.In(s.loc).Format("2006-01-02 15:04 MST"), date)
+		s.add(syncChange{Action: "create", Kind: "notification", Target: target, apply: func(ctx context.Context, st *Store) error {
+			note, err := st.createManifestNotification(ctx, ref.api.ID, vref.id, n.typ, when, s.loc.String(), key)
+			if err != nil {
+				return err
+			}
+			st.emitEvent(ctx, s.org.ID, "notification.scheduled", ref.api.ID, vref.id, note)
+			return nil
+		}})
+	}
+}
+
+func manifestNoticeKey(api, version, typ string, when time.Time) string {
+	return "manifest:" + strings.ToLower(api) + ":" + version + ":" + typ + ":" + when.UTC().Format(time.RFC3339)
+}
+
+func (s *syncer) cancelNotice(ctx context.Context, n *APINotification) {
+	st := s.store
+	api, version := "", ""
+	if a, err := st.GetAPIByID(ctx, n.APIID); err == nil {
+		api = a.Name
+	}
+	if v, err := st.GetVersionByID(ctx, n.VersionID); err == nil {
+		version = v.Version
+	}
+	target := fmt.Sprintf("%s %s %s %s", api, version, n.Type, n.ScheduledAt.In(s.loc).Format("2006-01-02 15:04 MST"))
+	s.add(syncChange{Action: "cancel", Kind: "notification", Target: target, apply: func(ctx context.Context, st *Store) error {
+		updated, err := st.UpdateNotificationStatus(ctx, n.ID, "canceled", 0)
+		if err != nil {
+			return err
+		}
+		st.emitEvent(ctx, s.org.ID, "notification.canceled", n.APIID, n.VersionID, updated)
+		return nil
+	}})
+}
+
+func (s *syncer) deleteVersion(api *API, v APIVersion) syncChange {
+	return syncChange{Action: "delete", Kind: "version", Target: api.Name + " " + v.Version, apply: func(ctx context.Context, st *Store) error {
+		if err := st.DeleteVersion(ctx, v.ID, 0); err != nil {
+			return err
+		}
+		st.emitEvent(ctx, s.org.ID, "version.deleted", api.ID, v.ID, versionEventData(api, &v))
+		return nil
+	}}
+}
+
+func (s *syncer) deleteAPI(api API) syncChange {
+	return syncChange{Action: "delete", Kind: "api", Target: api.Name, apply: func(ctx context.Context, st *Store) error {
+		if err := st.DeleteAPI(ctx, api.ID, 0); err != nil {
+			return err
+		}
+		st.emitEvent(ctx, s.org.ID, "api.deleted", api.ID, "", api)
+		return nil
+	}}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+/*
+Notification templates.
+
+Subject and plain-text bodies are Go text/template, the HTML body is Go
+html/template (values are escaped for their HTML context). Every template is
+executed against templateData; the documented variables are:
+
+	{{.OrgName}}       organisation sending the notice
+	{{.APIName}}       API name
+	{{.Version}}       version label, e.g. "v1"
+	{{.Type}}          "deprecate" | "sunset"
+	{{.Title}}         "Deprecation Notice" | "Sunset Notice" (localized)
+	{{.SunsetDate}}    version sunset date in the locale's format, empty if unset
+	{{.ScheduledAt}}   when the notice was scheduled, in the recipient's zone and
+	                   the locale's format (RFC 1123 for English)
+	{{.Timezone}}      IANA zone ScheduledAt is shown in, e.g. "Europe/Berlin"
+	{{.Successor}}     newest active version of the same API, empty if none
+	{{.DocsURL}}       API docs URL, empty if unset
+	{{.BaseURL}}       API base URL, empty if unset
+	{{.ConsumerName}}  recipient consumer name, empty for the API contact
+	{{.Locale}}        recipient locale, e.g. "de" (see i18n.go)
+
+{{t "key"}} looks a string up in the message catalog for .Locale.
+
+Templates are stored per locale. A recipient gets the org's template for
+their locale, else the org's locale-neutral one, else the built-in default
+(which is fully translated).
+*/
+
+type templateData struct {
+	OrgName      string
+	APIName      string
+	Version      string
+	Type         string
+	Title        string
+	SunsetDate   string
+	ScheduledAt  string
+	Timezone     string
+	Successor    string
+	DocsURL      string
+	BaseURL      string
+	ConsumerName string
+	AckURL       string
+	Locale       string
+}
+
+// templateVariables documents templateData for API clients (GET /templates).
+var templateVariables = map[string]string{
+	"OrgName":      "organisation sending the notice",
+	"APIName":      "API name",
+	"Version":      "version label, e.g. v1",
+	"Type":         "deprecate | sunset",
+	"Title":        "Deprecation Notice | Sunset Notice",
+	"SunsetDate":   "version sunset date in the locale's format, empty if unset",
+	"ScheduledAt":  "when the notice was scheduled, in the recipient's zone and locale's format",
+	"Timezone":     "IANA zone ScheduledAt is shown in, e.g. Europe/Berlin",
+	"Successor":    "newest active version of the same API, empty if none",
+	"DocsURL":      "API docs URL, empty if unset",
+	"BaseURL":      "API base URL, empty if unset",
+	"ConsumerName": "recipient consumer name, empty for the API contact",
+	"AckURL":       "signed acknowledge / migrated link, empty for the API contact",
+	"Locale":       "recipient locale (en, de, ja); use {{t \"key\"}} for catalog strings",
+}
+
+// noticeTemplate is the raw source of one notification template.
+type noticeTemplate struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text,omitempty"` // optional; derived from HTML when empty
+}
+
+// renderedNotice is a notice ready to hand to a Mailer.
+type renderedNotice struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+const defaultHTMLTemplate = `<div style="font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;line-height:1.5;color:#111">` +
+	`<h2 style="margin:0 0 12px 0">{{.Title}}</h2>` +
+	`<p style="margin:0 0 8px 0"><b>{{t "label.api"}}:</b> {{.APIName}}<br/>` +
+	`<b>{{t "label.version"}}:</b> {{.Version}}<br/>` +
+	`<b>{{t "label.scheduled_at"}}:</b> {{.ScheduledAt}}</p>` +
+	`{{if .BaseURL}}<p style="margin:8px 0"><b>{{t "label.base_url"}}:</b> <a href="{{.BaseURL}}">{{.BaseURL}}</a></p>{{end}}` +
+	`{{if .DocsURL}}<p style="margin:8px 0"><b>{{t "label.docs"}}:</b> <a href="{{.DocsURL}}">{{.DocsURL}}</a></p>{{end}}` +
+	`{{if .AckURL}}<p style="margin:16px 0"><a href="{{.AckURL}}">{{t "ack.link"}}</a></p>{{end}}` +
+	`<p style="margin-top:16px">{{t "footer.questions"}}</p>` +
+	`</div>`
+
+// defaultTemplates are the built-in notices used when an org has none stored.
+var defaultTemplates = map[string]noticeTemplate{
+	"deprecate": {Subject: `[Smelinx] {{t "subject.deprecate"}} – {{.APIName}} {{.Version}}`, HTML: defaultHTMLTemplate},
+	"sunset":    {Subject: `[Smelinx] {{t "subject.sunset"}} – {{.APIName}} {{.Version}}`, HTML: defaultHTMLTemplate},
+}
+
+// defaultTemplate returns the built-in template for typ.
+func defaultTemplate(typ string) noticeTemplate {
+	if t, ok := defaultTemplates[typ]; ok {
+		return t
+	}
+	return noticeTemplate{Subject: `[Smelinx] {{t "subject.other"}} – {{.APIName}} {{.Version}}`, HTML: defaultHTMLTemplate}
+}
+
+// newTemplateData builds the variable set for a due notification sent to one
+// recipient in their zone and locale; consumers also get a signed
+// acknowledgement link.
+func newTemplateData(d dueNotification, successor string, to recipient) templateData {
+	loc, lang := recipientZone(to, d), recipientLocale(to, d)
+	td := templateData{
+		OrgName:      d.OrgName,
+		APIName:      d.APIName,
+		Version:      d.Version,
+		Type:         d.Type,
+		Title:        noticeTitle(lang, d.Type),
+		ScheduledAt:  formatDateTime(lang, d.ScheduledAt.In(loc)),
+		Timezone:     loc.String(),
+		Successor:    successor,
+		DocsURL:      strings.TrimSpace(d.DocsURL.String),
+		BaseURL:      strings.TrimSpace(d.BaseURL.String),
+		ConsumerName: to.Name,
+		AckURL:       noticeAckURL(to, d),
+		Locale:       lang,
+	}
+	if d.SunsetDate.Valid {
+		td.SunsetDate = formatDate(lang, d.SunsetDate.Time)
+	}
+	return td
+}
+
+// render executes the template against td.
+func (t noticeTemplate) render(td templateData) (renderedNotice, error) {
+	var out renderedNotice
+	funcs := map[string]any{"t": func(key string) string { return tr(td.Locale, key) }}
+
+	st, err := texttemplate.New("subject").Funcs(funcs).Parse(t.Subject)
+	if err != nil {
+		return out, fmt.Errorf("subject: %w", err)
+	}
+	var sb bytes.Buffer
+	if err := st.Execute(&sb, td); err != nil {
+		return out, fmt.Errorf("subject: %w", err)
+	}
+	// subjects are single-line headers
+	out.Subject = strings.Join(strings.Fields(sb.String()), " ")
+
+	ht, err := htmltemplate.New("html").Funcs(funcs).Parse(t.HTML)
+	if err != nil {
+		return out, fmt.Errorf("html: %w", err)
+	}
+	var hb bytes.Buffer
+	if err := ht.Execute(&hb, td); err != nil {
+		return out, fmt.Errorf("html: %w", err)
+	}
+	out.HTML = hb.String()
+
+	if strings.TrimSpace(t.Text) == "" {
+		out.Text = htmlToText(out.HTML)
+		return out, nil
+	}
+	tt, err := texttemplate.New("text").Funcs(funcs).Parse(t.Text)
+	if err != nil {
+		return out, fmt.Errorf("text: %w", err)
+	}
+	var tb bytes.Buffer
+	if err := tt.Execute(&tb, td); err != nil {
+		return out, fmt.Errorf("text: %w", err)
+	}
+	out.Text = tb.String()
+	return out, nil
+}
+
+// renderNotice renders d for one recipient using the org's active template
+// for d.Type, falling back to the built-in default if none is stored or the
+// stored one fails to render.
+func renderNotice(ctx context.Context, store *Store, d dueNotification, to recipient) renderedNotice {
+	successor, err := store.SuccessorVersion(ctx, d.APIID, d.VersionID)
+	if err != nil {
+		log.Printf("[templates] successor lookup failed api=%s: %v", d.APIID, err)
+	}
+	td := newTemplateData(d, successor, to)
+
+	if tpl, err := store.GetActiveTemplate(ctx, d.OrgID, d.Type, td.Locale); err == nil {
+		out, err := tpl.Template().render(td)
+		if err == nil {
+			return out
+		}
+		log.Printf("[templates] org=%s type=%s locale=%s v%d failed to render, using default: %v", d.OrgID, d.Type, tpl.Locale, tpl.Version, err)
+	}
+
+	out, err := defaultTemplate(d.Type).render(td)
+	if err != nil {
+		// the built-in templates are static; this only happens on a programming error
+		log.Printf("[templates] default template failed: %v", err)
+	}
+	return out
+}
+
+// sampleNotification is the fixed data used by template previews.
+func sampleNotification(orgName, typ string) (dueNotification, string, recipient) {
+	d := dueNotification{
+		OrgName:     orgName,
+		VersionID:   "sample-version",
+		APIName:     "Payments API",
+		Version:     "v1",
+		Type:        typ,
+		ScheduledAt: time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC),
+	}
+	d.SunsetDate.Valid = true
+	d.SunsetDate.Time = time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC)
+	d.DocsURL.Valid = true
+	d.DocsURL.String = "https://docs.example.com/payments/v2"
+	d.BaseURL.Valid = true
+	d.BaseURL.String = "https://api.example.com/payments"
+	return d, "v2", recipient{Channel: "email", ConsumerID: "sample-consumer", Name: "Example Consumer"}
+}
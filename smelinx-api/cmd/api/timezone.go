@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// the runtime image may not ship a zoneinfo database
+	_ "time/tzdata"
+)
+
+// defaultSendTime is the local time of day a date-only scheduled_at is sent at.
+const defaultSendTime = "09:00"
+
+// loadZone validates an IANA zone name ("" means UTC).
+func loadZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	return loc, nil
+}
+
+// zoneOrUTC returns the first valid zone among names, falling back to UTC.
+func zoneOrUTC(names ...string) *time.Location {
+	for _, n := range names {
+		if strings.TrimSpace(n) == "" {
+			continue
+		}
+		if loc, err := loadZone(n); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// parseClock parses "HH:MM" (24h) into hours and minutes.
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, fmt.Errorf("time of day must be HH:MM (got %q)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// quietUntil reports whether now falls inside the daily quiet window
+// [start, end) in loc (the window may wrap midnight, e.g. 22:00–07:00) and,
+// if so, when it ends.
+func quietUntil(now time.Time, loc *time.Location, start, end string) (time.Time, bool) {
+	sh, sm, err1 := parseClock(start)
+	eh, em, err2 := parseClock(end)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+	local := now.In(loc)
+	at := func(day time.Time, h, m int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+	}
+	startToday, endToday := at(local, sh, sm), at(local, eh, em)
+
+	switch {
+	case startToday.Equal(endToday):
+		return time.Time{}, false
+	case startToday.Before(endToday): // same-day window
+		if !local.Before(startToday) && local.Before(endToday) {
+			return endToday, true
+		}
+	default: // wraps midnight
+		if local.Before(endToday) {
+			return endToday, true
+		}
+		if !local.Before(startToday) {
+			return at(local.AddDate(0, 0, 1), eh, em), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// startBackupWorker takes a backup every BACKUP_INTERVAL (a Go duration such
+// as "6h"); unset disables scheduled backups. SQLite only.
+func startBackupWorker(store *Store) {
+	raw := os.Getenv("BACKUP_INTERVAL")
+	if raw == "" {
+		return
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < time.Minute {
+		log.Printf("[backup] invalid BACKUP_INTERVAL %q (want a duration of at least 1m); scheduled backups disabled", raw)
+		return
+	}
+	if store.db.driver() != "sqlite" {
+		log.Printf("[backup] %v; scheduled backups disabled", errBackupUnsupported)
+		return
+	}
+	dir := backupDir()
+
+	go func() {
+		log.Printf("[backup] worker started (interval=%s, dir=%s, retain=%d)", interval, dir, backupRetain())
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for range t.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			b, err := store.Backup(ctx, dir)
+			cancel()
+			if err != nil {
+				log.Printf("[backup] error: %v", err)
+				continue
+			}
+			log.Printf("[backup] wrote %s (%d bytes)", b.Name, b.Size)
+		}
+	}()
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+/* -------------------- consumer digest -------------------- */
+
+// startConsumerDigestWorker mails consumers on the weekly digest the notices
+// held back for them by the dispatcher (see routeForConsumer).
+func startConsumerDigestWorker(store *Store, mailer Mailer) {
+	interval := time.Hour
+
+	go func() {
+		log.Printf("[digest] consumer digest worker started (interval=%s)", interval)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			if err := sendConsumerDigestsOnce(store, mailer); err != nil {
+				log.Printf("[digest] consumer digest error: %v", err)
+			}
+			<-t.C
+		}
+	}()
+}
+
+func sendConsumerDigestsOnce(store *Store, mailer Mailer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	consumers, err := store.ListDigestConsumersDue(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range consumers {
+		items, err := store.ListDigestItems(ctx, c.ID)
+		if err != nil {
+			log.Printf("[digest] load items failed consumer=%s: %v", c.ID, err)
+			continue
+		}
+		if sup, err := store.GetEmailSuppression(ctx, c.OrgID, *c.Email); err == nil {
+			log.Printf("[digest] consumer=%s address suppressed (%s); dropping digest", c.ID, sup.Reason)
+			items = nil
+		}
+		if len(items) > 0 {
+			msg, err := consumerDigestMessage(c, items)
+			if err == nil {
+				err = mailer.SendMessage(msg)
+			}
+			if err != nil {
+				// leave the queue alone; the next tick retries
+				log.Printf("[digest] send failed consumer=%s: %v", c.ID, err)
+				continue
+			}
+		}
+		if err := store.MarkDigestSent(ctx, c.ID); err != nil {
+			log.Printf("[digest] mark sent failed consumer=%s: %v", c.ID, err)
+			continue
+		}
+		log.Printf("[digest] sent consumer=%s items=%d", c.ID, len(items))
+	}
+	return nil
+}
+
+// consumerDigestTemplate is cloned per message to bind "t" to its locale.
+var consumerDigestTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{"t": func(string) string { return "" }}).Parse(
+	`<div style="font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;line-height:1.5;color:#111">` +
+		`<h2 style="margin:0 0 12px 0">{{t "digest.heading"}}</h2>` +
+		`<p>{{.Intro}}</p>` +
+		`<ul>{{range .Items}}<li style="margin-bottom:8px"><b>{{.Title}}</b> – {{.APIName}} {{.Version}}` +
+		`{{if .SunsetDate}}<br/>{{t "label.sunset_date"}}: {{.SunsetDate}}{{end}}` +
+		`{{if .DocsURL}}<br/><a href="{{.DocsURL}}">{{.DocsURL}}</a>{{end}}` +
+		`{{if .AckURL}}<br/><a href="{{.AckURL}}">{{t "ack.link"}}</a>{{end}}</li>{{end}}</ul>` +
+		`</div>`))
+
+// consumerDigestMessage renders the digest email for one consumer in their
+// locale, else the org default (carried on the items), like every notice.
+func consumerDigestMessage(c Consumer, items []dueNotification) (Message, error) {
+	to := consumerRecipient(recipient{Channel: "email", Target: *c.Email}, &c)
+	locale := defaultLocale
+	if len(items) > 0 {
+		locale = recipientLocale(to, items[0])
+	}
+	to.Locale = locale
+
+	data := struct {
+		Intro string
+		Items []templateData
+	}{Intro: fmt.Sprintf(tr(locale, "digest.intro"), c.Name)}
+	for _, d := range items {
+		data.Items = append(data.Items, newTemplateData(d, "", to))
+	}
+
+	tpl, err := consumerDigestTemplate.Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	tpl.Funcs(template.FuncMap{"t": func(key string) string { return tr(locale, key) }})
+	var b bytes.Buffer
+	if err := tpl.Execute(&b, data); err != nil {
+		return Message{}, err
+	}
+	msg := Message{
+		To:      *c.Email,
+		Subject: fmt.Sprintf(tr(locale, "digest.subject"), len(items)),
+		HTML:    b.String(),
+	}
+	msg.Tags = emailTags(c.OrgID, "")
+	addPreferencesFooter(&msg, c.ID, locale)
+	return msg, nil
+}
+
+/* -------------------- member digest -------------------- */
+
+// startMemberDigestWorker emails each org member a summary of what needs
+// attention, daily or weekly (Mondays) at DIGEST_HOUR_UTC (default 7).
+func startMemberDigestWorker(store *Store, mailer Mailer) {
+	interval := 15 * time.Minute
+	hour := digestHourUTC()
+
+	go func() {
+		log.Printf("[digest] member digest worker started (interval=%s, hour=%02d:00 UTC)", interval, hour)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			if err := sendMemberDigestsOnce(store, mailer, time.Now().UTC(), hour); err != nil {
+				log.Printf("[digest] member digest error: %v", err)
+			}
+			<-t.C
+		}
+	}()
+}
+
+// digestHourUTC reads DIGEST_HOUR_UTC, 0-23 (default 7). Unlike getenvInt
+// it accepts 0 (midnight); anything else out of range is logged and ignored.
+func digestHourUTC() int {
+	v := strings.TrimSpace(os.Getenv("DIGEST_HOUR_UTC"))
+	if v == "" {
+		return 7
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > 23 {
+		log.Printf("[digest] DIGEST_HOUR_UTC=%q is not an hour from 0 to 23; using 7", v)
+		return 7
+	}
+	return n
+}
+
+func sendMemberDigestsOnce(store *Store, mailer Mailer, now time.Time, hour int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	members, err := store.ListMemberDigestSettings(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.LastSentAt != nil && !m.LastSentAt.Before(digestSlot(m.Frequency, now, hour)) {
+			continue
+		}
+		d, err := buildMemberDigest(ctx, store, m, now)
+		if err != nil {
+			log.Printf("[digest] build failed org=%s user=%s: %v", m.OrgID, m.UserID, err)
+			continue
+		}
+		if !d.Empty() {
+			msg, err := d.Message(m.Email)
+			if err == nil {
+				err = mailer.SendMessage(msg)
+			}
+			if err != nil {
+				log.Printf("[digest] send failed org=%s user=%s: %v", m.OrgID, m.UserID, err)
+				continue
+			}
+		}
+		if err := store.MarkMemberDigestSent(ctx, m.OrgID, m.UserID, now); err != nil {
+			log.Printf("[digest] mark sent failed org=%s user=%s: %v", m.OrgID, m.UserID, err)
+			continue
+		}
+		log.Printf("[digest] member digest org=%s user=%s empty=%t", m.OrgID, m.UserID, d.Empty())
+	}
+	return nil
+}
+
+// digestSlot is the most recent scheduled send time at or before now: today
+// (or yesterday) at hour for daily digests, the latest Monday for weekly ones.
+func digestSlot(frequency string, now time.Time, hour int) time.Time {
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if frequency == "weekly" {
+		for slot.Weekday() != time.Monday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+type digestSunset struct {
+	APIName     string
+	Version     string
+	Date        string
+	DaysLeft    int
+	Subscribed  int
+	Outstanding []string // consumers that have not acknowledged or migrated
+}
+
+type digestChange struct {
+	APIName    string
+	Version    string
+	SunsetDate string
+	At         string
+}
+
+type digestFailure struct {
+	APIName string
+	Version string
+	Type    string
+	Error   string
+	At      string
+}
+
+// memberDigest is everything one member's summary email reports.
+type memberDigest struct {
+	OrgName      string
+	Frequency    string
+	HorizonDays  int
+	Since        time.Time
+	Sunsets      []digestSunset
+	Deprecated   []digestChange
+	Failed       []digestFailure
+	Retrying     []retryingNotification
+	DashboardURL string
+}
+
+func (d *memberDigest) Empty() bool {
+	return len(d.Sunsets) == 0 && len(d.Deprecated) == 0 && len(d.Failed) == 0 && len(d.Retrying) == 0
+}
+
+// buildMemberDigest gathers the digest for m as of now. The reporting window
+// starts at the previous digest (or one period back for the first one).
+func buildMemberDigest(ctx context.Context, store *Store, m MemberDigestSettings, now time.Time) (*memberDigest, error) {
+	period := 7 * 24 * time.Hour
+	if m.Frequency == "daily" {
+		period = 24 * time.Hour
+	}
+	since := now.Add(-period)
+	if m.LastSentAt != nil {
+		since = *m.LastSentAt
+	}
+	d := &memberDigest{
+		OrgName:      m.OrgName,
+		Frequency:    m.Frequency,
+		HorizonDays:  m.HorizonDays,
+		Since:        since,
+		DashboardURL: getenv("DASHBOARD_URL", "http://localhost:3000"),
+	}
+
+	// upcoming sunsets and who still has to move
+	entries, err := store.ListCalendarEntries(ctx, m.OrgID, "")
+	if err != nil {
+		return nil, err
+	}
+	until := now.AddDate(0, 0, m.HorizonDays)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	for _, e := range entries {
+		if e.Kind != "sunset" || e.Date.After(until) {
+			continue
+		}
+		s := digestSunset{
+			APIName:  e.APIName,
+			Version:  e.Version,
+			Date:     e.Date.Format("2006-01-02"),
+			DaysLeft: int(e.Date.Sub(now.Truncate(24*time.Hour)).Hours() / 24),
+		}
+		acks, err := store.ListVersionAcknowledgements(ctx, e.APIID, e.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range acks {
+			if !a.Subscribed {
+				continue
+			}
+			s.Subscribed++
+			if a.Status == "outstanding" {
+				s.Outstanding = append(s.Outstanding, a.Name)
+			}
+		}
+		d.Sunsets = append(d.Sunsets, s)
+	}
+
+	// what happened since the last digest
+	events, err := store.ListOrgEvents(ctx, m.OrgID, since, "version.deprecated", "notification.failed")
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range events {
+		at := ev.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")
+		switch ev.Type {
+		case "version.deprecated":
+			var data struct {
+				API     struct{ Name string } `json:"api"`
+				Version APIVersion            `json:"version"`
+			}
+			_ = json.Unmarshal(ev.Data, &data)
+			c := digestChange{APIName: data.API.Name, Version: data.Version.Version, At: at}
+			if data.Version.SunsetDate != nil {
+				c.SunsetDate = data.Version.SunsetDate.Format("2006-01-02")
+			}
+			d.Deprecated = append(d.Deprecated, c)
+		case "notification.failed":
+			var data struct {
+				APIName   string `json:"api_name"`
+				Version   string `json:"version"`
+				Type      string `json:"type"`
+				LastError string `json:"last_error"`
+			}
+			_ = json.Unmarshal(ev.Data, &data)
+			d.Failed = append(d.Failed, digestFailure{
+				APIName: data.APIName, Version: data.Version, Type: data.Type, Error: data.LastError, At: at,
+			})
+		}
+	}
+
+	if d.Retrying, err = store.ListRetryingNotifications(ctx, m.OrgID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+var memberDigestHTML = template.Must(template.New("member-digest").Parse(
+	`<div style="font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;line-height:1.5;color:#111">` +
+		`<h2 style="margin:0 0 12px 0">{{.OrgName}} – API lifecycle digest</h2>` +
+		`{{if .Sunsets}}<h3>Upcoming sunsets (next {{.HorizonDays}} days)</h3><ul>{{range .Sunsets}}` +
+		`<li><b>{{.APIName}} {{.Version}}</b> on {{.Date}} ({{.DaysLeft}} days)` +
+		`{{if .Outstanding}}<br/>Not yet acknowledged: {{range $i, $n := .Outstanding}}{{if $i}}, {{end}}{{$n}}{{end}}` +
+		`{{else if .Subscribed}}<br/>All {{.Subscribed}} consumer(s) acknowledged{{end}}</li>{{end}}</ul>{{end}}` +
+		`{{if .Deprecated}}<h3>Newly deprecated</h3><ul>{{range .Deprecated}}` +
+		`<li><b>{{.APIName}} {{.Version}}</b>{{if .SunsetDate}}, sunset {{.SunsetDate}}{{end}} <span style="color:#888">({{.At}})</span></li>{{end}}</ul>{{end}}` +
+		`{{if .Failed}}<h3>Failed or auto-canceled notifications</h3><ul>{{range .Failed}}` +
+		`<li><b>{{.APIName}} {{.Version}}</b> {{.Type}} notice: {{.Error}} <span style="color:#888">({{.At}})</span></li>{{end}}</ul>{{end}}` +
+		`{{if .Retrying}}<h3>Notifications retrying</h3><ul>{{range .Retrying}}` +
+		`<li><b>{{.APIName}} {{.Version}}</b> {{.Type}} notice, attempt {{.Attempts}}: {{.LastError}}</li>{{end}}</ul>{{end}}` +
+		`<p style="margin-top:16px"><a href="{{.DashboardURL}}">Open the dashboard</a></p>` +
+		`</div>`))
+
+var memberDigestText = texttemplate.Must(texttemplate.New("member-digest").Parse(
+	`{{.OrgName}} – API lifecycle digest
+{{if .Sunsets}}
+Upcoming sunsets (next {{.HorizonDays}} days)
+{{range .Sunsets}}  - {{.APIName}} {{.Version}} on {{.Date}} ({{.DaysLeft}} days){{if .Outstanding}}
+      not yet acknowledged: {{range $i, $n := .Outstanding}}{{if $i}}, {{end}}{{$n}}{{end}}{{else if .Subscribed}}
+      all {{.Subscribed}} consumer(s) acknowledged{{end}}
+{{end}}{{end}}{{if .Deprecated}}
+Newly deprecated
+{{range .Deprecated}}  - {{.APIName}} {{.Version}}{{if .SunsetDate}}, sunset {{.SunsetDate}}{{end}} ({{.At}})
+{{end}}{{end}}{{if .Failed}}
+Failed or auto-canceled notifications
+{{range .Failed}}  - {{.APIName}} {{.Version}} {{.Type}} notice: {{.Error}} ({{.At}})
+{{end}}{{end}}{{if .Retrying}}
+Notifications retrying
+{{range .Retrying}}  - {{.APIName}} {{.Version}} {{.Type}} notice, attempt {{.Attempts}}: {{.LastError}}
+{{end}}{{end}}
+Dashboard: {{.DashboardURL}}
+`))
+
+// Message renders the digest as a multipart email.
+func (d *memberDigest) Message(to string) (Message, error) {
+	var h, t bytes.Buffer
+	if err := memberDigestHTML.Execute(&h, d); err != nil {
+		return Message{}, err
+	}
+	if err := memberDigestText.Execute(&t, d); err != nil {
+		return Message{}, err
+	}
+	var parts []string
+	if n := len(d.Sunsets); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d upcoming sunset(s)", n))
+	}
+	if n := len(d.Deprecated); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d newly deprecated", n))
+	}
+	if n := len(d.Failed) + len(d.Retrying); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d notification problem(s)", n))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "nothing needs attention")
+	}
+	label := "Weekly"
+	if d.Frequency == "daily" {
+		label = "Daily"
+	}
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("[Smelinx] %s digest for %s – %s", label, d.OrgName, strings.Join(parts, ", ")),
+		HTML:    h.String(),
+		Text:    t.String(),
+	}, nil
+}
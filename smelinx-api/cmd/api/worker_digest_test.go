@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestHourUTC(t *testing.T) {
+	for v, want := range map[string]int{
+		"":     7,
+		"0":    0,
+		" 23 ": 23,
+		"9":    9,
+		"24":   7,
+		"-1":   7,
+		"7am":  7,
+	} {
+		t.Setenv("DIGEST_HOUR_UTC", v)
+		if got := digestHourUTC(); got != want {
+			t.Errorf("DIGEST_HOUR_UTC=%q: hour %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestConsumerDigestLocale(t *testing.T) {
+	item := func(orgLocale string) dueNotification {
+		return dueNotification{
+			NoteID: "n1", APIID: "a1", OrgID: "o1", APIName: "Payments", VersionID: "v1", Version: "v1",
+			Type:        "sunset",
+			SunsetDate:  sql.NullTime{Time: time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC), Valid: true},
+			ScheduledAt: time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC),
+			Locale:      orgLocale,
+		}
+	}
+	cases := []struct {
+		name             string
+		consumer, org    string
+		subject, heading string
+		sunset, footer   string
+	}{
+		{"consumer locale", "de", "ja", "Wöchentliche Übersicht", "Ihre wöchentliche Übersicht", "Abschaltdatum: 30.06.2030", "Einstellungen verwalten"},
+		{"org default", "", "ja", "週間ダイジェスト", "APIライフサイクル週間ダイジェスト", "提供終了日: 2030年06月30日", "配信設定の変更"},
+		{"english", "", "en", "Weekly API lifecycle digest – 1 notice(s)", "Your weekly API lifecycle digest", "Sunset date: 2030-06-30", "Manage preferences"},
+	}
+	for _, tc := range cases {
+		c := Consumer{ID: "c1", OrgID: "o1", Name: "Acme", Email: ptr("dev@acme.test")}
+		if tc.consumer != "" {
+			c.Locale = ptr(tc.consumer)
+		}
+		msg, err := consumerDigestMessage(c, []dueNotification{item(tc.org)})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !strings.Contains(msg.Subject, tc.subject) {
+			t.Errorf("%s: subject %q, want %q", tc.name, msg.Subject, tc.subject)
+		}
+		for _, want := range []string{tc.heading, tc.sunset, "Acme"} {
+			if !strings.Contains(msg.HTML, want) {
+				t.Errorf("%s: html lacks %q:\n%s", tc.name, want, msg.HTML)
+			}
+		}
+		if !strings.Contains(msg.Text, tc.footer) {
+			t.Errorf("%s: text footer not localized:\n%s", tc.name, msg.Text)
+		}
+	}
+}
@@ -13,8 +13,21 @@ import (
 // consoleMailer is a fallback when SENDGRID_API_KEY is not set.
 type consoleMailer struct{}
 
-func (consoleMailer) Send(to, subject, html string) error {
-	log.Printf("[mailer] to=%s subject=%q html=%q", to, subject, html)
+func (c consoleMailer) Send(to, subject, html string) error {
+	return c.SendMessage(Message{To: to, Subject: subject, HTML: html})
+}
+
+// SendMessage logs the message; when a plain-text part is set it is printed
+// as-is so digests and notices read naturally in the terminal.
+func (consoleMailer) SendMessage(msg Message) error {
+	if strings.TrimSpace(msg.Text) != "" {
+		log.Printf("[mailer] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Text)
+	} else {
+		log.Printf("[mailer] to=%s subject=%q html=%q", msg.To, msg.Subject, msg.HTML)
+	}
+	for _, a := range msg.Attachments {
+		log.Printf("[mailer]   attachment %s (%s, %d bytes)", a.Filename, a.ContentType, len(a.Content))
+	}
 	return nil
 }
 
@@ -30,9 +43,37 @@ func getenvInt(name string, def int) int {
 	return n
 }
 
+// retryPolicy is the exponential backoff shared by every outbound sender
+// (notification dispatcher, webhook deliveries).
+type retryPolicy struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+func loadRetryPolicy() retryPolicy {
+	return retryPolicy{
+		MaxAttempts: getenvInt("NOTIFY_MAX_ATTEMPTS", 6),
+		Base:        time.Duration(getenvInt("NOTIFY_BACKOFF_BASE_SECS", 60)) * time.Second,
+		Max:         time.Duration(getenvInt("NOTIFY_BACKOFF_MAX_SECS", 3600)) * time.Second,
+	}
+}
+
+// Backoff returns the delay before retrying after the given (1-based) attempt.
+func (p retryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := p.Base << (attempt - 1) // exp2
+	if backoff > p.Max || backoff <= 0 {
+		backoff = p.Max
+	}
+	return backoff
+}
+
 // startNotificationDispatcher runs a periodic loop that picks due notifications
-// and sends emails.
-func startNotificationDispatcher(store *Store, mailer Mailer) {
+// and delivers them through every configured channel.
+func startNotificationDispatcher(store *Store, channels map[string]Channel) {
 	interval := 30 * time.Second // check every 30s
 	batchLimit := 50
 
@@ -42,7 +83,7 @@ func startNotificationDispatcher(store *Store, mailer Mailer) {
 		defer t.Stop()
 
 		for {
-			if err := dispatchOnce(store, mailer, batchLimit); err != nil {
+			if err := dispatchOnce(store, channels, batchLimit); err != nil {
 				log.Printf("[notify] dispatch error: %v", err)
 			}
 			<-t.C
@@ -50,7 +91,7 @@ func startNotificationDispatcher(store *Store, mailer Mailer) {
 	}()
 }
 
-func dispatchOnce(store *Store, mailer Mailer, limit int) error {
+func dispatchOnce(store *Store, channels map[string]Channel, limit int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
 
@@ -65,33 +106,30 @@ func dispatchOnce(store *Store, mailer Mailer, limit int) error {
 
 	log.Printf("[notify] found %d due notification(s)", len(due))
 
-	maxAttempts := getenvInt("NOTIFY_MAX_ATTEMPTS", 6)
-	base := time.Duration(getenvInt("NOTIFY_BACKOFF_BASE_SECS", 60)) * time.Second
-	maxBackoff := time.Duration(getenvInt("NOTIFY_BACKOFF_MAX_SECS", 3600)) * time.Second
+	policy := loadRetryPolicy()
 
 	for _, d := range due {
-		to := firstNonEmpty(d.ContactEmail.String, getenv("SENDGRID_TEST_TO", ""))
-		if strings.TrimSpace(to) == "" {
-			log.Printf("[notify] skip note=%s api=%s (no contact email and no SENDGRID_TEST_TO)", d.NoteID, d.APIID)
+		recipients, err := store.ListNotificationRecipients(ctx, d, getenv("SENDGRID_TEST_TO", ""))
+		if err != nil {
+			log.Printf("[notify] resolve recipients failed note=%s: %v", d.NoteID, err)
+			continue
+		}
+		if len(recipients) == 0 {
+			log.Printf("[notify] skip note=%s api=%s (no contact email, consumers, channels or SENDGRID_TEST_TO)", d.NoteID, d.APIID)
 			continue
 		}
 
-		subj := buildSubject(d)
-		body := buildHTML(d)
-
-		if err := mailer.Send(to, subj, body); err != nil {
+		deferUntil, err := deliverAll(ctx, store, channels, d, recipients)
+		if err != nil {
 			// compute backoff and reschedule
 			nextAttempts := d.Attempts + 1
-			backoff := base << (nextAttempts - 1) // exp2
-			if backoff > maxBackoff {
-				backoff = maxBackoff
-			}
-			next := time.Now().UTC().Add(backoff)
+			next := time.Now().UTC().Add(policy.Backoff(nextAttempts))
 			msg := truncate(fmt.Sprintf("send failed: %v", err), 500)
 
-			if nextAttempts >= maxAttempts {
+			if nextAttempts >= policy.MaxAttempts {
 				_ = store.AutoCancelNotification(ctx, d.NoteID, "auto-canceled after max attempts; last error: "+msg)
 				log.Printf("[notify] auto-canceled note=%s after %d attempts", d.NoteID, nextAttempts)
+				store.emitEvent(ctx, d.OrgID, "notification.failed", d.APIID, d.VersionID, notificationEventData(d, msg))
 				continue
 			}
 
@@ -103,53 +141,142 @@ func dispatchOnce(store *Store, mailer Mailer, limit int) error {
 			continue
 		}
 
+		if !deferUntil.IsZero() {
+			if err := store.DeferNotification(ctx, d.NoteID, deferUntil); err != nil {
+				log.Printf("[notify] defer failed note=%s: %v", d.NoteID, err)
+			} else {
+				log.Printf("[notify] note=%s held for quiet hours until %s", d.NoteID, deferUntil.UTC().Format(time.RFC3339))
+			}
+			continue
+		}
+
 		if err := store.MarkNotificationSent(ctx, d.NoteID); err != nil {
 			log.Printf("[notify] mark sent failed note=%s: %v", d.NoteID, err)
 		} else {
-			log.Printf("[notify] sent note=%s to=%s", d.NoteID, to)
+			log.Printf("[notify] sent note=%s to %d recipient(s)", d.NoteID, len(recipients))
+			store.emitEvent(ctx, d.OrgID, "notification.sent", d.APIID, d.VersionID, notificationEventData(d, ""))
 		}
 	}
 
 	return nil
 }
 
-func buildSubject(d dueNotification) string {
-	switch d.Type {
-	case "deprecate":
-		return fmt.Sprintf("[Smelinx] Deprecation notice – %s %s", d.APIName, d.Version)
-	case "sunset":
-		return fmt.Sprintf("[Smelinx] Sunset notice – %s %s", d.APIName, d.Version)
-	default:
-		return fmt.Sprintf("[Smelinx] Notice – %s %s", d.APIName, d.Version)
+// deliverAll sends d to every recipient not already delivered on a previous
+// attempt, recording each outcome. Consumer preferences are applied first
+// (see routeForConsumer); recipients inside their quiet hours are held back
+// and deferUntil reports the earliest time one of them becomes reachable.
+// It returns the first failure, if any.
+func deliverAll(ctx context.Context, store *Store, channels map[string]Channel, d dueNotification, recipients []recipient) (deferUntil time.Time, err error) {
+	done, err := store.SentDeliveryTargets(ctx, d.NoteID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	prefs := map[string]*ConsumerPreferences{}
+	var firstErr error
+	for _, to := range recipients {
+		if done[to.Channel+"|"+to.Target] {
+			continue
+		}
+		switch route, err := routeForConsumer(ctx, store, prefs, to, d); {
+		case err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("preferences: %w", err)
+			}
+			continue
+		case route == "skip":
+			log.Printf("[notify] note=%s: %s %s opted out; skipping", d.NoteID, to.Channel, to.Target)
+			continue
+		case route == "digest":
+			continue
+		}
+		if until, quiet := recipientQuietUntil(time.Now(), to, d); quiet {
+			if deferUntil.IsZero() || until.Before(deferUntil) {
+				deferUntil = until
+			}
+			continue
+		}
+		if to.Channel == "email" {
+			if sup, err := store.GetEmailSuppression(ctx, d.OrgID, to.Target); err == nil {
+				_ = store.RecordDeliveryOnce(ctx, d.NoteID, to, "failed", "suppressed: "+sup.Reason)
+				log.Printf("[notify] note=%s: %s is suppressed (%s); skipping", d.NoteID, to.Target, sup.Reason)
+				continue
+			}
+		}
+		ch, ok := channels[to.Channel]
+		if !ok {
+			log.Printf("[notify] note=%s: no channel %q configured; skipping %s", d.NoteID, to.Channel, to.Target)
+			continue
+		}
+		if err := ch.Deliver(ctx, to, d); err != nil {
+			_ = store.RecordDelivery(ctx, d.NoteID, to, "failed", truncate(err.Error(), 500))
+			log.Printf("[notify] %s delivery failed note=%s: %v", to.Channel, d.NoteID, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", to.Channel, err)
+			}
+			continue
+		}
+		if err := store.RecordDelivery(ctx, d.NoteID, to, "sent", ""); err != nil {
+			log.Printf("[notify] record delivery failed note=%s: %v", d.NoteID, err)
+		}
 	}
+	return deferUntil, firstErr
 }
 
-func buildHTML(d dueNotification) string {
-	var b strings.Builder
-	title := "API Notice"
-	switch d.Type {
-	case "deprecate":
-		title = "Deprecation Notice"
-	case "sunset":
-		title = "Sunset Notice"
+// recipientQuietUntil reports whether a consumer recipient is inside their
+// quiet hours at now and, if so, when the window ends.
+func recipientQuietUntil(now time.Time, to recipient, d dueNotification) (time.Time, bool) {
+	if to.QuietStart == "" || to.QuietEnd == "" {
+		return time.Time{}, false
 	}
+	return quietUntil(now, recipientZone(to, d), to.QuietStart, to.QuietEnd)
+}
 
-	fmt.Fprintf(&b, `<div style="font-family:ui-sans-serif,system-ui,Segoe UI,Roboto,Arial,sans-serif;line-height:1.5;color:#111">`)
-	fmt.Fprintf(&b, `<h2 style="margin:0 0 12px 0">%s</h2>`, htmlEsc(title))
-	fmt.Fprintf(&b, `<p style="margin:0 0 8px 0"><b>API:</b> %s<br/>`, htmlEsc(d.APIName))
-	fmt.Fprintf(&b, `<b>Version:</b> %s<br/>`, htmlEsc(d.Version))
-	fmt.Fprintf(&b, `<b>Scheduled at:</b> %s</p>`, d.ScheduledAt.Format(time.RFC1123))
+// recipientZone is the zone a recipient reads times in: their own, else the
+// notification's (which itself defaults to the org's), else UTC.
+func recipientZone(to recipient, d dueNotification) *time.Location {
+	return zoneOrUTC(to.Timezone, d.Timezone)
+}
 
-	if d.BaseURL.Valid && strings.TrimSpace(d.BaseURL.String) != "" {
-		fmt.Fprintf(&b, `<p style="margin:8px 0"><b>Base URL:</b> <a href="%s">%s</a></p>`, htmlAttr(d.BaseURL.String), htmlEsc(d.BaseURL.String))
+// routeForConsumer applies a consumer's preferences to one recipient and
+// returns "send", "skip" or "digest" (queued for the weekly digest email).
+// Sunset notices are legally required and always sent.
+func routeForConsumer(ctx context.Context, store *Store, cache map[string]*ConsumerPreferences, to recipient, d dueNotification) (string, error) {
+	if to.ConsumerID == "" || d.Type == "sunset" {
+		return "send", nil
+	}
+	p, ok := cache[to.ConsumerID]
+	if !ok {
+		var err error
+		if p, err = store.GetConsumerPreferences(ctx, to.ConsumerID); err != nil {
+			return "", err
+		}
+		cache[to.ConsumerID] = p
+	}
+	if !p.Wants(d.APIID, d.Type) {
+		return "skip", nil
 	}
-	if d.DocsURL.Valid && strings.TrimSpace(d.DocsURL.String) != "" {
-		fmt.Fprintf(&b, `<p style="margin:8px 0"><b>Docs:</b> <a href="%s">%s</a></p>`, htmlAttr(d.DocsURL.String), htmlEsc(d.DocsURL.String))
+	if p.Digest == "weekly" && to.Channel == "email" {
+		return "digest", store.QueueDigestItem(ctx, to.ConsumerID, d.NoteID)
 	}
+	return "send", nil
+}
 
-	fmt.Fprintf(&b, `<p style="margin-top:16px">If you have questions, please reply to this email.</p>`)
-	fmt.Fprintf(&b, `</div>`)
-	return b.String()
+// notificationEventData is the webhook payload for notification.* events.
+func notificationEventData(d dueNotification, lastErr string) map[string]any {
+	data := map[string]any{
+		"notification_id": d.NoteID,
+		"api_id":          d.APIID,
+		"api_name":        d.APIName,
+		"version_id":      d.VersionID,
+		"version":         d.Version,
+		"type":            d.Type,
+		"scheduled_at":    d.ScheduledAt.UTC().Format(time.RFC3339),
+	}
+	if lastErr != "" {
+		data["last_error"] = lastErr
+	}
+	return data
 }
 
 // tiny helpers
@@ -163,20 +290,6 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
-func htmlEsc(s string) string {
-	repl := strings.NewReplacer(
-		"&", "&amp;",
-		"\"", "&quot;",
-		"<", "&lt;",
-		">", "&gt;",
-	)
-	return repl.Replace(s)
-}
-
-func htmlAttr(s string) string {
-	return htmlEsc(s)
-}
-
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+Outbound webhook requests carry:
+
+	Smelinx-Webhook-Id:        <event id>   (stable across retries; use it to dedupe)
+	Smelinx-Webhook-Timestamp: <unix seconds of this attempt>
+	Smelinx-Webhook-Signature: t=<timestamp>,v1=<hex HMAC-SHA256(secret, "<timestamp>.<raw body>")>
+
+Receivers should recompute v1 with their endpoint secret, compare in constant
+time, and reject timestamps older than a few minutes to prevent replay.
+*/
+
+var webhookClient = outboundClient(10 * time.Second)
+
+// startWebhookDispatcher periodically sends pending webhook deliveries.
+func startWebhookDispatcher(store *Store) {
+	interval := 10 * time.Second
+	batchLimit := 50
+
+	go func() {
+		log.Printf("[webhooks] dispatcher started (interval=%s)", interval)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			if err := deliverWebhooksOnce(store, batchLimit); err != nil {
+				log.Printf("[webhooks] dispatch error: %v", err)
+			}
+			<-t.C
+		}
+	}()
+}
+
+func deliverWebhooksOnce(store *Store, limit int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
+	due, err := store.ListDueWebhookDeliveries(ctx, limit)
+	if err != nil {
+		return err
+	}
+	policy := loadRetryPolicy()
+	for _, d := range due {
+		deliverWebhook(ctx, store, d, policy)
+	}
+	return nil
+}
+
+// deliverWebhook performs one attempt and records the outcome, rescheduling
+// with the shared exponential backoff or failing after MaxAttempts.
+func deliverWebhook(ctx context.Context, store *Store, d dueWebhookDelivery, policy retryPolicy) {
+	attempts := d.Attempts + 1
+	code, err := postSignedWebhook(ctx, d.URL, d.Secret, d.EventID, d.Payload, time.Now())
+	if err == nil {
+		if e := store.MarkWebhookDelivered(ctx, d.ID, attempts, code); e != nil {
+			log.Printf("[webhooks] mark delivered failed delivery=%s: %v", d.ID, e)
+		}
+		return
+	}
+
+	msg := truncate(err.Error(), 500)
+	if attempts >= policy.MaxAttempts {
+		_ = store.FailWebhookDelivery(ctx, d.ID, attempts, code, msg)
+		log.Printf("[webhooks] delivery=%s failed permanently after %d attempts: %s", d.ID, attempts, msg)
+		return
+	}
+	next := time.Now().UTC().Add(policy.Backoff(attempts))
+	if e := store.ScheduleWebhookRetry(ctx, d.ID, next, attempts, code, msg); e != nil {
+		log.Printf("[webhooks] schedule retry failed delivery=%s: %v", d.ID, e)
+		return
+	}
+	log.Printf("[webhooks] will retry delivery=%s at=%s (attempt=%d): %s", d.ID, next.Format(time.RFC3339), attempts, msg)
+}
+
+// postSignedWebhook POSTs body to url with the signature headers. It returns
+// the HTTP status (0 if none) and an error for transport failures or non-2xx.
+func postSignedWebhook(ctx context.Context, url, secret, eventID string, body []byte, now time.Time) (int, error) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Smelinx-Webhooks/1.0")
+	req.Header.Set("Smelinx-Webhook-Id", eventID)
+	req.Header.Set("Smelinx-Webhook-Timestamp", ts)
+	req.Header.Set("Smelinx-Webhook-Signature", "t="+ts+",v1="+signWebhook(secret, ts, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d%s", resp.StatusCode, upstreamReason(resp.Body))
+	}
+	return resp.StatusCode, nil
+}
+
+func signWebhook(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
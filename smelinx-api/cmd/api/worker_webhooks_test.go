@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTestDeliveryIsLeased(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		_, org := seedOrg(t, s, "a@b.co")
+		ep, err := s.CreateWebhookEndpoint(ctx, org.ID, "https://hooks.example.com/smelinx", "whsec_test", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, err := s.RecordTestEvent(ctx, org.ID, map[string]any{"endpoint_id": ep.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := s.QueueWebhookDelivery(ctx, ep.ID, ev, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the dispatcher must not see the row while the handler sends it inline
+		due, err := s.ListDueWebhookDeliveries(ctx, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range due {
+			if d.ID == id {
+				t.Fatal("leased test delivery is due for the dispatcher")
+			}
+		}
+		if d, err := s.GetDueWebhookDelivery(ctx, id); err != nil || d.URL != ep.URL {
+			t.Fatalf("inline load: %+v, %v", d, err)
+		}
+
+		// a lease that ran out hands the row to the dispatcher
+		id, err = s.QueueWebhookDelivery(ctx, ep.ID, ev, -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		due, _ = s.ListDueWebhookDeliveries(ctx, 50)
+		if len(due) != 1 || due[0].ID != id {
+			t.Errorf("due after the lease expired = %+v, want the delivery", due)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	smelinx "github.com/yourname/smelinx-api/client"
+)
+
+var apiColumns = []column[smelinx.API]{
+	{"ID", func(a smelinx.API) string { return a.ID }},
+	{"NAME", func(a smelinx.API) string { return a.Name }},
+	{"OWNER", func(a smelinx.API) string { return str(a.OwnerTeam) }},
+	{"CONTACT", func(a smelinx.API) string { return str(a.ContactEmail) }},
+	{"CREATED", func(a smelinx.API) string { return stamp(a.CreatedAt) }},
+}
+
+func (c *cli) listAPIs(ctx context.Context, opts *smelinx.APIListOptions) ([]smelinx.API, error) {
+	return smelinx.Collect(smelinx.All(ctx, c.sdk.ListAPIs, opts))
+}
+
+// resolveAPI finds an API by ID or (case-insensitive) name.
+func (c *cli) resolveAPI(ctx context.Context, ref string) (*smelinx.API, error) {
+	list, err := c.listAPIs(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].ID == ref {
+			return &list[i], nil
+		}
+	}
+	for i := range list {
+		if strings.EqualFold(list[i].Name, ref) {
+			return &list[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no API %q", ref)
+}
+
+func (c *cli) apis(ctx context.Context, args []string) error {
+	action, args, err := subcommand("apis", args, "list", "get", "create", "update", "delete")
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("apis list", flag.ContinueOnError)
+		opts := &smelinx.APIListOptions{}
+		fs.StringVar(&opts.Search, "search", "", "only APIs whose name contains this")
+		fs.StringVar(&opts.OwnerTeam, "owner-team", "", "only APIs of this owner team")
+		fs.StringVar(&opts.Sort, "sort", "", "-created_at (default), created_at, name or -name")
+		if _, err := c.parseFlags(fs, args); err != nil {
+			return err
+		}
+		list, err := c.listAPIs(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return printList(c, list, apiColumns...)
+
+	case "get":
+		pos, err := c.parseFlags(flag.NewFlagSet("apis get", flag.ContinueOnError), args, "api")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		return printOne(c, *api, apiColumns...)
+
+	case "create":
+		fs := flag.NewFlagSet("apis create", flag.ContinueOnError)
+		f := apiFlags(fs)
+		pos, err := c.parseFlags(fs, args, "name")
+		if err != nil {
+			return err
+		}
+		req := smelinx.CreateAPIRequest{Name: pos[0]}
+		set := f.given(fs)
+		if set.Description != nil {
+			req.Description = *set.Description
+		}
+		req.BaseURL, req.DocsURL, req.ContactEmail, req.OwnerTeam = set.BaseURL, set.DocsURL, set.ContactEmail, set.OwnerTeam
+		api, err := c.sdk.CreateAPI(ctx, req)
+		if err != nil {
+			return err
+		}
+		return printOne(c, *api, apiColumns...)
+
+	case "update":
+		fs := flag.NewFlagSet("apis update", flag.ContinueOnError)
+		f := apiFlags(fs)
+		name := fs.String("name", "", "new name")
+		pos, err := c.parseFlags(fs, args, "api")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		req := f.given(fs)
+		if *name != "" {
+			req.Name = name
+		}
+		if req == (smelinx.UpdateAPIRequest{}) {
+			return fmt.Errorf("nothing to update; see smelinxctl apis update -h")
+		}
+		updated, err := c.sdk.UpdateAPI(ctx, api.ID, api.Revision, req)
+		if err != nil {
+			return err
+		}
+		return printOne(c, *updated, apiColumns...)
+
+	default: // delete
+		pos, err := c.parseFlags(flag.NewFlagSet("apis delete", flag.ContinueOnError), args, "api")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		if err := c.sdk.DeleteAPI(ctx, api.ID, api.Revision); err != nil {
+			return err
+		}
+		return c.done("deleted API %s (%s)", api.Name, api.ID)
+	}
+}
+
+// apiFieldFlags are the optional API fields shared by create and update.
+type apiFieldFlags struct {
+	description, baseURL, docsURL, contactEmail, ownerTeam *string
+}
+
+func apiFlags(fs *flag.FlagSet) apiFieldFlags {
+	return apiFieldFlags{
+		description:  fs.String("description", "", "description"),
+		baseURL:      fs.String("base-url", "", "base URL"),
+		docsURL:      fs.String("docs-url", "", "documentation URL"),
+		contactEmail: fs.String("contact-email", "", "contact email"),
+		ownerTeam:    fs.String("owner-team", "", "owning team"),
+	}
+}
+
+// given returns the fields whose flags were passed, so an update leaves the
+// others alone.
+func (f apiFieldFlags) given(fs *flag.FlagSet) smelinx.UpdateAPIRequest {
+	var req smelinx.UpdateAPIRequest
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "description":
+			req.Description = f.description
+		case "base-url":
+			req.BaseURL = f.baseURL
+		case "docs-url":
+			req.DocsURL = f.docsURL
+		case "contact-email":
+			req.ContactEmail = f.contactEmail
+		case "owner-team":
+			req.OwnerTeam = f.ownerTeam
+		}
+	})
+	return req
+}
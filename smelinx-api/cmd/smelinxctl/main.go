@@ -0,0 +1,205 @@
+// Command smelinxctl manages APIs, versions and notifications of a Smelinx
+// org from the command line, for scripts and CI.
+//
+//	smelinxctl login --url https://api.smelinx.com --email you@example.com
+//	smelinxctl apis list -o json
+//	smelinxctl versions deprecate payments v1 --sunset 2030-06-30
+//
+// It authenticates with a personal access token: --token, SMELINX_TOKEN, or
+// the one `login` saved in the config file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	smelinx "github.com/yourname/smelinx-api/client"
+)
+
+const usage = `usage: smelinxctl [global flags] <command> [args]
+
+commands:
+  login                                  create a personal access token and save it
+  apis list|get|create|update|delete     manage APIs
+  versions list|create|update|deprecate  manage versions of an API
+  notifications list|schedule|cancel     manage scheduled notices
+  tokens list|create|revoke              manage personal access tokens
+
+APIs may be given by ID or name, versions by ID or version string.
+Run "smelinxctl <command> -h" for the flags of a command.
+
+global flags:
+`
+
+// errUsage is returned for bad arguments; the command has printed its usage.
+var errUsage = errors.New("usage")
+
+type cli struct {
+	sdk    *smelinx.Client
+	out    io.Writer
+	format string // table | json | yaml
+	cfg    *config
+	cfgErr error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, out io.Writer) int {
+	cfg, cfgErr := loadConfig()
+	if cfg == nil {
+		cfg = &config{}
+	}
+
+	fs := flag.NewFlagSet("smelinxctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	baseURL := fs.String("url", firstNonEmpty(os.Getenv("SMELINX_URL"), cfg.URL, "http://localhost:8080"), "Smelinx API base URL (env SMELINX_URL)")
+	token := fs.String("token", firstNonEmpty(os.Getenv("SMELINX_TOKEN"), cfg.Token), "personal access token (env SMELINX_TOKEN)")
+	format := fs.String("o", "table", "output format: table, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if !validFormat(*format) {
+		fmt.Fprintln(os.Stderr, "-o must be table, json or yaml")
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	c := &cli{sdk: smelinx.New(*baseURL, smelinx.WithToken(*token), smelinx.WithUserAgent("smelinxctl")), out: out, format: *format, cfg: cfg, cfgErr: cfgErr}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	var err error
+	switch cmd {
+	case "login":
+		err = c.login(ctx, rest)
+	case "apis", "api":
+		err = c.apis(ctx, rest)
+	case "versions", "version":
+		err = c.versions(ctx, rest)
+	case "notifications", "notification", "notices":
+		err = c.notifications(ctx, rest)
+	case "tokens", "token":
+		err = c.tokens(ctx, rest)
+	case "help":
+		fs.SetOutput(out)
+		fs.Usage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		fs.Usage()
+		return 2
+	}
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		return 2
+	case errors.Is(err, smelinx.ErrUnauthorized):
+		fmt.Fprintln(os.Stderr, "error: unauthorized: run `smelinxctl login` or pass --token / SMELINX_TOKEN")
+		return 1
+	case errors.Is(err, smelinx.ErrPreconditionFailed):
+		fmt.Fprintln(os.Stderr, "error: changed by someone else while this command ran; run it again")
+		return 1
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+}
+
+// subcommand picks the action of a command group ("apis list", ...).
+func subcommand(group string, args []string, actions ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, a := range actions {
+			if args[0] == a {
+				return a, args[1:], nil
+			}
+		}
+		fmt.Fprintf(os.Stderr, "unknown action %q\n", args[0])
+	}
+	fmt.Fprintf(os.Stderr, "usage: smelinxctl %s <", group)
+	for i, a := range actions {
+		if i > 0 {
+			fmt.Fprint(os.Stderr, "|")
+		}
+		fmt.Fprint(os.Stderr, a)
+	}
+	fmt.Fprintln(os.Stderr, "> ...")
+	return "", nil, errUsage
+}
+
+// parseFlags parses a command's flags, allowing them (and -o) before or
+// after its positional arguments, and checks the number of positionals.
+func (c *cli) parseFlags(fs *flag.FlagSet, args []string, positional ...string) ([]string, error) {
+	fs.StringVar(&c.format, "o", c.format, "output format: table, json or yaml")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: smelinxctl %s", fs.Name())
+		for _, p := range positional {
+			fmt.Fprintf(fs.Output(), " <%s>", p)
+		}
+		fmt.Fprintln(fs.Output(), " [flags]")
+		fs.PrintDefaults()
+	}
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage // the flag package has printed the problem
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(pos) != len(positional) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	if !validFormat(c.format) {
+		fmt.Fprintln(fs.Output(), "-o must be table, json or yaml")
+		return nil, errUsage
+	}
+	return pos, nil
+}
+
+// splitList splits a comma-separated flag value; "" is no values.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func validFormat(f string) bool { return f == "table" || f == "json" || f == "yaml" }
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
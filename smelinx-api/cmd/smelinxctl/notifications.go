@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	smelinx "github.com/yourname/smelinx-api/client"
+)
+
+var notificationColumns = []column[smelinx.Notification]{
+	{"ID", func(n smelinx.Notification) string { return n.ID }},
+	{"VERSION", func(n smelinx.Notification) string { return n.VersionID }},
+	{"TYPE", func(n smelinx.Notification) string { return n.Type }},
+	{"SCHEDULED", func(n smelinx.Notification) string { return stamp(n.ScheduledAt) }},
+	{"STATUS", func(n smelinx.Notification) string { return n.Status }},
+}
+
+func (c *cli) notifications(ctx context.Context, args []string) error {
+	action, args, err := subcommand("notifications", args, "list", "schedule", "cancel")
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("notifications list", flag.ContinueOnError)
+		opts := &smelinx.NotificationListOptions{}
+		status := fs.String("status", "", "only these statuses, comma-separated (pending, sent, canceled)")
+		typ := fs.String("type", "", "only these types, comma-separated (deprecate, sunset)")
+		fs.StringVar(&opts.ScheduledAfter, "after", "", "scheduled at or after (YYYY-MM-DD or RFC 3339)")
+		fs.StringVar(&opts.ScheduledBefore, "before", "", "scheduled before (YYYY-MM-DD or RFC 3339)")
+		fs.StringVar(&opts.Sort, "sort", "", "scheduled_at (default), -scheduled_at, created_at or -created_at")
+		pos, err := c.parseFlags(fs, args, "api")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		opts.Status, opts.Type = splitList(*status), splitList(*typ)
+		list, err := smelinx.Collect(smelinx.All(ctx, func(ctx context.Context, o *smelinx.NotificationListOptions) (*smelinx.Page[smelinx.Notification], error) {
+			return c.sdk.ListNotifications(ctx, api.ID, o)
+		}, opts))
+		if err != nil {
+			return err
+		}
+		// show version strings rather than IDs
+		if c.format == "table" {
+			if versions, err := c.listVersions(ctx, api.ID, nil); err == nil {
+				names := map[string]string{}
+				for _, v := range versions {
+					names[v.ID] = v.Version
+				}
+				for i := range list {
+					if name, ok := names[list[i].VersionID]; ok {
+						list[i].VersionID = name
+					}
+				}
+			}
+		}
+		return printList(c, list, notificationColumns...)
+
+	case "schedule":
+		fs := flag.NewFlagSet("notifications schedule", flag.ContinueOnError)
+		typ := fs.String("type", "deprecate", "deprecate or sunset")
+		at := fs.String("at", "", "when: YYYY-MM-DD, YYYY-MM-DDTHH:MM (local to --timezone) or RFC 3339 (required)")
+		tz := fs.String("timezone", "", "IANA time zone (default: the org's)")
+		sendTime := fs.String("send-time", "", "HH:MM for a date-only --at (default 09:00)")
+		pos, err := c.parseFlags(fs, args, "api", "version")
+		if err != nil {
+			return err
+		}
+		if *at == "" {
+			fs.Usage()
+			return errUsage
+		}
+		api, v, err := c.resolveVersion(ctx, pos[0], pos[1])
+		if err != nil {
+			return err
+		}
+		n, err := c.sdk.CreateNotification(ctx, api.ID, smelinx.CreateNotificationRequest{
+			VersionID: v.ID, Type: *typ, ScheduledAt: *at, Timezone: *tz, SendTime: *sendTime,
+		})
+		if err != nil {
+			return err
+		}
+		if c.format == "table" {
+			n.VersionID = v.Version
+		}
+		return printOne(c, *n, notificationColumns...)
+
+	default: // cancel
+		pos, err := c.parseFlags(flag.NewFlagSet("notifications cancel", flag.ContinueOnError), args, "notification-id")
+		if err != nil {
+			return err
+		}
+		n, err := c.sdk.GetNotification(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		n, err = c.sdk.CancelNotification(ctx, n.ID, n.Revision)
+		if err != nil {
+			return err
+		}
+		return printOne(c, *n, notificationColumns...)
+	}
+}
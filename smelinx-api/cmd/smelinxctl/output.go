@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// column is one table column: a header and how to render a row's cell.
+type column[T any] struct {
+	header string
+	value  func(T) string
+}
+
+// printList writes rows as a table, or the raw value as JSON / YAML.
+func printList[T any](c *cli, rows []T, cols ...column[T]) error {
+	if c.format != "table" {
+		return c.printValue(rows)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = col.header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, col := range cols {
+			cells[i] = col.value(r)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printOne writes one row like printList does.
+func printOne[T any](c *cli, row T, cols ...column[T]) error {
+	if c.format != "table" {
+		return c.printValue(row)
+	}
+	return printList(c, []T{row}, cols...)
+}
+
+// printValue writes v as JSON or YAML with the field names of the API.
+func (c *cli) printValue(v any) error {
+	if c.format == "json" {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	// round-trip through JSON so YAML keys match the API's JSON names
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = c.out.Write(out)
+	return err
+}
+
+// done reports a change without a resource to print.
+func (c *cli) done(msg string, args ...any) error {
+	if c.format != "table" {
+		return c.printValue(map[string]string{"status": "ok", "message": fmt.Sprintf(msg, args...)})
+	}
+	_, err := fmt.Fprintf(c.out, msg+"\n", args...)
+	return err
+}
+
+func str(p *string) string {
+	if p == nil || *p == "" {
+		return "-"
+	}
+	return *p
+}
+
+func date(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func stamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
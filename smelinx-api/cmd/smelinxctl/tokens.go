@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	smelinx "github.com/yourname/smelinx-api/client"
+)
+
+var tokenColumns = []column[smelinx.Token]{
+	{"ID", func(t smelinx.Token) string { return t.ID }},
+	{"NAME", func(t smelinx.Token) string { return t.Name }},
+	{"PREFIX", func(t smelinx.Token) string { return t.Prefix + "..." }},
+	{"EXPIRES", func(t smelinx.Token) string { return date(t.ExpiresAt) }},
+	{"LAST USED", func(t smelinx.Token) string {
+		if t.LastUsedAt == nil {
+			return "never"
+		}
+		return stamp(*t.LastUsedAt)
+	}},
+}
+
+func (c *cli) tokens(ctx context.Context, args []string) error {
+	action, args, err := subcommand("tokens", args, "list", "create", "revoke")
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "list":
+		if _, err := c.parseFlags(flag.NewFlagSet("tokens list", flag.ContinueOnError), args); err != nil {
+			return err
+		}
+		list, err := c.sdk.ListTokens(ctx)
+		if err != nil {
+			return err
+		}
+		return printList(c, list, tokenColumns...)
+
+	case "create":
+		fs := flag.NewFlagSet("tokens create", flag.ContinueOnError)
+		email := fs.String("email", os.Getenv("SMELINX_EMAIL"), "account email (env SMELINX_EMAIL)")
+		days := fs.Int("expires-in-days", 0, "expire the token after this many days (0 = never)")
+		pos, err := c.parseFlags(fs, args, "name")
+		if err != nil {
+			return err
+		}
+		// tokens cannot create tokens; this needs a password session
+		session, err := c.signIn(ctx, *email)
+		if err != nil {
+			return err
+		}
+		t, err := session.CreateToken(ctx, smelinx.CreateTokenRequest{Name: pos[0], ExpiresInDays: *days})
+		if err != nil {
+			return err
+		}
+		if c.format != "table" {
+			return c.printValue(t)
+		}
+		fmt.Fprintf(c.out, "%s\n", t.Token)
+		fmt.Fprintln(os.Stderr, "Store this token now; it is not shown again.")
+		return nil
+
+	default: // revoke
+		pos, err := c.parseFlags(flag.NewFlagSet("tokens revoke", flag.ContinueOnError), args, "token-id")
+		if err != nil {
+			return err
+		}
+		if err := c.sdk.RevokeToken(ctx, pos[0]); err != nil {
+			return err
+		}
+		return c.done("revoked token %s", pos[0])
+	}
+}
+
+// login signs in with email and password, creates a personal access token
+// and saves it with the server URL to the config file.
+func (c *cli) login(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	email := fs.String("email", os.Getenv("SMELINX_EMAIL"), "account email (env SMELINX_EMAIL)")
+	name := fs.String("name", "", "token name (default: smelinxctl@<hostname>)")
+	days := fs.Int("expires-in-days", 90, "token lifetime in days (0 = never)")
+	if _, err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if c.cfgErr != nil {
+		return fmt.Errorf("config: %w", c.cfgErr)
+	}
+	if *name == "" {
+		host, _ := os.Hostname()
+		*name = "smelinxctl@" + firstNonEmpty(host, "unknown")
+	}
+
+	session, err := c.signIn(ctx, *email)
+	if err != nil {
+		return err
+	}
+	t, err := session.CreateToken(ctx, smelinx.CreateTokenRequest{Name: *name, ExpiresInDays: *days})
+	if err != nil {
+		return err
+	}
+
+	c.cfg.URL, c.cfg.Token = session.BaseURL(), t.Token
+	path, err := c.cfg.save()
+	if err != nil {
+		return fmt.Errorf("token %s created but not saved: %w", t.Prefix, err)
+	}
+	fmt.Fprintf(os.Stderr, "Logged in to %s; token %q saved to %s\n", session.BaseURL(), t.Name, path)
+	return nil
+}
+
+// signIn returns a client holding a password session, prompting for the
+// email and password unless given (SMELINX_PASSWORD). Only a session cookie
+// from /auth/login may create tokens.
+func (c *cli) signIn(ctx context.Context, email string) (*smelinx.Client, error) {
+	in := bufio.NewReader(os.Stdin)
+	if email == "" {
+		fmt.Fprint(os.Stderr, "Email: ")
+		line, _ := in.ReadString('\n')
+		email = strings.TrimSpace(line)
+	}
+	password := os.Getenv("SMELINX_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, _ := in.ReadString('\n')
+		password = strings.TrimRight(line, "\r\n")
+	}
+	session := smelinx.New(c.sdk.BaseURL(), smelinx.WithUserAgent("smelinxctl"))
+	if err := session.Login(ctx, email, password); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
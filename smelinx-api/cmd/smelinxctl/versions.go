@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	smelinx "github.com/yourname/smelinx-api/client"
+)
+
+var versionColumns = []column[smelinx.Version]{
+	{"ID", func(v smelinx.Version) string { return v.ID }},
+	{"VERSION", func(v smelinx.Version) string { return v.Version }},
+	{"STATUS", func(v smelinx.Version) string { return v.Status }},
+	{"SUNSET", func(v smelinx.Version) string { return date(v.SunsetDate) }},
+	{"CREATED", func(v smelinx.Version) string { return stamp(v.CreatedAt) }},
+}
+
+func (c *cli) listVersions(ctx context.Context, apiID string, opts *smelinx.VersionListOptions) ([]smelinx.Version, error) {
+	return smelinx.Collect(smelinx.All(ctx, func(ctx context.Context, o *smelinx.VersionListOptions) (*smelinx.Page[smelinx.Version], error) {
+		return c.sdk.ListVersions(ctx, apiID, o)
+	}, opts))
+}
+
+// resolveVersion finds a version of the API by ID or version string.
+func (c *cli) resolveVersion(ctx context.Context, apiRef, ref string) (*smelinx.API, *smelinx.Version, error) {
+	api, err := c.resolveAPI(ctx, apiRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, err := c.listVersions(ctx, api.ID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range list {
+		if list[i].ID == ref || list[i].Version == ref {
+			return api, &list[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("API %s has no version %q", api.Name, ref)
+}
+
+func (c *cli) versions(ctx context.Context, args []string) error {
+	action, args, err := subcommand("versions", args, "list", "create", "update", "deprecate")
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("versions list", flag.ContinueOnError)
+		opts := &smelinx.VersionListOptions{}
+		status := fs.String("status", "", "only these statuses, comma-separated (active, deprecated, sunset)")
+		fs.StringVar(&opts.Sort, "sort", "", "-created_at (default), created_at, version or -version")
+		pos, err := c.parseFlags(fs, args, "api")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		opts.Status = splitList(*status)
+		list, err := c.listVersions(ctx, api.ID, opts)
+		if err != nil {
+			return err
+		}
+		return printList(c, list, versionColumns...)
+
+	case "create":
+		fs := flag.NewFlagSet("versions create", flag.ContinueOnError)
+		status := fs.String("status", "active", "active, deprecated or sunset")
+		sunset := fs.String("sunset", "", "sunset date (YYYY-MM-DD)")
+		pos, err := c.parseFlags(fs, args, "api", "version")
+		if err != nil {
+			return err
+		}
+		api, err := c.resolveAPI(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		req := smelinx.CreateVersionRequest{Version: pos[1], Status: *status}
+		if *sunset != "" {
+			req.SunsetDate = sunset
+		}
+		v, err := c.sdk.CreateVersion(ctx, api.ID, req)
+		if err != nil {
+			return err
+		}
+		return printOne(c, *v, versionColumns...)
+
+	case "update":
+		fs := flag.NewFlagSet("versions update", flag.ContinueOnError)
+		status := fs.String("status", "", "active, deprecated or sunset")
+		sunset := fs.String("sunset", "", `sunset date (YYYY-MM-DD); "none" clears it`)
+		pos, err := c.parseFlags(fs, args, "api", "version")
+		if err != nil {
+			return err
+		}
+		if *status == "" && *sunset == "" {
+			return fmt.Errorf("nothing to update; pass --status and/or --sunset")
+		}
+		return c.updateVersion(ctx, pos[0], pos[1], *status, *sunset)
+
+	default: // deprecate
+		fs := flag.NewFlagSet("versions deprecate", flag.ContinueOnError)
+		sunset := fs.String("sunset", "", "sunset date (YYYY-MM-DD, required)")
+		pos, err := c.parseFlags(fs, args, "api", "version")
+		if err != nil {
+			return err
+		}
+		if *sunset == "" {
+			fs.Usage()
+			return errUsage
+		}
+		return c.updateVersion(ctx, pos[0], pos[1], "deprecated", *sunset)
+	}
+}
+
+// updateVersion changes status and/or sunset date, keeping whichever is
+// not given (the server replaces both).
+func (c *cli) updateVersion(ctx context.Context, apiRef, ref, status, sunset string) error {
+	_, v, err := c.resolveVersion(ctx, apiRef, ref)
+	if err != nil {
+		return err
+	}
+	req := smelinx.UpdateVersionRequest{Status: firstNonEmpty(status, v.Status)}
+	switch sunset {
+	case "none":
+	case "":
+		if v.SunsetDate != nil {
+			d := v.SunsetDate.Format("2006-01-02")
+			req.SunsetDate = &d
+		}
+	default:
+		req.SunsetDate = &sunset
+	}
+	updated, err := c.sdk.UpdateVersion(ctx, v.ID, v.Revision, req)
+	if err != nil {
+		return err
+	}
+	return printOne(c, *updated, versionColumns...)
+}
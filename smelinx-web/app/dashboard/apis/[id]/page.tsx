@@ -187,7 +187,7 @@ export default function ApiDetailsPage() {
   async function saveApiMeta() {
     if (!apiMeta) return;
     try {
-      const updated = await api.updateApi(apiMeta.id, {
+      const updated = await api.updateApi(apiMeta.id, apiMeta.revision, {
         name: editName.trim(),
         description: editDesc.trim() || undefined,
         base_url: baseUrl.trim() || undefined,
@@ -204,7 +204,7 @@ export default function ApiDetailsPage() {
   async function confirmDeleteAPI() {
     if (!apiMeta) return;
     try {
-      await api.deleteApi(apiMeta.id);
+      await api.deleteApi(apiMeta.id, apiMeta.revision);
       router.replace('/dashboard');
     } catch (e: any) {
       alert(e.message || 'Delete failed');
@@ -241,7 +241,7 @@ export default function ApiDetailsPage() {
     }
     if (!editFor) return;
     try {
-      const updated = await api.updateVersionStatus(editFor.id, editStatus, editSunset || undefined);
+      const updated = await api.updateVersionStatus(editFor.id, editFor.revision, editStatus, editSunset || undefined);
       setVersions(prev => prev.map(x => (x.id === editFor.id ? updated : x)));
       setEditOpen(false);
     } catch (e: any) {
@@ -257,7 +257,7 @@ export default function ApiDetailsPage() {
   async function confirmDeleteVersion() {
     if (!deleteV) return;
     try {
-      await api.deleteVersion(deleteV.id);
+      await api.deleteVersion(deleteV.id, deleteV.revision);
       setVersions(prev => prev.filter(x => x.id !== deleteV.id));
       // if we deleted the selected version for notifications, clear or reselect
       setSelectedVersionId(prev => (prev === deleteV.id ? (versions.find(x => x.id !== deleteV.id)?.id || '') : prev));
@@ -302,7 +302,7 @@ export default function ApiDetailsPage() {
 
   async function setNoteStatus(note: Notification, status: Notification['status']) {
     try {
-      const updated = await api.updateNotificationStatus(note.id, status);
+      const updated = await api.updateNotificationStatus(note.id, note.revision, status);
       setNotes(prev => prev.map(n => (n.id === note.id ? updated : n)));
     } catch (e: any) {
       alert(e.message || 'Update failed');
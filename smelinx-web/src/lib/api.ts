@@ -11,6 +11,7 @@ export type APIItem = {
   docs_url?: string | null;
   contact_email?: string | null;
   owner_team?: string | null;
+  revision: number;
 };
 
 export type Version = {
@@ -20,6 +21,7 @@ export type Version = {
   status: "active" | "deprecated" | "sunset";
   sunset_date?: string | null;
   created_at: string;
+  revision: number;
 };
 
 export type Notification = {
@@ -30,8 +32,14 @@ export type Notification = {
   scheduled_at: string;
   status: "pending" | "sent" | "canceled";
   created_at: string;
+  revision: number;
 };
 
+/** One page of a list endpoint; next_cursor is absent on the last page. */
+export type Page<T> = { items: T[]; next_cursor?: string };
+
+export type ListParams = Record<string, string | number | undefined>;
+
 /** Helpers */
 function isFetchResponse(x: any): x is Response {
   return (
@@ -53,7 +61,8 @@ async function parseOrThrow(res: any) {
   let data: any = null;
 
   try {
-    if (contentType.includes("application/json")) {
+    // application/json, or application/problem+json for errors
+    if (/[/+]json\b/.test(contentType)) {
       data = await res.json();
     } else {
       const t = await res.text();
@@ -64,11 +73,17 @@ async function parseOrThrow(res: any) {
   }
 
   if (!res.ok) {
+    // RFC 7807 problem: list the invalid fields, else detail / title
+    const fields: { field: string; message: string }[] = data?.errors || [];
     const message =
-      (data && (data.error || data.message)) ||
+      (fields.length > 0 && fields.map((f) => (f.field ? `${f.field}: ${f.message}` : f.message)).join("; ")) ||
+      (data && (data.detail || data.title || data.error || data.message)) ||
       `Request failed (${res.status})`;
     const err: any = new Error(message);
     err.status = res.status;
+    err.code = data?.code;
+    err.errors = fields;
+    err.requestId = data?.request_id || res.headers.get("x-request-id");
     err.data = data;
     throw err;
   }
@@ -76,17 +91,45 @@ async function parseOrThrow(res: any) {
   return data;
 }
 
+function query(params: ListParams = {}) {
+  const q = new URLSearchParams();
+  for (const [k, v] of Object.entries(params)) {
+    if (v !== undefined && v !== "") q.set(k, String(v));
+  }
+  const s = q.toString();
+  return s ? `?${s}` : "";
+}
+
+// listAll follows next_cursor until the last page.
+async function listAll<T>(path: string, params: ListParams = {}): Promise<T[]> {
+  const out: T[] = [];
+  let cursor: string | undefined;
+  do {
+    const r = await j(`${BASE}${path}${query({ limit: 200, ...params, cursor })}`);
+    const page: Page<T> = await parseOrThrow(r);
+    out.push(...(page?.items ?? []));
+    cursor = page?.next_cursor;
+  } while (cursor);
+  return out;
+}
+
 function j(url: string, init: RequestInit = {}) {
   return fetch(url, {
     credentials: "include",
+    ...init,
     headers: {
       ...(init.body ? { "Content-Type": "application/json" } : {}),
       ...(init.headers || {}),
     },
-    ...init,
   });
 }
 
+// Updates and deletes send the revision they were based on; the server
+// answers 412 when someone else saved in between.
+function ifMatch(revision: number) {
+  return { "If-Match": `"${revision}"` };
+}
+
 /** API client */
 export const api = {
   // ---------- Auth ----------
@@ -128,9 +171,9 @@ export const api = {
   },
 
   // ---------- APIs ----------
-  async listApis(): Promise<APIItem[] | null> {
-    const r = await j(`${BASE}/apis`);
-    return parseOrThrow(r);
+  // params: q, owner_team, sort
+  async listApis(params?: ListParams): Promise<APIItem[]> {
+    return listAll<APIItem>("/apis", params);
   },
 
   async createApi(payload: {
@@ -155,6 +198,7 @@ export const api = {
 
   async updateApi(
     id: string,
+    revision: number,
     payload: {
       name?: string;
       description?: string;
@@ -166,20 +210,21 @@ export const api = {
   ): Promise<APIItem> {
     const r = await j(`${BASE}/apis/${id}`, {
       method: "PUT",
+      headers: ifMatch(revision),
       body: JSON.stringify(payload),
     });
     return parseOrThrow(r);
   },
 
-  async deleteApi(id: string) {
-    const r = await j(`${BASE}/apis/${id}`, { method: "DELETE" });
+  async deleteApi(id: string, revision: number) {
+    const r = await j(`${BASE}/apis/${id}`, { method: "DELETE", headers: ifMatch(revision) });
     return parseOrThrow(r);
   },
 
   // ---------- Versions ----------
-  async listVersions(apiId: string): Promise<Version[] | null> {
-    const r = await j(`${BASE}/apis/${apiId}/versions`);
-    return parseOrThrow(r);
+  // params: status (comma-separated), sort
+  async listVersions(apiId: string, params?: ListParams): Promise<Version[]> {
+    return listAll<Version>(`/apis/${apiId}/versions`, params);
   },
 
   async createVersion(
@@ -197,25 +242,27 @@ export const api = {
 
   async updateVersionStatus(
     versionId: string,
+    revision: number,
     status: "active" | "deprecated" | "sunset",
     sunset_date?: string
   ): Promise<Version> {
     const r = await j(`${BASE}/versions/${versionId}`, {
       method: "PUT",
+      headers: ifMatch(revision),
       body: JSON.stringify({ status, sunset_date }),
     });
     return parseOrThrow(r);
   },
 
-  async deleteVersion(versionId: string) {
-    const r = await j(`${BASE}/versions/${versionId}`, { method: "DELETE" });
+  async deleteVersion(versionId: string, revision: number) {
+    const r = await j(`${BASE}/versions/${versionId}`, { method: "DELETE", headers: ifMatch(revision) });
     return parseOrThrow(r);
   },
 
   // ---------- Notifications ----------
-  async listNotifications(apiId: string): Promise<Notification[] | null> {
-    const r = await j(`${BASE}/apis/${apiId}/notifications`);
-    return parseOrThrow(r);
+  // params: status, type (comma-separated), scheduled_after, scheduled_before, sort
+  async listNotifications(apiId: string, params?: ListParams): Promise<Notification[]> {
+    return listAll<Notification>(`/apis/${apiId}/notifications`, params);
   },
 
   async createNotification(
@@ -231,10 +278,12 @@ export const api = {
 
   async updateNotificationStatus(
     noteId: string,
+    revision: number,
     status: "pending" | "sent" | "canceled"
   ): Promise<Notification> {
     const r = await j(`${BASE}/notifications/${noteId}`, {
       method: "PUT",
+      headers: ifMatch(revision),
       body: JSON.stringify({ status }),
     });
     return parseOrThrow(r);